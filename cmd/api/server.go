@@ -10,6 +10,7 @@ import (
 	mw "schoolManagement/internal/api/middlewares"
 	"schoolManagement/internal/api/routers"
 	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/rbac"
 )
 
 // Even though the user struct is private (not starting with uppercase), the field values after made public (Name, Age and City).
@@ -28,7 +29,13 @@ func main() {
 	}
 
 	var port = os.Getenv("API_PORT")
-	_, err = sqlconnect.ConnectDb()
+	_, err = sqlconnect.ConnectControlDb()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Role permissions: built in unless RBAC_POLICY_FILE names a policy file;
+	err = rbac.LoadPolicy()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -55,8 +62,9 @@ func main() {
 
 	// For this server we will use mw.SecurityHandler alone now;
 	router := routers.MainRouter()
-	jwtMiddleware := mw.MiddleWareExcludePaths(mw.JWTMiddleware, "/execs/login", "/execs/forgot-password", "/execs/reset-password/reset", "/execs/reset-password/reset/")
-	secureMux := jwtMiddleware(mw.SecurityHandler(router))
+	jwtMiddleware := mw.MiddleWareExcludePaths(mw.JWTMiddleware, "/execs/login", "/execs/refresh", "/execs/logout", "/execs/forgot-password", "/execs/reset-password/reset", "/execs/reset-password/reset/", "/admissions/apply", "/files/", "/platform/login")
+	csrfMiddleware := mw.MiddleWareExcludePaths(mw.CSRFMiddleware, "/execs/login", "/execs/forgot-password", "/execs/reset-password/reset", "/admissions/apply", "/platform/login")
+	secureMux := mw.TenantMiddleware(jwtMiddleware(csrfMiddleware(mw.SecurityHandler(router))))
 	//secureMux := mw.XSSMiddleware(router)
 	//secureMux := (mw.SecurityHandler(router))
 
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits for the public application form;
+const maxApplicationBodySize = 16 << 10
+const maxApplicationFieldLength = 255
+
+// validateApplication - Normalises and validates a public application before it is stored;
+func validateApplication(request *models.ApplicationRequest) error {
+	fields := []*string{
+		&request.FirstName, &request.LastName, &request.Email, &request.DateOfBirth, &request.ApplyingForClass,
+		&request.AcademicYear, &request.GuardianName, &request.GuardianEmail, &request.GuardianPhone, &request.PreviousSchool,
+	}
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
+		if len(*field) > maxApplicationFieldLength {
+			return errors.New("Err: Field value too long!")
+		}
+	}
+
+	if request.FirstName == "" || request.LastName == "" || request.Email == "" || request.DateOfBirth == "" ||
+		request.ApplyingForClass == "" || request.GuardianName == "" || request.GuardianEmail == "" {
+		return errors.New("Err: Missing required fields!")
+	}
+
+	for _, email := range []string{request.Email, request.GuardianEmail} {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return errors.New("Err: Invalid email address!")
+		}
+	}
+
+	dateOfBirth, err := time.Parse(time.DateOnly, request.DateOfBirth)
+	if err != nil || dateOfBirth.After(time.Now()) {
+		return errors.New("Err: Date of birth must be a past date in YYYY-MM-DD format!")
+	}
+
+	for _, c := range request.GuardianPhone {
+		if !strings.ContainsRune("0123456789+-() ", c) {
+			return errors.New("Err: Invalid phone number!")
+		}
+	}
+
+	// Applications are for the academic year following the current one unless told otherwise;
+	if request.AcademicYear == "" {
+		currentYear, _ := strconv.Atoi(utils.AcademicYear(time.Now()))
+		request.AcademicYear = strconv.Itoa(currentYear + 1)
+	}
+	_, err = strconv.Atoi(request.AcademicYear)
+	if err != nil {
+		return errors.New("Err: Invalid academic year!")
+	}
+	return nil
+}
+
+// SubmitApplicationHandler - Public (unauthenticated) route to submit an admission application;
+func SubmitApplicationHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxApplicationBodySize)
+
+	var request models.ApplicationRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&request)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = validateApplication(&request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, applicant := sqlconnect.AddApplicantDbHandler(r.Context(), request)
+	if err != nil {
+		http.Error(w, err.Error(), admissionsErrorStatus(err))
+		return
+	}
+
+	// Only the reference and state are echoed back to the public caller;
+	response := struct {
+		Status        string `json:"status"`
+		ApplicationId int    `json:"application_id"`
+		State         string `json:"state"`
+	}{
+		Status:        "Success",
+		ApplicationId: applicant.Id,
+		State:         applicant.Status,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetApplicantsHandler - Lists applicants for the admissions staff;
+func GetApplicantsHandler(w http.ResponseWriter, r *http.Request) {
+	err, applicants := sqlconnect.GetApplicantsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status     string             `json:"status"`
+		Applicants []models.Applicant `json:"applicants"`
+		Count      int                `json:"count"`
+	}{
+		Status:     "Success",
+		Applicants: applicants,
+		Count:      len(applicants),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetApplicantHandler - Gets a single applicant;
+func GetApplicantHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid applicant id!", http.StatusBadRequest)
+		return
+	}
+
+	err, applicant := sqlconnect.GetApplicantDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeApplicant(w, applicant)
+}
+
+// GetApplicantHistoryHandler - Lists every state change of an applicant with its actor and time;
+func GetApplicantHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid applicant id!", http.StatusBadRequest)
+		return
+	}
+
+	err, history := sqlconnect.GetApplicantHistoryDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status  string                       `json:"status"`
+		History []models.ApplicantTransition `json:"history"`
+		Count   int                          `json:"count"`
+	}{
+		Status:  "Success",
+		History: history,
+		Count:   len(history),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// admissionsErrorStatus - Status of an admissions DB error: conflicts for duplicates and invalid transitions, 500 for database failures;
+func admissionsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sqlconnect.ErrApplicantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, sqlconnect.ErrDuplicateApplication), errors.Is(err, sqlconnect.ErrInvalidTransition):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// TransitionApplicantHandler - Moves an applicant through the admissions state machine;
+func TransitionApplicantHandler(w http.ResponseWriter, r *http.Request) {
+	role := utils.GetUserRole(r)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid applicant id!", http.StatusBadRequest)
+		return
+	}
+
+	var request models.ApplicantTransitionRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Status == "" {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if request.Status == "interview_scheduled" {
+		_, err = time.Parse(time.DateTime, request.InterviewAt)
+		if err != nil {
+			http.Error(w, "Err: Interview time must be in YYYY-MM-DD HH:MM:SS format!", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err, applicant := sqlconnect.TransitionApplicantDbHandler(r.Context(), id, request, utils.GetUserId(r), role)
+	if err != nil {
+		http.Error(w, err.Error(), admissionsErrorStatus(err))
+		return
+	}
+
+	writeApplicant(w, applicant)
+}
+
+func writeApplicant(w http.ResponseWriter, applicant models.Applicant) {
+	response := struct {
+		Status    string           `json:"status"`
+		Applicant models.Applicant `json:"applicant"`
+	}{
+		Status:    "Success",
+		Applicant: applicant,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// announcementScope - Resolves which announcements the caller may manage;
+// Holders of announcements:manage (admins) manage every announcement (0), other staff and teachers only the ones they published (their user id);
+func announcementScope(r *http.Request) (int, error) {
+	if callerCan(r, announcementsManage) {
+		return 0, nil
+	}
+	return utils.GetUserId(r), nil
+}
+
+// GetAnnouncementsHandler - Lists the announcements the caller manages, filtered by ?state=scheduled|active|expired;
+func GetAnnouncementsHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, announcements := sqlconnect.GetAnnouncementsDbHandler(r, authorId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeAnnouncements(w, announcements, -1)
+}
+
+// AddAnnouncementHandler - Publishes (or schedules) an announcement;
+func AddAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	_, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var announcement models.Announcement
+	err = json.NewDecoder(r.Body).Decode(&announcement)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	// Server managed fields and defaults;
+	announcement.Id = 0
+	announcement.AuthorId = utils.GetUserId(r)
+	announcement.AuthorRole = utils.GetUserRole(r)
+	announcement.UpdatedAt = ""
+	announcement.ReadAt = ""
+	if announcement.PublishAt == "" {
+		announcement.PublishAt = time.Now().Format(time.DateTime)
+	}
+
+	err = sqlconnect.ValidateAnnouncement(announcement)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, announcement = sqlconnect.AddAnnouncementDbHandler(r.Context(), announcement)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeAnnouncement(w, announcement)
+}
+
+// GetAnnouncementHandler - Gets a single announcement with its audience;
+func GetAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid announcement id!", http.StatusBadRequest)
+		return
+	}
+
+	err, announcement := sqlconnect.GetAnnouncementDbHandler(r.Context(), id, authorId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeAnnouncement(w, announcement)
+}
+
+// PatchAnnouncementHandler - Updates the title, body, pinning or schedule of an announcement;
+func PatchAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid announcement id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, announcement := sqlconnect.PatchAnnouncementDbHandler(r.Context(), id, authorId, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeAnnouncement(w, announcement)
+}
+
+// DeleteAnnouncementHandler - Deletes an announcement;
+func DeleteAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid announcement id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteAnnouncementDbHandler(r.Context(), id, authorId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetAnnouncementReadsHandler - Lists who has read an announcement and when;
+func GetAnnouncementReadsHandler(w http.ResponseWriter, r *http.Request) {
+	authorId, err := announcementScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid announcement id!", http.StatusBadRequest)
+		return
+	}
+
+	err, reads := sqlconnect.GetAnnouncementReadsDbHandler(r.Context(), id, authorId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string                    `json:"status"`
+		Reads  []models.AnnouncementRead `json:"reads"`
+		Count  int                       `json:"count"`
+	}{
+		Status: "Success",
+		Reads:  reads,
+		Count:  len(reads),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetMyAnnouncementsHandler - Personalised announcement feed of the logged-in user (?unread=true for unread only);
+func GetMyAnnouncementsHandler(w http.ResponseWriter, r *http.Request) {
+	err, announcements := sqlconnect.GetAnnouncementFeedDbHandler(r, utils.GetUserId(r), utils.GetUserRole(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	unread := 0
+	for _, announcement := range announcements {
+		if announcement.ReadAt == "" {
+			unread++
+		}
+	}
+
+	writeAnnouncements(w, announcements, unread)
+}
+
+// MarkAnnouncementReadHandler - Records that the logged-in user has read an announcement;
+func MarkAnnouncementReadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid announcement id!", http.StatusBadRequest)
+		return
+	}
+
+	err, readAt := sqlconnect.MarkAnnouncementReadDbHandler(r.Context(), id, utils.GetUserId(r), utils.GetUserRole(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status         string `json:"status"`
+		AnnouncementId int    `json:"announcement_id"`
+		ReadAt         string `json:"read_at"`
+	}{
+		Status:         "Success",
+		AnnouncementId: id,
+		ReadAt:         readAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeAnnouncement(w http.ResponseWriter, announcement models.Announcement) {
+	response := struct {
+		Status       string              `json:"status"`
+		Announcement models.Announcement `json:"announcement"`
+	}{
+		Status:       "Success",
+		Announcement: announcement,
+	}
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeAnnouncements - Writes a list of announcements; unread < 0 leaves the unread count out;
+func writeAnnouncements(w http.ResponseWriter, announcements []models.Announcement, unread int) {
+	response := struct {
+		Status        string                `json:"status"`
+		Announcements []models.Announcement `json:"announcements"`
+		Count         int                   `json:"count"`
+		Unread        *int                  `json:"unread,omitempty"`
+	}{
+		Status:        "Success",
+		Announcements: announcements,
+		Count:         len(announcements),
+	}
+	if unread >= 0 {
+		response.Unread = &unread
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
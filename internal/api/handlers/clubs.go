@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// clubManager - Holders of clubs:manage run every club; teachers only the clubs they supervise;
+func clubManager(r *http.Request, clubId int) error {
+	if callerCan(r, clubsManage) {
+		return nil
+	}
+
+	err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+	if err == nil {
+		err, ok := sqlconnect.IsClubSupervisorDbHandler(r.Context(), clubId, teacherId)
+		if err == nil && ok {
+			return nil
+		}
+	}
+	return errors.New("Err: Access denied!")
+}
+
+// clubPathIds - Reads the club id and, when named, one more numeric path value;
+func clubPathIds(r *http.Request, other string) (int, int, error) {
+	clubId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, 0, errors.New("Err: Invalid club id!")
+	}
+	if other == "" {
+		return clubId, 0, nil
+	}
+
+	otherId, err := strconv.Atoi(r.PathValue(other))
+	if err != nil {
+		return 0, 0, errors.New("Err: Invalid " + other + "!")
+	}
+	return clubId, otherId, nil
+}
+
+func writeClub(w http.ResponseWriter, status int, club models.Club) {
+	response := struct {
+		Status string      `json:"status"`
+		Club   models.Club `json:"club"`
+	}{
+		Status: "Success",
+		Club:   club,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetClubsHandler - Lists clubs, filtered by ?active=true|false or ?teacher_id=;
+func GetClubsHandler(w http.ResponseWriter, r *http.Request) {
+	err, clubs := sqlconnect.GetClubsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string        `json:"status"`
+		Clubs  []models.Club `json:"clubs"`
+		Count  int           `json:"count"`
+	}{
+		Status: "Success",
+		Clubs:  clubs,
+		Count:  len(clubs),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddClubHandler - Creates a club with its supervising teachers and weekly schedule;
+func AddClubHandler(w http.ResponseWriter, r *http.Request) {
+	club := models.Club{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&club)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateClub(club)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, club = sqlconnect.AddClubDbHandler(r.Context(), club)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeClub(w, http.StatusCreated, club)
+}
+
+// GetClubHandler - Fetches a club with its supervisors, schedule and member counts;
+func GetClubHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, club := sqlconnect.GetClubDbHandler(r.Context(), clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeClub(w, http.StatusOK, club)
+}
+
+// PatchClubHandler - Updates the details, capacity, supervisors or schedule of a club;
+func PatchClubHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, club := sqlconnect.PatchClubDbHandler(r.Context(), clubId, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeClub(w, http.StatusOK, club)
+}
+
+// DeleteClubHandler - Deletes a club with its memberships, sessions and attendance;
+func DeleteClubHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteClubDbHandler(r.Context(), clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     clubId,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetClubMembersHandler - Lists members and the waitlist in queue order (?status=active|waitlisted|left);
+func GetClubMembersHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, members := sqlconnect.GetClubMembersDbHandler(r.Context(), clubId, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status  string              `json:"status"`
+		Members []models.ClubMember `json:"members"`
+		Count   int                 `json:"count"`
+	}{
+		Status:  "Success",
+		Members: members,
+		Count:   len(members),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddClubMemberHandler - Enrols a student ({"student_id"}); when the club is full the student is waitlisted;
+func AddClubMemberHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = clubManager(r, clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var request struct {
+		StudentId int `json:"student_id"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.StudentId <= 0 {
+		http.Error(w, "Err: Invalid request body, student_id is required!", http.StatusBadRequest)
+		return
+	}
+
+	err, member := sqlconnect.JoinClubDbHandler(r.Context(), clubId, request.StudentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string            `json:"status"`
+		Member models.ClubMember `json:"member"`
+	}{
+		Status: "Success",
+		Member: member,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RemoveClubMemberHandler - Takes a student out of a club or its waitlist; the freed place goes to the next in line;
+func RemoveClubMemberHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, studentId, err := clubPathIds(r, "studentId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = clubManager(r, clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, promoted := sqlconnect.LeaveClubDbHandler(r.Context(), clubId, studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status   string `json:"status"`
+		Id       int    `json:"id"`
+		Promoted []int  `json:"promoted_student_ids"`
+	}{
+		Status:   "Success",
+		Id:       studentId,
+		Promoted: promoted,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetClubSessionsHandler - Lists the sessions of a club with attendance totals;
+func GetClubSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, sessions := sqlconnect.GetClubSessionsDbHandler(r.Context(), clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status   string               `json:"status"`
+		Sessions []models.ClubSession `json:"sessions"`
+		Count    int                  `json:"count"`
+	}{
+		Status:   "Success",
+		Sessions: sessions,
+		Count:    len(sessions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddClubSessionHandler - Records a club session ({"session_date", "start_time", "notes"});
+func AddClubSessionHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = clubManager(r, clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var session models.ClubSession
+	err = json.NewDecoder(r.Body).Decode(&session)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	_, err = time.Parse(time.DateOnly, session.SessionDate)
+	if err != nil {
+		http.Error(w, "Err: Session date must be in YYYY-MM-DD format!", http.StatusBadRequest)
+		return
+	}
+	if session.StartTime == "" {
+		session.StartTime = "00:00:00"
+	}
+	_, err = time.Parse(time.TimeOnly, session.StartTime)
+	if err != nil {
+		http.Error(w, "Err: Start time must be in HH:MM:SS format!", http.StatusBadRequest)
+		return
+	}
+
+	err, club := sqlconnect.GetClubDbHandler(r.Context(), clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !club.Active {
+		http.Error(w, "Err: The club is not active!", http.StatusBadRequest)
+		return
+	}
+
+	session.ClubId = clubId
+	session.CreatedBy = utils.GetUserId(r)
+	err, session = sqlconnect.AddClubSessionDbHandler(r.Context(), session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status  string             `json:"status"`
+		Session models.ClubSession `json:"session"`
+	}{
+		Status:  "Success",
+		Session: session,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetSessionAttendanceHandler - The register of a session; unmarked members have an empty status;
+func GetSessionAttendanceHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, sessionId, err := clubPathIds(r, "sessionId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, register := sqlconnect.GetSessionAttendanceDbHandler(r.Context(), clubId, sessionId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status     string                  `json:"status"`
+		Attendance []models.ClubAttendance `json:"attendance"`
+		Count      int                     `json:"count"`
+	}{
+		Status:     "Success",
+		Attendance: register,
+		Count:      len(register),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SaveSessionAttendanceHandler - Marks members present, absent or excused ([{"student_id", "status"}]);
+func SaveSessionAttendanceHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, sessionId, err := clubPathIds(r, "sessionId")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = clubManager(r, clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var records []models.ClubAttendance
+	err = json.NewDecoder(r.Body).Decode(&records)
+	if err != nil || len(records) == 0 {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.SaveSessionAttendanceDbHandler(r.Context(), clubId, sessionId, records)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, register := sqlconnect.GetSessionAttendanceDbHandler(r.Context(), clubId, sessionId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status     string                  `json:"status"`
+		Attendance []models.ClubAttendance `json:"attendance"`
+		Count      int                     `json:"count"`
+	}{
+		Status:     "Success",
+		Attendance: register,
+		Count:      len(register),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetClubReportHandler - Participation report of a club: sessions held while each student was a member and their attendance;
+func GetClubReportHandler(w http.ResponseWriter, r *http.Request) {
+	clubId, _, err := clubPathIds(r, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, club, report := sqlconnect.GetClubReportDbHandler(r.Context(), clubId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status        string                     `json:"status"`
+		Club          models.Club                `json:"club"`
+		Participation []models.ClubParticipation `json:"participation"`
+		Count         int                        `json:"count"`
+	}{
+		Status:        "Success",
+		Club:          club,
+		Participation: report,
+		Count:         len(report),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStudentActivitiesHandler - The clubs and activities of a student with their attendance;
+func GetStudentActivitiesHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, activities := sqlconnect.GetStudentActivitiesDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status     string                     `json:"status"`
+		Activities []models.ClubParticipation `json:"activities"`
+		Count      int                        `json:"count"`
+	}{
+		Status:     "Success",
+		Activities: activities,
+		Count:      len(activities),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/storage"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits for uploaded documents;
+const maxDocumentSize = 10 << 20
+
+// documentFormMemory - Part of a multipart upload kept in memory, the rest is spooled to disk;
+const documentFormMemory = 1 << 20
+
+// defaultDownloadLinkTTL - Validity of signed download links unless DOWNLOAD_URL_TTL is set;
+const defaultDownloadLinkTTL = 5 * time.Minute
+
+// allowedDocumentTypes - Content types (as sniffed from the data) accepted as documents;
+var allowedDocumentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// documentAccess - Holders of documents:all handle every document; teachers may only read their own;
+func documentAccess(r *http.Request, ownerType string, ownerId int, write bool) error {
+	if callerCan(r, documentsAll) {
+		return nil
+	}
+
+	if !write && ownerType == "teacher" {
+		err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+		if err == nil && teacherId == ownerId {
+			return nil
+		}
+	}
+	return errors.New("Err: Access denied!")
+}
+
+// storeUpload - Validates the "file" part of a multipart request and writes it to storage;
+// The returned version carries the storage key, which the caller must delete if saving the metadata fails;
+func storeUpload(w http.ResponseWriter, r *http.Request, store storage.Store, ownerType string, ownerId int) (models.DocumentVersion, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize+documentFormMemory)
+	err := r.ParseMultipartForm(documentFormMemory)
+	if err != nil {
+		return models.DocumentVersion{}, errors.New("Err: Invalid upload, expected multipart form data with a file of at most 10MB!")
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return models.DocumentVersion{}, errors.New("Err: File is required!")
+	}
+	defer file.Close()
+
+	if header.Size == 0 || header.Size > maxDocumentSize {
+		return models.DocumentVersion{}, errors.New("Err: File must be between 1 byte and 10MB!")
+	}
+
+	// The declared type is not trusted;
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return models.DocumentVersion{}, errors.New("Err: Cannot read file!")
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	extension, ok := allowedDocumentTypes[contentType]
+	if !ok {
+		return models.DocumentVersion{}, errors.New("Err: File type not allowed, use PDF, JPEG or PNG!")
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return models.DocumentVersion{}, errors.New("Err: Cannot read file!")
+	}
+
+	random := make([]byte, 16)
+	_, err = rand.Read(random)
+	if err != nil {
+		return models.DocumentVersion{}, utils.HandleError(err, "Err: Cannot store file!")
+	}
+
+	fileName := filepath.Base(strings.TrimSpace(header.Filename))
+	if fileName == "." || fileName == "/" || fileName == "" {
+		fileName = "document" + extension
+	}
+
+	version := models.DocumentVersion{
+		FileName:    fileName,
+		ContentType: contentType,
+		StorageKey:  fmt.Sprintf("documents/%s/%d/%s%s", ownerType, ownerId, hex.EncodeToString(random), extension),
+		UploadedBy:  utils.GetUserId(r),
+	}
+
+	hash := sha256.New()
+	version.Size, err = store.Put(version.StorageKey, io.TeeReader(file, hash))
+	if err != nil {
+		return models.DocumentVersion{}, err
+	}
+	version.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return version, nil
+}
+
+// discardUpload - Removes a stored file whose metadata could not be saved; failures are only logged;
+func discardUpload(store storage.Store, key string) {
+	err := store.Delete(key)
+	if err != nil {
+		log.Println("Orphaned upload not removed : ", key, err)
+	}
+}
+
+// GetStudentDocumentsHandler - Lists the documents of a student (?category=);
+func GetStudentDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	getDocuments(w, r, "student")
+}
+
+// GetTeacherDocumentsHandler - Lists the documents of a teacher (?category=);
+func GetTeacherDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	getDocuments(w, r, "teacher")
+}
+
+func getDocuments(w http.ResponseWriter, r *http.Request, ownerType string) {
+	ownerId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+ownerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if ownerType == "student" && !studentVisible(w, r, ownerId) {
+		return
+	}
+
+	err = documentAccess(r, ownerType, ownerId, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, documents := sqlconnect.GetDocumentsDbHandler(r, ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status    string            `json:"status"`
+		Documents []models.Document `json:"documents"`
+		Count     int               `json:"count"`
+	}{
+		Status:    "Success",
+		Documents: documents,
+		Count:     len(documents),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddStudentDocumentHandler - Uploads a new document for a student (multipart: file, category, title);
+func AddStudentDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	addDocument(w, r, "student")
+}
+
+// AddTeacherDocumentHandler - Uploads a new document for a teacher (multipart: file, category, title);
+func AddTeacherDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	addDocument(w, r, "teacher")
+}
+
+func addDocument(w http.ResponseWriter, r *http.Request, ownerType string) {
+	ownerId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+ownerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if ownerType == "student" && !studentVisible(w, r, ownerId) {
+		return
+	}
+
+	err = documentAccess(r, ownerType, ownerId, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	version, err := storeUpload(w, r, store, ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	document := models.Document{
+		OwnerType: ownerType,
+		OwnerId:   ownerId,
+		Category:  r.FormValue("category"),
+		Title:     strings.TrimSpace(r.FormValue("title")),
+		CreatedBy: utils.GetUserId(r),
+	}
+	if document.Title == "" {
+		document.Title = version.FileName
+	}
+
+	err = sqlconnect.ValidateDocument(document)
+	if err != nil {
+		discardUpload(store, version.StorageKey)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, document = sqlconnect.AddDocumentDbHandler(r.Context(), document, version)
+	if err != nil {
+		discardUpload(store, version.StorageKey)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeDocument(w, http.StatusCreated, document)
+}
+
+// GetDocumentHandler - Returns a document with its version history;
+func GetDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid document id!", http.StatusBadRequest)
+		return
+	}
+
+	err, document := sqlconnect.GetDocumentDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = documentAccess(r, document.OwnerType, document.OwnerId, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	writeDocument(w, http.StatusOK, document)
+}
+
+// AddDocumentVersionHandler - Uploads a new version of a document (multipart: file); older versions are kept;
+func AddDocumentVersionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid document id!", http.StatusBadRequest)
+		return
+	}
+
+	err, document, _ := sqlconnect.GetDocumentVersionDbHandler(r.Context(), id, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = documentAccess(r, document.OwnerType, document.OwnerId, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	version, err := storeUpload(w, r, store, document.OwnerType, document.OwnerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	version.DocumentId = id
+
+	err, document = sqlconnect.AddDocumentVersionDbHandler(r.Context(), version)
+	if err != nil {
+		discardUpload(store, version.StorageKey)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeDocument(w, http.StatusCreated, document)
+}
+
+// DeleteDocumentHandler - Deletes a document with all its versions and files (admin and manager only);
+func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid document id!", http.StatusBadRequest)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err, keys := sqlconnect.DeleteDocumentDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	for _, key := range keys {
+		discardUpload(store, key)
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeDocument(w http.ResponseWriter, status int, document models.Document) {
+	response := struct {
+		Status   string          `json:"status"`
+		Document models.Document `json:"document"`
+	}{
+		Status:   "Success",
+		Document: document,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// documentFilePath - Path of the public download endpoint for a document version;
+// The school is part of the signed path, so a link cannot be replayed against another school's document of the same id;
+func documentFilePath(tenant string, id, version int) string {
+	return fmt.Sprintf("/files/documents/%d/versions/%d?tenant=%s", id, version, url.QueryEscape(tenant))
+}
+
+// GetDocumentLinkHandler - Issues a short lived signed download URL for a document version (?version=, default current);
+func GetDocumentLinkHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid document id!", http.StatusBadRequest)
+		return
+	}
+
+	versionNumber := 0
+	if value := r.URL.Query().Get("version"); value != "" {
+		versionNumber, err = strconv.Atoi(value)
+		if err != nil || versionNumber < 1 {
+			http.Error(w, "Err: Invalid version!", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err, document, version := sqlconnect.GetDocumentVersionDbHandler(r.Context(), id, versionNumber)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = documentAccess(r, document.OwnerType, document.OwnerId, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ttl := defaultDownloadLinkTTL
+	if value := os.Getenv("DOWNLOAD_URL_TTL"); value != "" {
+		ttl, err = time.ParseDuration(value)
+		if err != nil {
+			http.Error(w, utils.HandleError(err, "Err: Invalid DOWNLOAD_URL_TTL!").Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	path := documentFilePath(utils.GetTenant(r), document.Id, version.Version)
+	expires, signature := utils.SignDownloadPath(path, ttl)
+
+	response := struct {
+		Status string              `json:"status"`
+		Link   models.DocumentLink `json:"link"`
+	}{
+		Status: "Success",
+		Link: models.DocumentLink{
+			Url:       fmt.Sprintf("%s&expires=%d&signature=%s", path, expires, signature),
+			ExpiresAt: time.Unix(expires, 0).Format(time.DateTime),
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DownloadDocumentHandler - Streams a document version; authorized by the signed URL instead of the JWT;
+func DownloadDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid document id!", http.StatusBadRequest)
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || versionNumber < 1 {
+		http.Error(w, "Err: Invalid version!", http.StatusBadRequest)
+		return
+	}
+
+	err = utils.VerifyDownloadPath(documentFilePath(utils.GetTenant(r), id, versionNumber), r.URL.Query().Get("expires"), r.URL.Query().Get("signature"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, _, version := sqlconnect.GetDocumentVersionDbHandler(r.Context(), id, versionNumber)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file, err := store.Get(version.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Err: File missing from storage!", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", version.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(version.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": version.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	_, err = io.Copy(w, file)
+	if err != nil {
+		log.Println("Document download interrupted : ", err)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"strconv"
+)
+
+// GetStudentEnrollmentsHandler - Returns the class history of a student across academic years;
+func GetStudentEnrollmentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, id) {
+		return
+	}
+
+	err, enrollments := sqlconnect.GetStudentEnrollmentsDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeEnrollments(w, enrollments)
+}
+
+// GetEnrollmentsHandler - Searches enrollments by class, academic year, status, student or date;
+func GetEnrollmentsHandler(w http.ResponseWriter, r *http.Request) {
+	err, enrollments := sqlconnect.GetEnrollmentsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeEnrollments(w, enrollments)
+}
+
+func writeEnrollments(w http.ResponseWriter, enrollments []models.Enrollment) {
+	response := struct {
+		Status      string              `json:"status"`
+		Enrollments []models.Enrollment `json:"enrollments"`
+		Count       int                 `json:"count"`
+	}{
+		Status:      "Success",
+		Enrollments: enrollments,
+		Count:       len(enrollments),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
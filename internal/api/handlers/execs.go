@@ -6,12 +6,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"github.com/go-mail/mail/v2"
 	"log"
 	"net/http"
 	"os"
 	"schoolManagement/internal/models"
 	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/rbac"
 	"schoolManagement/pkg/utils"
 	"strconv"
 	"time"
@@ -93,7 +93,7 @@ func PatchExecsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = sqlconnect.PatchExecsDbHandler(updates)
+	err = sqlconnect.PatchExecsDbHandler(r.Context(), updates)
 	if err != nil {
 		fmt.Println("Error: Failed to patch execs!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -114,7 +114,7 @@ func DeleteExecsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, deletedIds := sqlconnect.DeleteStudentsDbHandler(ids)
+	err, deletedIds := sqlconnect.DeleteStudentsDbHandler(r.Context(), ids)
 	if err != nil {
 		fmt.Println("Error: Failed to delete students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -146,7 +146,7 @@ func GetExecByIdHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err, exec := sqlconnect.GetExecsByIdHandler(id)
+	err, exec := sqlconnect.GetExecsByIdHandler(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -185,7 +185,7 @@ func PatchExecByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, _ = sqlconnect.PatchExecByIdDbHandler(id, updates)
+	err, _ = sqlconnect.PatchExecByIdDbHandler(r.Context(), id, updates)
 	if err != nil {
 		fmt.Println("Error: Failed to patch students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -204,7 +204,7 @@ func DeleteExecByIdHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	}
 
-	err = sqlconnect.DeleteExecByIdDbHandler(id)
+	err = sqlconnect.DeleteExecByIdDbHandler(r.Context(), id)
 	if err != nil {
 		fmt.Println("Error: Failed to delete exec!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -237,71 +237,73 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Back-off and lockout after failed logins;
+	if checkLoginWait(w, r, req.Username) {
+		return
+	}
+
 	// Search for user;
 	user := &models.Exec{}
-	err = sqlconnect.LoginDbHandler(req.Username, user)
+	err, found := sqlconnect.LoginDbHandler(r.Context(), req.Username, user)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	// Is user active;
-	if user.Inactive {
-		http.Error(w, "Err: User is inactive!", http.StatusForbidden)
+
+	// Verify password; unknown usernames are checked against a dummy hash and fail like a wrong password;
+	if !found {
+		_ = utils.PasswordValidate(dummyPasswordHash(), req.Password)
+		failLogin(w, r, req.Username, nil, invalidLoginMessage)
 		return
 	}
-
-	// Verify password;
-	log.Println(req.Username, user.Password)
 	err = utils.PasswordValidate(user.Password, req.Password)
 	if err != nil {
 		_ = utils.HandleError(err, "Err: Error from password validate!")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		failLogin(w, r, req.Username, user, invalidLoginMessage)
 		return
 	}
 
-	// Generate JWT token;
-	usrId := strconv.Itoa(user.Id)
-	token, err := utils.SignToken(usrId, req.Username, user.Role)
-	if err != nil {
-		http.Error(w, "Err: Token generation failed!", http.StatusInternalServerError)
+	// Is user active; only told to someone who knows the password;
+	if user.Inactive {
+		http.Error(w, "Err: Account is inactive!", http.StatusForbidden)
+		return
 	}
 
-	// Send token as a response/cookie;
-	http.SetCookie(w, &http.Cookie{
-		Name:     "Bearer",
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		Expires:  time.Now().Add(24 * time.Hour),
-		SameSite: http.SameSiteStrictMode,
-	})
+	// With two-factor authentication the password only earns a challenge for the second step, failures are kept until then;
+	if challengeSecondFactor(w, r, *user) {
+		return
+	}
 
-	// Response;
-	response := struct {
-		Status string `json:"status"`
-		Token  string `json:"token"`
-	}{
-		Status: "Success",
-		Token:  token,
+	err = clearLoginFailures(r, req.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(response)
+	// Issue the access and refresh tokens;
+	startSession(w, r, *user)
 }
 
+// LogoutHandler - Revokes the access token and the session of the refresh token, if any, and clears both cookies;
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "Bearer",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		Expires:  time.Unix(0, 0),
-		SameSite: http.SameSiteStrictMode,
-	})
+	err := revokeAccessToken(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r, tokenHash, err := refreshTokenRequest(r)
+	if err == nil {
+		err = sqlconnect.RevokeSessionDbHandler(r.Context(), tokenHash)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	clearSessionCookies(w)
 
 	w.Header().Set("Content-Type", "application/json")
-	_, err := w.Write([]byte("Logged out!"))
+	_, err = w.Write([]byte("Logged out!"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -316,6 +318,13 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Anyone may change their own password with the current one; resetting another user's takes execs:admin instead;
+	ownPassword := userId == utils.GetUserId(r)
+	if !ownPassword && !rbac.Can(utils.GetUserRole(r), "execs:admin") {
+		http.Error(w, "Err: Permission execs:admin required!", http.StatusForbidden)
+		return
+	}
+
 	var request models.UpdatePasswordRequest
 	err = json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
@@ -323,23 +332,27 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if request.CurrentPassword == "" || request.NewPassword == "" {
+	if (ownPassword && request.CurrentPassword == "") || request.NewPassword == "" {
 		http.Error(w, "Err: Current or new password is empty!", http.StatusBadRequest)
 		return
 	}
 
-	err, token := sqlconnect.UpdatePasswordDbHandler(userId, request)
+	err, exec := sqlconnect.UpdatePasswordDbHandler(r.Context(), userId, request, ownPassword)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// The change ended every session of the user; one changing their own password continues in a new one;
+	if ownPassword {
+		startSession(w, r, exec)
+		return
+	}
+
 	response := struct {
 		Status string `json:"status"`
-		Token  string `json:"token"`
 	}{
 		Status: "Success",
-		Token:  token,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -348,7 +361,6 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
 }
 
 func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
@@ -369,7 +381,7 @@ func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var exec models.Exec
-	err = sqlconnect.ForgotPasswordDbHandler(request.Email, &exec)
+	err = sqlconnect.ForgotPasswordDbHandler(r.Context(), request.Email, &exec)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -396,27 +408,20 @@ func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	hashedToken := sha256.Sum256(tokenBytes)
 	hashedTokenString := hex.EncodeToString(hashedToken[:])
 
-	err = sqlconnect.ForgotPasswordUpdateDbHandler(exec, hashedTokenString, expiry)
+	err = sqlconnect.ForgotPasswordUpdateDbHandler(r.Context(), exec, hashedTokenString, expiry)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Sending email;
-	resetUrl := fmt.Sprintf("https://localhost:3000/execs/reset-password/reset/%s", token)
+	resetUrl := fmt.Sprintf("https://localhost:3000/execs/reset-password/reset/%s?tenant=%s", token, utils.GetTenant(r))
 	message := fmt.Sprintf("Forgot your password? Reset your password using the following link,\n%s\nThis reset link is valid for %d minutes!", resetUrl, duration)
 
-	m := mail.NewMessage()
-	m.SetHeader("From", "schooladmin@school.com")
-	m.SetHeader("To", request.Email)
-	m.SetHeader("Subject", "Password reset link")
-	m.SetBody("text/html", message)
-
 	log.Println("Email generated : ", message)
 	log.Println("Sending email to ", request.Email)
 
-	d := mail.NewDialer("localhost", 1025, "", "")
-	err = d.DialAndSend(m)
+	err = utils.SendMail(request.Email, "Password reset link", message)
 	if err != nil {
 		http.Error(w, "Err: Mail sending failed!", http.StatusInternalServerError)
 		return
@@ -453,7 +458,7 @@ func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var exec models.Exec
-	err = sqlconnect.ResetPasswordDbHandler(token, &exec)
+	err = sqlconnect.ResetPasswordDbHandler(r.Context(), token, &exec)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -465,7 +470,7 @@ func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 
-	err = sqlconnect.ForgetPasswordResetDbHandler(&exec, hashedPassword)
+	err = sqlconnect.ForgetPasswordResetDbHandler(r.Context(), &exec, hashedPassword)
 	if err != nil {
 		http.Error(w, "Err: Password reset failed!", http.StatusInternalServerError)
 		return
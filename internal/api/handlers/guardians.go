@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"strconv"
+)
+
+// GetStudentGuardiansHandler - Lists the guardian accounts linked to a student;
+func GetStudentGuardiansHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, guardians := sqlconnect.GetStudentGuardiansDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status    string                   `json:"status"`
+		Guardians []models.StudentGuardian `json:"guardians"`
+		Count     int                      `json:"count"`
+	}{
+		Status:    "Success",
+		Guardians: guardians,
+		Count:     len(guardians),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddStudentGuardianHandler - Links a guardian account (exec with the guardian role) to a student;
+func AddStudentGuardianHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	var guardian models.StudentGuardian
+	err = json.NewDecoder(r.Body).Decode(&guardian)
+	if err != nil || guardian.ExecId == 0 {
+		http.Error(w, "Err: exec_id is required!", http.StatusBadRequest)
+		return
+	}
+	guardian.StudentId = studentId
+
+	err, guardian = sqlconnect.AddStudentGuardianDbHandler(r.Context(), guardian)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status   string                 `json:"status"`
+		Guardian models.StudentGuardian `json:"guardian"`
+	}{
+		Status:   "Success",
+		Guardian: guardian,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteStudentGuardianHandler - Unlinks a guardian account from a student;
+func DeleteStudentGuardianHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	execId, err := strconv.Atoi(r.PathValue("execId"))
+	if err != nil {
+		http.Error(w, "Err: Invalid guardian id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteStudentGuardianDbHandler(r.Context(), studentId, execId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status    string `json:"status"`
+		StudentId int    `json:"student_id"`
+		ExecId    int    `json:"exec_id"`
+	}{
+		Status:    "Success",
+		StudentId: studentId,
+		ExecId:    execId,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/storage"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// ID card layout in points: CR80 cards (85.6 x 54 mm), 2 x 5 per A4 sheet, ready to cut;
+const (
+	idCardWidth   = 242.65
+	idCardHeight  = 153.07
+	idCardColumns = 2
+	idCardRows    = 5
+	idCardGapX    = 20
+	idCardGapY    = 10
+)
+
+// GetStudentIdCardHandler - Renders the ID card of a student as a PDF;
+func GetStudentIdCardHandler(w http.ResponseWriter, r *http.Request) {
+	idCards(w, r, "student")
+}
+
+// GetTeacherIdCardHandler - Renders the staff ID card of a teacher as a PDF;
+func GetTeacherIdCardHandler(w http.ResponseWriter, r *http.Request) {
+	idCards(w, r, "teacher")
+}
+
+// GetClassIdCardsHandler - Renders the ID cards of every active student of a class (?class=) as a PDF;
+func GetClassIdCardsHandler(w http.ResponseWriter, r *http.Request) {
+	idCards(w, r, "class")
+}
+
+func idCards(w http.ResponseWriter, r *http.Request, scope string) {
+	kind := scope
+	id := 0
+	class := ""
+	fileName := ""
+	var err error
+	if scope == "class" {
+		kind = "student"
+		class = r.URL.Query().Get("class")
+		if class == "" {
+			http.Error(w, "Err: Class is required!", http.StatusBadRequest)
+			return
+		}
+		fileName = "id-cards-" + class
+	} else {
+		id, err = strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Err: Invalid "+kind+" id!", http.StatusBadRequest)
+			return
+		}
+		fileName = fmt.Sprintf("id-card-%s-%d", kind, id)
+		if kind == "student" && !studentVisible(w, r, id) {
+			return
+		}
+	}
+
+	err, cards := sqlconnect.GetIdCardsDbHandler(r.Context(), kind, id, class)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pdf, err := idCardsPDF(store, cards)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", strings.Map(safeFileNameRune, fileName)))
+	_, err = w.Write(pdf)
+	if err != nil {
+		log.Println("ID card download interrupted : ", err)
+	}
+}
+
+// AssignStudentIdsHandler - Assigns roll numbers to students created before ids were generated;
+func AssignStudentIdsHandler(w http.ResponseWriter, r *http.Request) {
+	assignMissingIds(w, r, "student")
+}
+
+// AssignTeacherIdsHandler - Assigns employee ids to teachers created before ids were generated;
+func AssignTeacherIdsHandler(w http.ResponseWriter, r *http.Request) {
+	assignMissingIds(w, r, "teacher")
+}
+
+func assignMissingIds(w http.ResponseWriter, r *http.Request, kind string) {
+	err, assigned := sqlconnect.AssignMissingIdsDbHandler(r.Context(), kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status   string `json:"status"`
+		Assigned int    `json:"assigned"`
+	}{
+		Status:   "Success",
+		Assigned: assigned,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// safeFileNameRune - Keeps file names in headers to letters, digits, dashes and underscores;
+func safeFileNameRune(r rune) rune {
+	if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+		return r
+	}
+	return '_'
+}
+
+// idCardsPDF - Lays the cards out on A4 sheets; photos are taken from the stored "medium" variant;
+func idCardsPDF(store storage.Store, cards []models.IdCard) ([]byte, error) {
+	schoolName := os.Getenv("SCHOOL_NAME")
+	if schoolName == "" {
+		schoolName = "School"
+	}
+
+	doc := utils.NewPDFDocument(utils.PDFA4Width, utils.PDFA4Height)
+	marginX := (utils.PDFA4Width - idCardColumns*idCardWidth - (idCardColumns-1)*idCardGapX) / 2
+	marginY := (utils.PDFA4Height - idCardRows*idCardHeight - (idCardRows-1)*idCardGapY) / 2
+
+	var page *utils.PDFPage
+	for i, card := range cards {
+		slot := i % (idCardColumns * idCardRows)
+		if slot == 0 {
+			page = doc.AddPage()
+		}
+		column := slot % idCardColumns
+		row := slot / idCardColumns
+		x := marginX + float64(column)*(idCardWidth+idCardGapX)
+		y := utils.PDFA4Height - marginY - float64(row+1)*idCardHeight - float64(row)*idCardGapY
+
+		err := drawIdCard(doc, page, store, card, schoolName, x, y)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc.Bytes(), nil
+}
+
+// drawIdCard - Draws one card with its bottom-left corner at (x, y);
+func drawIdCard(doc *utils.PDFDocument, page *utils.PDFPage, store storage.Store, card models.IdCard, schoolName string, x, y float64) error {
+	page.Rect(x, y, idCardWidth, idCardHeight, false)
+	page.Text(x+10, y+idCardHeight-18, 11, true, schoolName)
+	title := "STUDENT ID CARD"
+	if card.Kind == "teacher" {
+		title = "STAFF ID CARD"
+	}
+	page.Text(x+10, y+idCardHeight-29, 7, false, title)
+	page.Line(x+10, y+idCardHeight-34, x+idCardWidth-10, y+idCardHeight-34, 0.5)
+
+	// Photo box, 60 x 75;
+	photoX, photoY, photoW, photoH := x+10, y+36, 60.0, 75.0
+	drawn := false
+	if card.PhotoPrefix != "" {
+		name, width, height, err := loadIdCardPhoto(doc, store, card.PhotoPrefix)
+		if err != nil {
+			log.Println("ID card photo skipped : ", card.Kind, card.Id, err)
+		} else {
+			// Fit the photo into the box keeping its aspect ratio;
+			scale := min(photoW/float64(width), photoH/float64(height))
+			w, h := float64(width)*scale, float64(height)*scale
+			page.Image(name, photoX+(photoW-w)/2, photoY+(photoH-h)/2, w, h)
+			drawn = true
+		}
+	}
+	if !drawn {
+		page.Rect(photoX, photoY, photoW, photoH, false)
+		page.Text(photoX+12, photoY+35, 7, false, "No photo")
+	}
+
+	textX := x + 80
+	page.Text(textX, y+98, 10, true, card.FirstName+" "+card.LastName)
+	if card.Kind == "teacher" {
+		page.Text(textX, y+84, 8, false, "Subject: "+card.Subject)
+	} else {
+		page.Text(textX, y+84, 8, false, "Class: "+card.Class)
+	}
+	page.Text(textX, y+72, 8, true, "ID: "+card.Number)
+
+	err := page.Barcode128(textX, y+22, idCardWidth-textX+x-10, 34, card.Number)
+	if err != nil {
+		return err
+	}
+	page.Text(textX, y+12, 7, false, card.Number)
+	return nil
+}
+
+// loadIdCardPhoto - Reads the medium photo variant (a baseline JPEG) and embeds it in the document;
+func loadIdCardPhoto(doc *utils.PDFDocument, store storage.Store, prefix string) (string, int, int, error) {
+	file, err := store.Get(photoKey(prefix, "medium"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", 0, 0, errors.New("photo file missing")
+		}
+		return "", 0, 0, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return doc.AddJPEG(data)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// incidentScope - Resolves which incidents the caller may see;
+// Holders of incidents:all (admins and counsellors) see every incident (0), others only the ones they filed (their user id);
+func incidentScope(r *http.Request) int {
+	if callerCan(r, incidentsAll) {
+		return 0
+	}
+	return utils.GetUserId(r)
+}
+
+// GetIncidentsHandler - Lists the incidents visible to the caller;
+func GetIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	reportedBy := incidentScope(r)
+
+	err, incidents := sqlconnect.GetIncidentsDbHandler(r, reportedBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status    string            `json:"status"`
+		Incidents []models.Incident `json:"incidents"`
+		Count     int               `json:"count"`
+	}{
+		Status:    "Success",
+		Incidents: incidents,
+		Count:     len(incidents),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetIncidentHandler - Gets a single incident if it is visible to the caller;
+func GetIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	reportedBy := incidentScope(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid incident id!", http.StatusBadRequest)
+		return
+	}
+
+	err, incident := sqlconnect.GetIncidentDbHandler(r.Context(), id, reportedBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status   string          `json:"status"`
+		Incident models.Incident `json:"incident"`
+	}{
+		Status:   "Success",
+		Incident: incident,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddIncidentHandler - Files a new incident; teachers are recorded as the reporting teacher automatically;
+func AddIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	var incident models.Incident
+	err := json.NewDecoder(r.Body).Decode(&incident)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	// Server managed fields and defaults;
+	incident.Id = 0
+	incident.CreatedAt = ""
+	incident.ReportedBy = utils.GetUserId(r)
+	if incident.IncidentDate == "" {
+		incident.IncidentDate = time.Now().Format(time.DateOnly)
+	}
+	if incident.Severity == "" {
+		incident.Severity = "low"
+	}
+	if incident.FollowUpStatus == "" {
+		incident.FollowUpStatus = "open"
+	}
+
+	if !callerCan(r, incidentsAll) {
+		err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), incident.ReportedBy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		incident.ReportingTeacherId = teacherId
+	}
+
+	if incident.ReportingTeacherId == 0 {
+		http.Error(w, "Err: Reporting teacher is required!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateIncident(incident)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, incident = sqlconnect.AddIncidentDbHandler(r.Context(), incident)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := struct {
+		Status   string          `json:"status"`
+		Incident models.Incident `json:"incident"`
+	}{
+		Status:   "Success",
+		Incident: incident,
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// PatchIncidentHandler - Updates the follow-up, actions or other details of an incident;
+func PatchIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	reportedBy := incidentScope(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid incident id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	// Teachers cannot re-assign the incident to another reporting teacher;
+	if reportedBy > 0 {
+		delete(updates, "reporting_teacher_id")
+	}
+
+	err, incident := sqlconnect.PatchIncidentDbHandler(r.Context(), id, reportedBy, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status   string          `json:"status"`
+		Incident models.Incident `json:"incident"`
+	}{
+		Status:   "Success",
+		Incident: incident,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteIncidentHandler - Deletes an incident (admin only);
+func DeleteIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid incident id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteIncidentDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStudentIncidentSummaryHandler - Incident counts for a single student;
+func GetStudentIncidentSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	reportedBy := incidentScope(r)
+
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	err, summary := sqlconnect.GetStudentIncidentSummaryDbHandler(r.Context(), studentId, reportedBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeIncidentSummary(w, summary)
+}
+
+// GetClassIncidentSummaryHandler - Incident counts for all students of a class;
+func GetClassIncidentSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	reportedBy := incidentScope(r)
+
+	err, summary := sqlconnect.GetClassIncidentSummaryDbHandler(r.Context(), r.PathValue("class"), reportedBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeIncidentSummary(w, summary)
+}
+
+func writeIncidentSummary(w http.ResponseWriter, summary models.IncidentSummary) {
+	response := struct {
+		Status  string                 `json:"status"`
+		Summary models.IncidentSummary `json:"summary"`
+	}{
+		Status:  "Success",
+		Summary: summary,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+)
+
+// leaveScope - Resolves which leave requests the caller may see;
+// Holders of leaves:manage (admins and managers) see every request (0), teachers only their own (their teacher id);
+func leaveScope(r *http.Request) (int, error) {
+	if callerCan(r, leavesManage) {
+		return 0, nil
+	}
+
+	err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+	if err != nil {
+		return 0, err
+	}
+	return teacherId, nil
+}
+
+// AddLeaveRequestHandler - Submits a leave request; teachers file for themselves, admins/managers on behalf of a teacher;
+func AddLeaveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	teacherId, err := leaveScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var leave models.LeaveRequest
+	err = json.NewDecoder(r.Body).Decode(&leave)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if teacherId > 0 {
+		leave.TeacherId = teacherId
+	}
+	leave.RequestedBy = utils.GetUserId(r)
+
+	err = sqlconnect.ValidateLeaveRequest(leave)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, leave = sqlconnect.AddLeaveRequestDbHandler(r.Context(), leave)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeLeaveRequest(w, leave, nil)
+}
+
+// GetLeaveRequestsHandler - Lists leave requests, filtered by ?status=, ?teacher_id= or ?date=;
+func GetLeaveRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	teacherId, err := leaveScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, leaves := sqlconnect.GetLeaveRequestsDbHandler(r, teacherId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string                `json:"status"`
+		Leaves []models.LeaveRequest `json:"leaves"`
+		Count  int                   `json:"count"`
+	}{
+		Status: "Success",
+		Leaves: leaves,
+		Count:  len(leaves),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetLeaveRequestHandler - Gets a single leave request;
+func GetLeaveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	teacherId, err := leaveScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid leave id!", http.StatusBadRequest)
+		return
+	}
+
+	err, leave := sqlconnect.GetLeaveRequestDbHandler(r.Context(), id, teacherId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeLeaveRequest(w, leave, nil)
+}
+
+// GetLeaveCoverHandler - Lists the timetable periods affected by a leave with their substitutes or suggestions;
+func GetLeaveCoverHandler(w http.ResponseWriter, r *http.Request) {
+	teacherId, err := leaveScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid leave id!", http.StatusBadRequest)
+		return
+	}
+
+	err, periods := sqlconnect.GetLeaveCoverDbHandler(r.Context(), id, teacherId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeCoverPeriods(w, periods)
+}
+
+// ApproveLeaveRequestHandler - Approves a pending leave and returns the affected periods with substitute suggestions;
+func ApproveLeaveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	decideLeaveRequest(w, r, "approved")
+}
+
+// RejectLeaveRequestHandler - Rejects a pending leave;
+func RejectLeaveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	decideLeaveRequest(w, r, "rejected")
+}
+
+func decideLeaveRequest(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid leave id!", http.StatusBadRequest)
+		return
+	}
+
+	// The decision note is optional;
+	var request models.LeaveDecisionRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err, leave := sqlconnect.DecideLeaveRequestDbHandler(r.Context(), id, status, request.Note, utils.GetUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	var cover []models.CoverPeriod
+	if status == "approved" {
+		err, cover = sqlconnect.GetLeaveCoverDbHandler(r.Context(), leave.Id, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeLeaveRequest(w, leave, cover)
+}
+
+func writeLeaveRequest(w http.ResponseWriter, leave models.LeaveRequest, cover []models.CoverPeriod) {
+	response := struct {
+		Status string               `json:"status"`
+		Leave  models.LeaveRequest  `json:"leave"`
+		Cover  []models.CoverPeriod `json:"cover,omitempty"`
+	}{
+		Status: "Success",
+		Leave:  leave,
+		Cover:  cover,
+	}
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeCoverPeriods(w http.ResponseWriter, periods []models.CoverPeriod) {
+	response := struct {
+		Status  string               `json:"status"`
+		Periods []models.CoverPeriod `json:"periods"`
+		Count   int                  `json:"count"`
+	}{
+		Status:  "Success",
+		Periods: periods,
+		Count:   len(periods),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,558 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+)
+
+// libraryBorrowerScope - Library staff (library:circulate) see everyone's loans and holds; teachers only their own;
+func libraryBorrowerScope(r *http.Request) (error, models.Borrower) {
+	if callerCan(r, libraryCirculate) {
+		return nil, models.Borrower{}
+	}
+
+	err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+	if err != nil {
+		return err, models.Borrower{}
+	}
+	return nil, models.Borrower{BorrowerType: "teacher", BorrowerId: teacherId}
+}
+
+func writeBook(w http.ResponseWriter, status int, book models.Book) {
+	response := struct {
+		Status string      `json:"status"`
+		Book   models.Book `json:"book"`
+	}{
+		Status: "Success",
+		Book:   book,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeLoan(w http.ResponseWriter, status int, loan models.LibraryLoan) {
+	response := struct {
+		Status string             `json:"status"`
+		Loan   models.LibraryLoan `json:"loan"`
+	}{
+		Status: "Success",
+		Loan:   loan,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeLoans(w http.ResponseWriter, loans []models.LibraryLoan) {
+	fines := 0
+	for _, loan := range loans {
+		fines += loan.FineCents
+	}
+
+	response := struct {
+		Status    string               `json:"status"`
+		Loans     []models.LibraryLoan `json:"loans"`
+		Count     int                  `json:"count"`
+		FineCents int                  `json:"fine_cents"`
+	}{
+		Status:    "Success",
+		Loans:     loans,
+		Count:     len(loans),
+		FineCents: fines,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetBooksHandler - Searches the catalogue by ?q= (title or author), ?isbn=, ?author= or ?subject=;
+func GetBooksHandler(w http.ResponseWriter, r *http.Request) {
+	err, books := sqlconnect.GetBooksDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status string        `json:"status"`
+		Books  []models.Book `json:"books"`
+		Count  int           `json:"count"`
+	}{
+		Status: "Success",
+		Books:  books,
+		Count:  len(books),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddBookHandler - Catalogues a book; ISBN-10 and ISBN-13 (with or without hyphens) are accepted;
+func AddBookHandler(w http.ResponseWriter, r *http.Request) {
+	var book models.Book
+	err := json.NewDecoder(r.Body).Decode(&book)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateBook(book)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, book = sqlconnect.AddBookDbHandler(r.Context(), book)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeBook(w, http.StatusCreated, book)
+}
+
+// GetBookHandler - Fetches a book with its copies and availability;
+func GetBookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid book id!", http.StatusBadRequest)
+		return
+	}
+
+	err, book := sqlconnect.GetBookDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeBook(w, http.StatusOK, book)
+}
+
+// PatchBookHandler - Updates the catalogue details of a book;
+func PatchBookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid book id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, book := sqlconnect.PatchBookDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeBook(w, http.StatusOK, book)
+}
+
+// DeleteBookHandler - Removes a book and its copies; refused while copies are on loan;
+func DeleteBookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid book id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteBookDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeBookCopy(w http.ResponseWriter, status int, copy models.BookCopy) {
+	response := struct {
+		Status string          `json:"status"`
+		Copy   models.BookCopy `json:"copy"`
+	}{
+		Status: "Success",
+		Copy:   copy,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddBookCopyHandler - Adds a copy ({"barcode", "location"}) of a book; it is set aside at once if holds are waiting;
+func AddBookCopyHandler(w http.ResponseWriter, r *http.Request) {
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid book id!", http.StatusBadRequest)
+		return
+	}
+
+	var copy models.BookCopy
+	err = json.NewDecoder(r.Body).Decode(&copy)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+	copy.BookId = bookId
+
+	err, copy = sqlconnect.AddBookCopyDbHandler(r.Context(), copy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeBookCopy(w, http.StatusCreated, copy)
+}
+
+// PatchBookCopyHandler - Updates the location of a copy or marks it available, lost or withdrawn;
+func PatchBookCopyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid copy id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, copy := sqlconnect.PatchBookCopyDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeBookCopy(w, http.StatusOK, copy)
+}
+
+// CheckoutHandler - Lends a copy ({"barcode", "borrower_type", "borrower_id"}) at the circulation desk;
+func CheckoutHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.CheckoutRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateBorrower(request.Borrower)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, loan := sqlconnect.CheckoutDbHandler(r.Context(), request, utils.GetUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeLoan(w, http.StatusCreated, loan)
+}
+
+// ReturnHandler - Checks a copy back in by {"barcode"}; the fine of a late return is fixed on the loan;
+func ReturnHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Barcode string `json:"barcode"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Barcode == "" {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, loan := sqlconnect.ReturnDbHandler(r.Context(), request.Barcode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeLoan(w, http.StatusOK, loan)
+}
+
+// RenewLoanHandler - Extends an open loan by another loan period;
+func RenewLoanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid loan id!", http.StatusBadRequest)
+		return
+	}
+
+	err, loan := sqlconnect.RenewLoanDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeLoan(w, http.StatusOK, loan)
+}
+
+// GetLoansHandler - Lists loans by ?status=open|overdue|returned and ?book_id=; teachers get their own;
+func GetLoansHandler(w http.ResponseWriter, r *http.Request) {
+	err, borrower := libraryBorrowerScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, loans := sqlconnect.GetLoansDbHandler(r, borrower)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeLoans(w, loans)
+}
+
+// GetStudentLibraryLoansHandler - Lists the loans of a student, by ?status=;
+func GetStudentLibraryLoansHandler(w http.ResponseWriter, r *http.Request) {
+	getBorrowerLoans(w, r, "student")
+}
+
+// GetTeacherLibraryLoansHandler - Lists the loans of a teacher, by ?status=; teachers can only see their own;
+func GetTeacherLibraryLoansHandler(w http.ResponseWriter, r *http.Request) {
+	err, scope := libraryBorrowerScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if scope.BorrowerId != 0 && strconv.Itoa(scope.BorrowerId) != r.PathValue("id") {
+		http.Error(w, "Err: Teachers can only see their own loans!", http.StatusForbidden)
+		return
+	}
+
+	getBorrowerLoans(w, r, "teacher")
+}
+
+func getBorrowerLoans(w http.ResponseWriter, r *http.Request, borrowerType string) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+borrowerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if borrowerType == "student" && !studentVisible(w, r, id) {
+		return
+	}
+
+	err, loans := sqlconnect.GetLoansDbHandler(r, models.Borrower{BorrowerType: borrowerType, BorrowerId: id})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeLoans(w, loans)
+}
+
+// GetOverdueReportHandler - Overdue loans and fines grouped by class, for ?class= or the whole school;
+func GetOverdueReportHandler(w http.ResponseWriter, r *http.Request) {
+	err, classes := sqlconnect.GetOverdueReportDbHandler(r.Context(), r.URL.Query().Get("class"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	count, fines := 0, 0
+	for _, class := range classes {
+		count += class.Count
+		fines += class.FineCents
+	}
+
+	response := struct {
+		Status    string                      `json:"status"`
+		Classes   []models.OverdueClassReport `json:"classes"`
+		Count     int                         `json:"count"`
+		FineCents int                         `json:"fine_cents"`
+	}{
+		Status:    "Success",
+		Classes:   classes,
+		Count:     count,
+		FineCents: fines,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// PlaceHoldHandler - Queues a borrower for a book with no copy on the shelf; teachers place holds for themselves;
+func PlaceHoldHandler(w http.ResponseWriter, r *http.Request) {
+	err, scope := libraryBorrowerScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	bookId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid book id!", http.StatusBadRequest)
+		return
+	}
+
+	borrower := scope
+	if scope.BorrowerId == 0 {
+		err = json.NewDecoder(r.Body).Decode(&borrower)
+		if err != nil {
+			http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = sqlconnect.ValidateBorrower(borrower)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, hold := sqlconnect.PlaceHoldDbHandler(r.Context(), bookId, borrower)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string          `json:"status"`
+		Hold   models.BookHold `json:"hold"`
+	}{
+		Status: "Success",
+		Hold:   hold,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetHoldsHandler - Lists holds by ?book_id= and ?status= (waiting and ready by default); teachers get their own;
+func GetHoldsHandler(w http.ResponseWriter, r *http.Request) {
+	err, borrower := libraryBorrowerScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	err, holds := sqlconnect.GetHoldsDbHandler(r, borrower)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status string            `json:"status"`
+		Holds  []models.BookHold `json:"holds"`
+		Count  int               `json:"count"`
+	}{
+		Status: "Success",
+		Holds:  holds,
+		Count:  len(holds),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// CancelHoldHandler - Cancels a hold; a copy set aside for it passes to the next in line;
+func CancelHoldHandler(w http.ResponseWriter, r *http.Request) {
+	err, scope := libraryBorrowerScope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid hold id!", http.StatusBadRequest)
+		return
+	}
+
+	if scope.BorrowerId != 0 {
+		err, hold := sqlconnect.GetHoldDbHandler(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if hold.BorrowerType != scope.BorrowerType || hold.BorrowerId != scope.BorrowerId {
+			http.Error(w, "Err: Teachers can only cancel their own holds!", http.StatusForbidden)
+			return
+		}
+	}
+
+	err = sqlconnect.CancelHoldDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
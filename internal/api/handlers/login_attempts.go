@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Login failure responses; they never tell whether the username exists;
+const (
+	invalidLoginMessage = "Err: Invalid username or password!"
+	invalidCodeMessage  = "Err: Invalid two-factor code!"
+	lockedLoginMessage  = "Err: Too many failed logins, the account is locked for a while!"
+	backoffLoginMessage = "Err: Too many login attempts, try again later!"
+)
+
+// unknownUserHash - Password hash checked for unknown usernames, so that they take as long to answer as known ones;
+var unknownUserHash = struct {
+	sync.Once
+	hash string
+}{}
+
+func dummyPasswordHash() string {
+	unknownUserHash.Do(func() {
+		unknownUserHash.hash, _ = utils.HashPassword("unknown user")
+	})
+	return unknownUserHash.hash
+}
+
+// refuseLogin - Answers a login attempt made too early, with the wait in Retry-After;
+func refuseLogin(w http.ResponseWriter, wait time.Duration, locked bool) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	if locked {
+		http.Error(w, lockedLoginMessage, http.StatusLocked)
+		return
+	}
+	http.Error(w, backoffLoginMessage, http.StatusTooManyRequests)
+}
+
+// checkLoginWait - Refuses the attempt while the client IP or the username is backing off or locked out; reports whether it was refused;
+func checkLoginWait(w http.ResponseWriter, r *http.Request, username string) bool {
+	wait := utils.LoginIPRetryAfter(utils.ClientIP(r))
+	if wait > 0 {
+		refuseLogin(w, wait, false)
+		return true
+	}
+
+	err, wait, locked := sqlconnect.LoginWaitDbHandler(r.Context(), username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	if wait > 0 {
+		refuseLogin(w, wait, locked)
+		return true
+	}
+	return false
+}
+
+// failLogin - Counts a failed login (a wrong password or two-factor code) against the client IP and the username and answers it with message;
+// The owner of an existing account is emailed when the failure locks it;
+func failLogin(w http.ResponseWriter, r *http.Request, username string, exec *models.Exec, message string) {
+	ip := utils.ClientIP(r)
+	err := utils.RecordLoginIPFailure(ip)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err, lockedUntil := sqlconnect.RecordLoginFailureDbHandler(r.Context(), username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if lockedUntil.IsZero() {
+		http.Error(w, message, http.StatusUnauthorized)
+		return
+	}
+
+	// Sent in the background, so that the answer takes as long whether or not the account exists;
+	if exec != nil && exec.Email != "" {
+		go notifyLockout(*exec, ip, lockedUntil)
+	}
+	refuseLogin(w, time.Until(lockedUntil), true)
+}
+
+// notifyLockout - Tells the owner of an account that it was locked after repeated failed logins; failures are only logged;
+func notifyLockout(exec models.Exec, ip string, lockedUntil time.Time) {
+	body := fmt.Sprintf("Hello %s,<br>Your account was locked after %d failed login attempts, the last one from %s. "+
+		"It unlocks at %s, or sooner if an administrator unlocks it.<br>"+
+		"If this was not you, please reset your password.",
+		html.EscapeString(exec.FirstName), utils.LoginMaxFailures(), html.EscapeString(ip), lockedUntil.Format(time.DateTime))
+	err := utils.SendMail(exec.Email, "Your account was locked", body)
+	if err != nil {
+		log.Println("Lockout notification failed : ", err)
+	}
+}
+
+// clearLoginFailures - Forgets the failed logins of the username once its login succeeded;
+// The failures of the client IP are left to expire, a login to another account from the same address must not reset them;
+func clearLoginFailures(r *http.Request, username string) error {
+	return sqlconnect.ClearLoginFailuresDbHandler(r.Context(), username)
+}
+
+// UnlockExecHandler - Lifts the lockout of an exec after failed logins;
+func UnlockExecHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid user id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.UnlockExecDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+	}{
+		Status: "Success",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
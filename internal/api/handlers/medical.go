@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// medicalAccess - Builds the audit entry for the current request;
+func medicalAccess(r *http.Request, studentId int, class, action string) models.MedicalAccess {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return models.MedicalAccess{
+		UserId:    utils.GetUserId(r),
+		Role:      utils.GetUserRole(r),
+		StudentId: studentId,
+		Class:     class,
+		Action:    action,
+		IpAddress: ip,
+	}
+}
+
+// teacherClass - Returns the class of the teacher linked to the logged-in user;
+func teacherClass(r *http.Request) (string, error) {
+	err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+	if err != nil {
+		return "", err
+	}
+
+	err, teacher := sqlconnect.GetTeacherDbHandler(r.Context(), strconv.Itoa(teacherId))
+	if err != nil {
+		return "", err
+	}
+	return teacher.Class, nil
+}
+
+// GetMedicalProfileHandler - Returns the full medical profile of a student (nurse and admin only, audited);
+func GetMedicalProfileHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, profile := sqlconnect.GetMedicalProfileDbHandler(r.Context(), studentId, medicalAccess(r, studentId, "", "view_profile"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeMedicalProfile(w, profile)
+}
+
+// SaveMedicalProfileHandler - Creates or replaces the medical profile of a student (nurse and admin only, audited);
+func SaveMedicalProfileHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	var profile models.MedicalProfile
+	err = json.NewDecoder(r.Body).Decode(&profile)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+	profile.StudentId = studentId
+
+	err = sqlconnect.ValidateMedicalProfile(profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, profile = sqlconnect.SaveMedicalProfileDbHandler(r.Context(), profile, medicalAccess(r, studentId, "", "update_profile"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeMedicalProfile(w, profile)
+}
+
+func writeMedicalProfile(w http.ResponseWriter, profile models.MedicalProfile) {
+	response := struct {
+		Status  string                `json:"status"`
+		Profile models.MedicalProfile `json:"profile"`
+	}{
+		Status:  "Success",
+		Profile: profile,
+	}
+
+	// Medical data must not end up in shared caches;
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetNurseVisitsHandler - Lists the nurse visits of a student (nurse and admin only, audited);
+func GetNurseVisitsHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, visits := sqlconnect.GetNurseVisitsDbHandler(r.Context(), studentId, medicalAccess(r, studentId, "", "view_visits"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string              `json:"status"`
+		Visits []models.NurseVisit `json:"visits"`
+		Count  int                 `json:"count"`
+	}{
+		Status: "Success",
+		Visits: visits,
+		Count:  len(visits),
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddNurseVisitHandler - Logs a visit to the nurse (nurse and admin only, audited);
+func AddNurseVisitHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	var visit models.NurseVisit
+	err = json.NewDecoder(r.Body).Decode(&visit)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	// Data validation;
+	visit.Id = 0
+	visit.StudentId = studentId
+	if visit.VisitedAt == "" {
+		visit.VisitedAt = time.Now().Format(time.DateTime)
+	}
+	_, err = time.Parse(time.DateTime, visit.VisitedAt)
+	if err != nil {
+		http.Error(w, "Err: Visit time must be in YYYY-MM-DD HH:MM:SS format!", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(visit.Complaint) == "" {
+		http.Error(w, "Err: Complaint is required!", http.StatusBadRequest)
+		return
+	}
+	if visit.Outcome == "" {
+		visit.Outcome = "returned_to_class"
+	}
+	validOutcome := false
+	for _, outcome := range models.NurseVisitOutcomes {
+		if visit.Outcome == outcome {
+			validOutcome = true
+		}
+	}
+	if !validOutcome {
+		http.Error(w, "Err: Invalid visit outcome!", http.StatusBadRequest)
+		return
+	}
+
+	err, visit = sqlconnect.AddNurseVisitDbHandler(r.Context(), visit, medicalAccess(r, studentId, "", "add_visit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string            `json:"status"`
+		Visit  models.NurseVisit `json:"visit"`
+	}{
+		Status: "Success",
+		Visit:  visit,
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStudentMedicalAlertsHandler - Minimal alerts of one student; teachers only for students of their own class;
+func GetStudentMedicalAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	class := ""
+	if !callerCan(r, medicalAlertsAll) {
+		class, err = teacherClass(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	err, alerts := sqlconnect.GetMedicalAlertsDbHandler(r.Context(), class, studentId, medicalAccess(r, studentId, class, "view_alerts"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeMedicalAlerts(w, alerts)
+}
+
+// GetClassMedicalAlertsHandler - Minimal alerts of a class (?class=); teachers always get their own class;
+func GetClassMedicalAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	class := r.URL.Query().Get("class")
+	if !callerCan(r, medicalAlertsAll) {
+		var err error
+		class, err = teacherClass(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	if class == "" {
+		http.Error(w, "Err: Class is required!", http.StatusBadRequest)
+		return
+	}
+
+	err, alerts := sqlconnect.GetMedicalAlertsDbHandler(r.Context(), class, 0, medicalAccess(r, 0, class, "view_alerts"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeMedicalAlerts(w, alerts)
+}
+
+func writeMedicalAlerts(w http.ResponseWriter, alerts []models.MedicalAlert) {
+	response := struct {
+		Status string                `json:"status"`
+		Alerts []models.MedicalAlert `json:"alerts"`
+		Count  int                   `json:"count"`
+	}{
+		Status: "Success",
+		Alerts: alerts,
+		Count:  len(alerts),
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetMedicalAuditHandler - Lists who accessed medical data and when (admin only);
+func GetMedicalAuditHandler(w http.ResponseWriter, r *http.Request) {
+	err, entries := sqlconnect.GetMedicalAuditDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status  string                 `json:"status"`
+		Entries []models.MedicalAccess `json:"entries"`
+		Count   int                    `json:"count"`
+	}{
+		Status:  "Success",
+		Entries: entries,
+		Count:   len(entries),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
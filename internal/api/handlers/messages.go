@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// Limits for messages and their attachments;
+const maxMessageBodySize = 32 << 20
+const maxMessageLength = 10000
+const maxMessageAttachments = 5
+const maxAttachmentSize = 4 << 20
+
+// allowedAttachmentTypes - Content types (as sniffed from the data) accepted as attachments;
+var allowedAttachmentTypes = []string{"application/pdf", "image/jpeg", "image/png", "text/plain"}
+
+// messageScope - Resolves which threads the caller may read;
+// Moderators (messages:moderate) can view any thread (0), staff and guardians only the threads they take part in (their user id);
+func messageScope(r *http.Request) int {
+	if callerCan(r, messagesModerate) {
+		return 0
+	}
+	return utils.GetUserId(r)
+}
+
+// prepareAttachments - Decodes the base64 attachments and checks their count, size and type;
+func prepareAttachments(attachments []models.MessageAttachment) error {
+	if len(attachments) > maxMessageAttachments {
+		return errors.New("Err: Too many attachments!")
+	}
+
+	for i := range attachments {
+		attachment := &attachments[i]
+		attachment.FileName = filepath.Base(strings.TrimSpace(attachment.FileName))
+		if attachment.FileName == "" || attachment.FileName == "." || attachment.FileName == "/" {
+			return errors.New("Err: Attachment file name is required!")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(attachment.Content)
+		if err != nil || len(data) == 0 {
+			return errors.New("Err: Attachment content must be base64 encoded!")
+		}
+		if len(data) > maxAttachmentSize {
+			return errors.New("Err: Attachment too large!")
+		}
+
+		// The declared type is not trusted;
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		if !isAllowedAttachmentType(contentType) {
+			return errors.New("Err: Attachment type not allowed!")
+		}
+
+		attachment.Id = 0
+		attachment.Data = data
+		attachment.Size = len(data)
+		attachment.ContentType = contentType
+	}
+	return nil
+}
+
+func isAllowedAttachmentType(contentType string) bool {
+	for _, allowed := range allowedAttachmentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMessage - Checks the body and attachments of a new message;
+func validateMessage(body string, attachments []models.MessageAttachment) error {
+	if strings.TrimSpace(body) == "" {
+		return errors.New("Err: Message body is required!")
+	}
+	if len(body) > maxMessageLength {
+		return errors.New("Err: Message too long!")
+	}
+	return prepareAttachments(attachments)
+}
+
+// notifyParticipant - Emails the other participant of a thread about a new message; failures are only logged;
+func notifyParticipant(ctx context.Context, thread models.MessageThread, senderId int) {
+	recipientId := thread.GuardianId
+	if senderId == thread.GuardianId {
+		recipientId = thread.StaffId
+	}
+
+	err, recipient := sqlconnect.GetExecContactDbHandler(ctx, recipientId)
+	if err != nil {
+		log.Println("Message notification skipped : ", err)
+		return
+	}
+
+	// The message itself is not emailed, only a pointer to the thread;
+	body := fmt.Sprintf("Hello %s,<br>You have a new message in the conversation \"%s\" (thread #%d). Please log in to read it.",
+		html.EscapeString(recipient.FirstName), html.EscapeString(thread.Subject), thread.Id)
+	err = utils.SendMail(recipient.Email, "New message: "+thread.Subject, body)
+	if err != nil {
+		log.Println("Message notification failed : ", err)
+	}
+}
+
+// GetThreadsHandler - Lists the caller's threads (every thread for admins) with unread counts;
+func GetThreadsHandler(w http.ResponseWriter, r *http.Request) {
+	viewerId := messageScope(r)
+
+	err, threads := sqlconnect.GetThreadsDbHandler(r, viewerId, utils.GetUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	unread := 0
+	for _, thread := range threads {
+		unread += thread.Unread
+	}
+
+	response := struct {
+		Status  string                 `json:"status"`
+		Threads []models.MessageThread `json:"threads"`
+		Count   int                    `json:"count"`
+		Unread  int                    `json:"unread"`
+	}{
+		Status:  "Success",
+		Threads: threads,
+		Count:   len(threads),
+		Unread:  unread,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddThreadHandler - Starts a conversation about a student between a staff member and a guardian;
+func AddThreadHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxMessageBodySize)
+	var request models.NewThreadRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	request.Subject = strings.TrimSpace(request.Subject)
+	if request.StudentId == 0 || request.Subject == "" || len(request.Subject) > 255 {
+		http.Error(w, "Err: Student and subject are required!", http.StatusBadRequest)
+		return
+	}
+
+	err = validateMessage(request.Body, request.Attachments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The caller is always one of the two participants;
+	userId, role := utils.GetUserId(r), utils.GetUserRole(r)
+	thread := models.MessageThread{StudentId: request.StudentId, Subject: request.Subject, CreatedBy: userId}
+	if role == "guardian" {
+		thread.GuardianId = userId
+		thread.StaffId = request.StaffId
+	} else {
+		thread.StaffId = userId
+		thread.GuardianId = request.GuardianId
+	}
+
+	message := models.Message{SenderId: userId, SenderRole: role, Body: request.Body, Attachments: request.Attachments}
+	err, thread, message = sqlconnect.AddThreadDbHandler(r.Context(), thread, message)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Notify {
+		notifyParticipant(r.Context(), thread, userId)
+	}
+
+	response := struct {
+		Status  string               `json:"status"`
+		Thread  models.MessageThread `json:"thread"`
+		Message models.Message       `json:"message"`
+	}{
+		Status:  "Success",
+		Thread:  thread,
+		Message: message,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetThreadHandler - Gets a thread with its messages and marks it as read for the caller;
+func GetThreadHandler(w http.ResponseWriter, r *http.Request) {
+	viewerId := messageScope(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid thread id!", http.StatusBadRequest)
+		return
+	}
+
+	err, thread, messages := sqlconnect.GetThreadMessagesDbHandler(r.Context(), id, viewerId, utils.GetUserId(r), viewerId == 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status   string               `json:"status"`
+		Thread   models.MessageThread `json:"thread"`
+		Messages []models.Message     `json:"messages"`
+		Count    int                  `json:"count"`
+	}{
+		Status:   "Success",
+		Thread:   thread,
+		Messages: messages,
+		Count:    len(messages),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddMessageHandler - Replies in a thread; only the two participants can post;
+func AddMessageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid thread id!", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxMessageBodySize)
+	var request models.NewMessageRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = validateMessage(request.Body, request.Attachments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userId := utils.GetUserId(r)
+	message := models.Message{SenderId: userId, SenderRole: utils.GetUserRole(r), Body: request.Body, Attachments: request.Attachments}
+	err, thread, message := sqlconnect.AddMessageDbHandler(r.Context(), id, message)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Notify {
+		notifyParticipant(r.Context(), thread, userId)
+	}
+
+	response := struct {
+		Status  string         `json:"status"`
+		Message models.Message `json:"message"`
+	}{
+		Status:  "Success",
+		Message: message,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetMessageAttachmentHandler - Downloads an attachment of a thread the caller can read;
+func GetMessageAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	viewerId := messageScope(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid thread id!", http.StatusBadRequest)
+		return
+	}
+
+	attachmentId, err := strconv.Atoi(r.PathValue("attachmentId"))
+	if err != nil {
+		http.Error(w, "Err: Invalid attachment id!", http.StatusBadRequest)
+		return
+	}
+
+	err, attachment := sqlconnect.GetMessageAttachmentDbHandler(r.Context(), id, attachmentId, viewerId, viewerId == 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(attachment.Data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = w.Write(attachment.Data)
+	if err != nil {
+		log.Println("Attachment download interrupted : ", err)
+	}
+}
+
+// PatchThreadHandler - Moderation: admins lock or unlock a thread ({"locked": true});
+func PatchThreadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid thread id!", http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		Locked *bool `json:"locked"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Locked == nil {
+		http.Error(w, "Err: locked is required!", http.StatusBadRequest)
+		return
+	}
+
+	err, thread := sqlconnect.SetThreadLockedDbHandler(r.Context(), id, *request.Locked)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string               `json:"status"`
+		Thread models.MessageThread `json:"thread"`
+	}{
+		Status: "Success",
+		Thread: thread,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// HideMessageHandler - Moderation: admins hide a message from the participants;
+func HideMessageHandler(w http.ResponseWriter, r *http.Request) {
+	setMessageHidden(w, r, true)
+}
+
+// UnhideMessageHandler - Moderation: admins restore a hidden message;
+func UnhideMessageHandler(w http.ResponseWriter, r *http.Request) {
+	setMessageHidden(w, r, false)
+}
+
+func setMessageHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid thread id!", http.StatusBadRequest)
+		return
+	}
+
+	messageId, err := strconv.Atoi(r.PathValue("messageId"))
+	if err != nil {
+		http.Error(w, "Err: Invalid message id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.SetMessageHiddenDbHandler(r.Context(), id, messageId, hidden, utils.GetUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status    string `json:"status"`
+		MessageId int    `json:"message_id"`
+		Hidden    bool   `json:"hidden"`
+	}{
+		Status:    "Success",
+		MessageId: messageId,
+		Hidden:    hidden,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/rbac"
+	"schoolManagement/pkg/utils"
+)
+
+// Scope permissions; routes require the base permission, these decide whose records the caller reaches;
+var (
+	incidentsAll        = rbac.Scope("incidents:all")
+	leavesManage        = rbac.Scope("leaves:manage")
+	clubsManage         = rbac.Scope("clubs:manage")
+	documentsAll        = rbac.Scope("documents:all")
+	photosAll           = rbac.Scope("photos:all")
+	announcementsManage = rbac.Scope("announcements:manage")
+	medicalAlertsAll    = rbac.Scope("medical:alerts_all")
+	transportRidersAll  = rbac.Scope("transport:riders_all")
+	messagesModerate    = rbac.Scope("messages:moderate")
+	libraryCirculate    = rbac.Scope("library:circulate")
+)
+
+// callerCan - Whether the logged-in user's role holds the permission;
+func callerCan(r *http.Request, permission string) bool {
+	return rbac.Can(utils.GetUserRole(r), permission)
+}
+
+// studentVisible - Checks that the student of a /students/{id}/... route is within the caller's scope, answering 404 otherwise;
+// Teachers only reach the students of their classes, through the sub-resources as through the student itself;
+func studentVisible(w http.ResponseWriter, r *http.Request, studentId int) bool {
+	err, visible := sqlconnect.StudentVisibleDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if !visible {
+		http.Error(w, "Err: No student found!", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// GetMyPermissionsHandler - Lists the permissions the logged-in user's role holds on this server's routes;
+func GetMyPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	role := utils.GetUserRole(r)
+	permissions := rbac.Permissions(role)
+
+	response := struct {
+		Status      string   `json:"status"`
+		Role        string   `json:"role"`
+		Count       int      `json:"count"`
+		Permissions []string `json:"permissions"`
+	}{
+		Status:      "Success",
+		Role:        role,
+		Count:       len(permissions),
+		Permissions: permissions,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/storage"
+	"schoolManagement/pkg/utils"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Limits and settings for profile photos;
+const maxPhotoSize = 8 << 20
+const photoQuality = 85
+
+// photoMaxAge - How long browsers may reuse a photo; the ETag changes with every upload;
+const photoMaxAge = 24 * time.Hour
+
+// photoAccess - Holders of photos:all manage every photo; teachers may also replace or remove their own;
+func photoAccess(r *http.Request, ownerType string, ownerId int) error {
+	if callerCan(r, photosAll) {
+		return nil
+	}
+
+	if ownerType == "teacher" {
+		err, teacherId := sqlconnect.GetTeacherIdByExecDbHandler(r.Context(), utils.GetUserId(r))
+		if err == nil && teacherId == ownerId {
+			return nil
+		}
+	}
+	return errors.New("Err: Access denied!")
+}
+
+func photoKey(prefix, size string) string {
+	return prefix + "/" + size + ".jpg"
+}
+
+// discardPhoto - Removes every stored variant below a prefix; failures are only logged;
+func discardPhoto(store storage.Store, prefix string) {
+	for size := range models.PhotoSizes {
+		err := store.Delete(photoKey(prefix, size))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Println("Photo file not removed : ", photoKey(prefix, size), err)
+		}
+	}
+}
+
+// readPhotoUpload - Reads the image from the "file" part of a multipart form or, failing that, the raw request body;
+func readPhotoUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+documentFormMemory)
+
+	var source io.Reader = r.Body
+	err := r.ParseMultipartForm(documentFormMemory)
+	if err == nil {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("Err: File is required!")
+		}
+		defer file.Close()
+		source = file
+	} else if !errors.Is(err, http.ErrNotMultipart) {
+		return nil, errors.New("Err: Invalid upload, the photo must be at most 8MB!")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(source, maxPhotoSize+1))
+	if err != nil {
+		return nil, errors.New("Err: Invalid upload, the photo must be at most 8MB!")
+	}
+	if len(data) == 0 || len(data) > maxPhotoSize {
+		return nil, errors.New("Err: Photo must be between 1 byte and 8MB!")
+	}
+	return data, nil
+}
+
+// UploadStudentPhotoHandler - Sets the photo of a student (JPEG or PNG);
+func UploadStudentPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	uploadPhoto(w, r, "student")
+}
+
+// UploadTeacherPhotoHandler - Sets the photo of a teacher (JPEG or PNG);
+func UploadTeacherPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	uploadPhoto(w, r, "teacher")
+}
+
+// uploadPhoto - Decodes and checks the image, then stores a re-encoded JPEG (no EXIF) for every size in models.PhotoSizes;
+func uploadPhoto(w http.ResponseWriter, r *http.Request, ownerType string) {
+	ownerId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+ownerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if ownerType == "student" && !studentVisible(w, r, ownerId) {
+		return
+	}
+
+	err = photoAccess(r, ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	data, err := readPhotoUpload(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	random := make([]byte, 8)
+	_, err = rand.Read(random)
+	if err != nil {
+		http.Error(w, utils.HandleError(err, "Err: Cannot store photo!").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	photo := models.ProfilePhoto{
+		OwnerType:     ownerType,
+		OwnerId:       ownerId,
+		StoragePrefix: fmt.Sprintf("photos/%s/%d/%s", ownerType, ownerId, hex.EncodeToString(random)),
+		UpdatedBy:     utils.GetUserId(r),
+	}
+
+	for size, maxSide := range models.PhotoSizes {
+		variant := utils.ResizeImage(img, maxSide)
+		if size == "original" {
+			photo.Width = variant.Bounds().Dx()
+			photo.Height = variant.Bounds().Dy()
+		}
+
+		encoded, err := utils.EncodeJPEG(variant, photoQuality)
+		if err == nil {
+			_, err = store.Put(photoKey(photo.StoragePrefix, size), bytes.NewReader(encoded))
+		}
+		if err != nil {
+			discardPhoto(store, photo.StoragePrefix)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err, saved, previousPrefix := sqlconnect.SaveProfilePhotoDbHandler(r.Context(), photo)
+	if err != nil {
+		discardPhoto(store, photo.StoragePrefix)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if previousPrefix != "" {
+		discardPhoto(store, previousPrefix)
+	}
+
+	writePhoto(w, http.StatusCreated, saved)
+}
+
+func writePhoto(w http.ResponseWriter, status int, photo models.ProfilePhoto) {
+	for size := range models.PhotoSizes {
+		photo.Sizes = append(photo.Sizes, size)
+	}
+	sort.Strings(photo.Sizes)
+
+	response := struct {
+		Status string              `json:"status"`
+		Photo  models.ProfilePhoto `json:"photo"`
+	}{
+		Status: "Success",
+		Photo:  photo,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStudentPhotoHandler - Serves the photo of a student (?size=small|medium|large|original, default medium);
+func GetStudentPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	getPhoto(w, r, "student")
+}
+
+// GetTeacherPhotoHandler - Serves the photo of a teacher (?size=small|medium|large|original, default medium);
+func GetTeacherPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	getPhoto(w, r, "teacher")
+}
+
+func getPhoto(w http.ResponseWriter, r *http.Request, ownerType string) {
+	ownerId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+ownerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if ownerType == "student" && !studentVisible(w, r, ownerId) {
+		return
+	}
+
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = "medium"
+	}
+	_, ok := models.PhotoSizes[size]
+	if !ok {
+		http.Error(w, "Err: Invalid photo size!", http.StatusBadRequest)
+		return
+	}
+
+	err, photo := sqlconnect.GetProfilePhotoDbHandler(r.Context(), ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// The photo is private to logged-in users, but may be cached by their browser;
+	etag := fmt.Sprintf("\"%s-%d-v%d-%s\"", ownerType, ownerId, photo.Version, size)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(photoMaxAge.Seconds())))
+	updatedAt, err := time.ParseInLocation(time.DateTime, photo.UpdatedAt, time.Local)
+	if err == nil {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file, err := store.Get(photoKey(photo.StoragePrefix, size))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Err: File missing from storage!", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = io.Copy(w, file)
+	if err != nil {
+		log.Println("Photo download interrupted : ", err)
+	}
+}
+
+// DeleteStudentPhotoHandler - Removes the photo of a student;
+func DeleteStudentPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	deletePhoto(w, r, "student")
+}
+
+// DeleteTeacherPhotoHandler - Removes the photo of a teacher;
+func DeleteTeacherPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	deletePhoto(w, r, "teacher")
+}
+
+func deletePhoto(w http.ResponseWriter, r *http.Request, ownerType string) {
+	ownerId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid "+ownerType+" id!", http.StatusBadRequest)
+		return
+	}
+
+	if ownerType == "student" && !studentVisible(w, r, ownerId) {
+		return
+	}
+
+	err = photoAccess(r, ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	store, err := storage.New(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err, photo := sqlconnect.DeleteProfilePhotoDbHandler(r.Context(), ownerType, ownerId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	discardPhoto(store, photo.StoragePrefix)
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     ownerId,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+func writeTenant(w http.ResponseWriter, status int, tenant models.Tenant) {
+	response := struct {
+		Status string        `json:"status"`
+		Tenant models.Tenant `json:"tenant"`
+	}{
+		Status: "Success",
+		Tenant: tenant,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// PlatformLoginHandler - Signs in a super-admin; the token is not bound to a school, one is picked per request by host or X-Tenant;
+func PlatformLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SuperAdmin
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		http.Error(w, "Err: Username or password is empty!", http.StatusBadRequest)
+		return
+	}
+
+	err, admin := sqlconnect.SuperAdminLoginDbHandler(req.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if admin.Inactive {
+		http.Error(w, "Err: User is inactive!", http.StatusForbidden)
+		return
+	}
+
+	err = utils.PasswordValidate(admin.Password, req.Password)
+	if err != nil {
+		http.Error(w, "Err: Invalid username or password!", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := utils.SignToken(strconv.Itoa(admin.Id), admin.Username, "superadmin", "")
+	if err != nil {
+		http.Error(w, "Err: Token generation failed!", http.StatusInternalServerError)
+		return
+	}
+
+	// Super-admins have no refresh token, they sign in again once the access token expires;
+	ttl, err := utils.AccessTokenTTL()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Bearer",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		Expires:  time.Now().Add(ttl),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	csrfToken, err := utils.SetCSRFCookie(w, time.Now().Add(ttl))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status    string `json:"status"`
+		Token     string `json:"token"`
+		CSRFToken string `json:"csrf_token"`
+	}{
+		Status:    "Success",
+		Token:     token,
+		CSRFToken: csrfToken,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetTenantsHandler - Lists the schools of the trust;
+func GetTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	err, tenants := sqlconnect.GetTenantsDbHandler()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string          `json:"status"`
+		Count  int             `json:"count"`
+		Data   []models.Tenant `json:"data"`
+	}{
+		Status: "Success",
+		Count:  len(tenants),
+		Data:   tenants,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddTenantHandler - Registers a school whose database has already been created and migrated;
+func AddTenantHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := models.Tenant{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&tenant)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateTenant(tenant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, tenant = sqlconnect.AddTenantDbHandler(tenant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeTenant(w, http.StatusCreated, tenant)
+}
+
+// PatchTenantHandler - Renames a school, changes its host or (de)activates it;
+func PatchTenantHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid school id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, tenant := sqlconnect.PatchTenantDbHandler(id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeTenant(w, http.StatusOK, tenant)
+}
+
+// GetTenantSummariesHandler - Head counts across all schools of the trust;
+func GetTenantSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	err, summaries := sqlconnect.GetTenantSummariesDbHandler()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string                 `json:"status"`
+		Count  int                    `json:"count"`
+		Data   []models.TenantSummary `json:"data"`
+	}{
+		Status: "Success",
+		Count:  len(summaries),
+		Data:   summaries,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// loadClassProgression - Reads the class progression map (JSON object "6A": "7A") from CLASS_PROGRESSION_FILE;
+func loadClassProgression() (map[string]string, error) {
+	path := os.Getenv("CLASS_PROGRESSION_FILE")
+	if path == "" {
+		return nil, utils.HandleError(errors.New("CLASS_PROGRESSION_FILE not set"), "Err: No class progression configured!")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Cannot read class progression file!")
+	}
+
+	progression := make(map[string]string)
+	err = json.Unmarshal(data, &progression)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Invalid class progression file!")
+	}
+	return progression, nil
+}
+
+// promotionUndoWindow - How long a committed promotion can be undone (PROMOTION_UNDO_WINDOW, defaults to 48h);
+func promotionUndoWindow() time.Duration {
+	window, err := time.ParseDuration(os.Getenv("PROMOTION_UNDO_WINDOW"))
+	if err != nil || window <= 0 {
+		return 48 * time.Hour
+	}
+	return window
+}
+
+// decodePromotionRequest - Decodes the promotion body and falls back to the configured progression map;
+func decodePromotionRequest(r *http.Request) (models.PromotionRequest, error) {
+	var request models.PromotionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return request, utils.HandleError(err, "Err: Invalid request body!")
+	}
+
+	// Students are promoted into the academic year following the current one unless told otherwise;
+	if request.AcademicYear == "" {
+		currentYear, _ := strconv.Atoi(utils.AcademicYear(time.Now()))
+		request.AcademicYear = strconv.Itoa(currentYear + 1)
+	}
+
+	if len(request.Progression) == 0 {
+		request.Progression, err = loadClassProgression()
+		if err != nil {
+			return request, err
+		}
+	}
+	return request, nil
+}
+
+// PreviewPromotionHandler - Shows the proposed class moves without applying them;
+func PreviewPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	request, err := decodePromotionRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, preview := sqlconnect.PreviewPromotionDbHandler(r.Context(), request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status       string                  `json:"status"`
+		AcademicYear string                  `json:"academic_year"`
+		Preview      models.PromotionPreview `json:"preview"`
+		Count        int                     `json:"count"`
+	}{
+		Status:       "Success",
+		AcademicYear: request.AcademicYear,
+		Preview:      preview,
+		Count:        len(preview.Moves),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddPromotionHandler - Commits the promotion in one transaction;
+func AddPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	request, err := decodePromotionRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, promotion := sqlconnect.CommitPromotionDbHandler(r.Context(), request, utils.GetUserId(r), promotionUndoWindow())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writePromotion(w, http.StatusCreated, promotion)
+}
+
+// GetPromotionsHandler - Lists the promotion batches;
+func GetPromotionsHandler(w http.ResponseWriter, r *http.Request) {
+	err, promotions := sqlconnect.GetPromotionsDbHandler(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status     string             `json:"status"`
+		Promotions []models.Promotion `json:"promotions"`
+		Count      int                `json:"count"`
+	}{
+		Status:     "Success",
+		Promotions: promotions,
+		Count:      len(promotions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetPromotionHandler - Gets a promotion batch with all of its moves;
+func GetPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid promotion id!", http.StatusBadRequest)
+		return
+	}
+
+	err, promotion := sqlconnect.GetPromotionDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writePromotion(w, http.StatusOK, promotion)
+}
+
+// UndoPromotionHandler - Reverts a promotion within its undo window;
+func UndoPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid promotion id!", http.StatusBadRequest)
+		return
+	}
+
+	err, promotion := sqlconnect.UndoPromotionDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writePromotion(w, http.StatusOK, promotion)
+}
+
+func writePromotion(w http.ResponseWriter, status int, promotion models.Promotion) {
+	response := struct {
+		Status    string           `json:"status"`
+		Promotion models.Promotion `json:"promotion"`
+		Count     int              `json:"count"`
+	}{
+		Status:    "Success",
+		Promotion: promotion,
+		Count:     len(promotion.Moves),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
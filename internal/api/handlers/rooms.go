@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/rbac"
+	"schoolManagement/pkg/utils"
+	"strconv"
+)
+
+func writeRoom(w http.ResponseWriter, status int, room models.Room) {
+	response := struct {
+		Status string      `json:"status"`
+		Room   models.Room `json:"room"`
+	}{
+		Status: "Success",
+		Room:   room,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeRooms(w http.ResponseWriter, rooms []models.Room) {
+	response := struct {
+		Status string        `json:"status"`
+		Rooms  []models.Room `json:"rooms"`
+		Count  int           `json:"count"`
+	}{
+		Status: "Success",
+		Rooms:  rooms,
+		Count:  len(rooms),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRoomsHandler - Lists rooms, filtered by ?type=, ?building=, ?min_capacity= or ?active=true|false;
+func GetRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	err, rooms := sqlconnect.GetRoomsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeRooms(w, rooms)
+}
+
+// GetRoomAvailabilityHandler - Lists the rooms free between ?start= and ?end= (YYYY-MM-DD HH:MM:SS), by ?type= and ?min_capacity=;
+func GetRoomAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	err, rooms := sqlconnect.GetRoomAvailabilityDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeRooms(w, rooms)
+}
+
+// AddRoomHandler - Adds a room or facility with its capacity and equipment;
+func AddRoomHandler(w http.ResponseWriter, r *http.Request) {
+	room := models.Room{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&room)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateRoom(room)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, room = sqlconnect.AddRoomDbHandler(r.Context(), room)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeRoom(w, http.StatusCreated, room)
+}
+
+// GetRoomHandler - Fetches a room with its equipment;
+func GetRoomHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid room id!", http.StatusBadRequest)
+		return
+	}
+
+	err, room := sqlconnect.GetRoomDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeRoom(w, http.StatusOK, room)
+}
+
+// PatchRoomHandler - Updates the details, capacity, restriction or equipment of a room;
+func PatchRoomHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid room id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, room := sqlconnect.PatchRoomDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeRoom(w, http.StatusOK, room)
+}
+
+// DeleteRoomHandler - Deletes a room that has no upcoming bookings;
+func DeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid room id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteRoomDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddRoomBookingHandler - Books a room ({"title", "start_at", "end_at", "recurrence": {"frequency", "until"}});
+// Conflicting bookings and timetable periods are listed in a 409 response;
+func AddRoomBookingHandler(w http.ResponseWriter, r *http.Request) {
+	role := utils.GetUserRole(r)
+	roomId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid room id!", http.StatusBadRequest)
+		return
+	}
+
+	var request models.RoomBookingRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	autoApprove := rbac.Can(role, "rooms:approve")
+	err, bookings, conflicts := sqlconnect.AddRoomBookingDbHandler(r.Context(), roomId, request, utils.GetUserId(r), autoApprove)
+	if len(conflicts) > 0 {
+		response := struct {
+			Status    string                `json:"status"`
+			Error     string                `json:"error"`
+			Conflicts []models.RoomConflict `json:"conflicts"`
+		}{
+			Status:    "Conflict",
+			Error:     err.Error(),
+			Conflicts: conflicts,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		err = json.NewEncoder(w).Encode(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status   string               `json:"status"`
+		Bookings []models.RoomBooking `json:"bookings"`
+		Count    int                  `json:"count"`
+	}{
+		Status:   "Success",
+		Bookings: bookings,
+		Count:    len(bookings),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRoomBookingsHandler - Lists bookings by ?room_id=, ?status=, ?series_id=, ?from= and ?to=; ?mine=true lists one's own;
+func GetRoomBookingsHandler(w http.ResponseWriter, r *http.Request) {
+	bookedBy := 0
+	if r.URL.Query().Get("mine") == "true" {
+		bookedBy = utils.GetUserId(r)
+	}
+
+	err, bookings := sqlconnect.GetRoomBookingsDbHandler(r, bookedBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status   string               `json:"status"`
+		Bookings []models.RoomBooking `json:"bookings"`
+		Count    int                  `json:"count"`
+	}{
+		Status:   "Success",
+		Bookings: bookings,
+		Count:    len(bookings),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRoomBookingHandler - Fetches a single booking;
+func GetRoomBookingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid booking id!", http.StatusBadRequest)
+		return
+	}
+
+	err, booking := sqlconnect.GetRoomBookingDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeRoomBooking(w, booking, 1)
+}
+
+// ApproveRoomBookingHandler - Approves a pending booking and the pending occurrences of its series;
+func ApproveRoomBookingHandler(w http.ResponseWriter, r *http.Request) {
+	decideRoomBooking(w, r, "approved")
+}
+
+// RejectRoomBookingHandler - Rejects a pending booking and the pending occurrences of its series;
+func RejectRoomBookingHandler(w http.ResponseWriter, r *http.Request) {
+	decideRoomBooking(w, r, "rejected")
+}
+
+func decideRoomBooking(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid booking id!", http.StatusBadRequest)
+		return
+	}
+
+	// The decision note is optional;
+	var request models.RoomDecisionRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err, booking, decided := sqlconnect.DecideRoomBookingDbHandler(r.Context(), id, status, request.Note, utils.GetUserId(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeRoomBooking(w, booking, decided)
+}
+
+// CancelRoomBookingHandler - Cancels an upcoming booking; ?series=true also cancels the later occurrences of its series;
+func CancelRoomBookingHandler(w http.ResponseWriter, r *http.Request) {
+	role := utils.GetUserRole(r)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid booking id!", http.StatusBadRequest)
+		return
+	}
+
+	manager := rbac.Can(role, "rooms:approve")
+	err, cancelled := sqlconnect.CancelRoomBookingDbHandler(r.Context(), id, r.URL.Query().Get("series") == "true", utils.GetUserId(r), manager)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status    string `json:"status"`
+		Id        int    `json:"id"`
+		Cancelled int    `json:"cancelled"`
+	}{
+		Status:    "Success",
+		Id:        id,
+		Cancelled: cancelled,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeRoomBooking - Writes a booking; affected counts the bookings of its series changed by the request;
+func writeRoomBooking(w http.ResponseWriter, booking models.RoomBooking, affected int) {
+	response := struct {
+		Status   string             `json:"status"`
+		Booking  models.RoomBooking `json:"booking"`
+		Affected int                `json:"affected"`
+	}{
+		Status:   "Success",
+		Booking:  booking,
+		Affected: affected,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// refreshCookiePath - The refresh token is only sent along to the /execs endpoints (refresh and logout use it);
+const refreshCookiePath = "/execs"
+
+// newSession - Describes the session of the current request, expiring after the refresh token lifetime;
+func newSession(r *http.Request, execId int) (models.Session, time.Time, error) {
+	ttl, err := utils.RefreshTokenTTL()
+	if err != nil {
+		return models.Session{}, time.Time{}, err
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	expires := time.Now().Add(ttl)
+	return models.Session{
+		ExecId:    execId,
+		ExpiresAt: expires.Format(time.DateTime),
+		UserAgent: userAgent,
+		IpAddress: r.RemoteAddr,
+	}, expires, nil
+}
+
+// startSession - Opens a new session family for an exec who just logged in and sends both tokens;
+func startSession(w http.ResponseWriter, r *http.Request, exec models.Exec) {
+	refreshToken, tokenHash, err := utils.NewRefreshToken(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session, expires, err := newSession(r, exec.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err, _ = sqlconnect.CreateSessionDbHandler(r.Context(), session, tokenHash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeSessionTokens(w, r, exec, refreshToken, expires)
+}
+
+// writeSessionTokens - Signs an access token and sets it and the refresh token as cookies, each expiring with its token, plus a new CSRF token;
+func writeSessionTokens(w http.ResponseWriter, r *http.Request, exec models.Exec, refreshToken string, refreshExpires time.Time) {
+	accessTTL, err := utils.AccessTokenTTL()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := utils.SignToken(strconv.Itoa(exec.Id), exec.Username, exec.Role, utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, "Err: Token generation failed!", http.StatusInternalServerError)
+		return
+	}
+
+	accessExpires := time.Now().Add(accessTTL)
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Bearer",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		Expires:  accessExpires,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Refresh",
+		Value:    refreshToken,
+		Path:     refreshCookiePath,
+		HttpOnly: true,
+		Secure:   true,
+		Expires:  refreshExpires,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	csrfToken, err := utils.SetCSRFCookie(w, refreshExpires)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status    string `json:"status"`
+		Token     string `json:"token"`
+		CSRFToken string `json:"csrf_token"`
+		ExpiresAt string `json:"expires_at"`
+	}{
+		Status:    "Success",
+		Token:     token,
+		CSRFToken: csrfToken,
+		ExpiresAt: accessExpires.Format(time.DateTime),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// clearSessionCookies - Removes the access token, refresh token and CSRF token cookies;
+func clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Bearer",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		Expires:  time.Unix(0, 0),
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Refresh",
+		Value:    "",
+		Path:     refreshCookiePath,
+		HttpOnly: true,
+		Secure:   true,
+		Expires:  time.Unix(0, 0),
+		SameSite: http.SameSiteStrictMode,
+	})
+	utils.ClearCSRFCookie(w)
+}
+
+// refreshTokenRequest - Reads the refresh token cookie and resolves the request to the school it was issued by;
+func refreshTokenRequest(r *http.Request) (*http.Request, string, error) {
+	cookie, err := r.Cookie("Refresh")
+	if err != nil {
+		return r, "", utils.HandleError(err, "Err: No refresh token!")
+	}
+
+	tenant, tokenHash, err := utils.ParseRefreshToken(cookie.Value)
+	if err != nil {
+		return r, "", err
+	}
+
+	current := utils.GetTenant(r)
+	if current == "" {
+		return r.WithContext(utils.WithTenant(r.Context(), tenant)), tokenHash, nil
+	}
+	if current != tenant {
+		return r, "", utils.HandleError(errors.New("tenant mismatch"), "Err: Token was issued for another school!")
+	}
+	return r, tokenHash, nil
+}
+
+// RefreshHandler - Exchanges the refresh token cookie for a new access token and a new refresh token;
+// The presented refresh token is spent; presenting it again revokes every token of the session;
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	r, tokenHash, err := refreshTokenRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	refreshToken, newHash, err := utils.NewRefreshToken(utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session, expires, err := newSession(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err, exec, _ := sqlconnect.RotateSessionDbHandler(r.Context(), tokenHash, newHash, session)
+	if err != nil {
+		clearSessionCookies(w)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	writeSessionTokens(w, r, exec, refreshToken, expires)
+}
+
+// revokeAccessToken - Revokes the access token of the request (header or cookie) until it expires, if it is still valid;
+func revokeAccessToken(r *http.Request) error {
+	token, _ := utils.RequestToken(r)
+	if token == "" {
+		return nil
+	}
+
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		return nil
+	}
+
+	jti, _ := claims["jti"].(string)
+	expires, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || expires == nil {
+		return nil
+	}
+	utils.RevokeToken(jti, expires.Time)
+
+	tenant, _ := claims["tid"].(string)
+	uid, _ := claims["uid"].(string)
+	execId, err := strconv.Atoi(uid)
+	if !utils.RevocationStoreInDb() || tenant == "" || err != nil {
+		return nil
+	}
+	current := utils.GetTenant(r)
+	if current != "" && current != tenant {
+		return nil
+	}
+	return sqlconnect.RevokeTokenDbHandler(utils.WithTenant(r.Context(), tenant), jti, execId, expires.Time)
+}
+
+// LogoutEverywhereHandler - Ends every session of the logged-in user: all access and refresh tokens issued so far are rejected;
+func LogoutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	userId := utils.GetUserId(r)
+	if userId == 0 || utils.GetUserRole(r) == "superadmin" {
+		http.Error(w, "Err: Unauthorized user!", http.StatusForbidden)
+		return
+	}
+
+	err := sqlconnect.RevokeAllTokensDbHandler(r.Context(), userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = revokeAccessToken(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	clearSessionCookies(w)
+
+	response := struct {
+		Status string `json:"status"`
+	}{
+		Status: "Success",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
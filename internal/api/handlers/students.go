@@ -101,7 +101,7 @@ func PatchStudentsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = sqlconnect.PatchStudentsDbHandler(updates)
+	err = sqlconnect.PatchStudentsDbHandler(r.Context(), updates)
 	if err != nil {
 		fmt.Println("Error: Failed to patch students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -121,7 +121,7 @@ func DeleteStudentsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, deletedIds := sqlconnect.DeleteStudentsDbHandler(ids)
+	err, deletedIds := sqlconnect.DeleteStudentsDbHandler(r.Context(), ids)
 	if err != nil {
 		fmt.Println("Error: Failed to delete students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -153,7 +153,7 @@ func GetStudentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err, student := sqlconnect.GetStudentHandler(id)
+	err, student := sqlconnect.GetStudentHandler(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -194,7 +194,7 @@ func UpdateStudentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update CRUD operation;
-	err, student := sqlconnect.UpdateStudentsDbHandler(id, updatedStudent)
+	err, student := sqlconnect.UpdateStudentsDbHandler(r.Context(), id, updatedStudent)
 	if err != nil {
 		fmt.Println("Err : Student Update Failed!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -232,7 +232,7 @@ func PatchStudentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, _ = sqlconnect.PatchStudentDbHandler(id, updates)
+	err, _ = sqlconnect.PatchStudentDbHandler(r.Context(), id, updates)
 	if err != nil {
 		fmt.Println("Error: Failed to patch students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -251,7 +251,7 @@ func DeleteStudentHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	}
 
-	err = sqlconnect.DeleteStudentDbHandler(id)
+	err = sqlconnect.DeleteStudentDbHandler(r.Context(), id)
 	if err != nil {
 		fmt.Println("Error: Failed to delete students!")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
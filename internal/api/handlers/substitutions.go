@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// GetUncoveredPeriodsHandler - Lists the day's periods (?date=, default today) that still need a substitute;
+func GetUncoveredPeriodsHandler(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format(time.DateOnly)
+	}
+
+	err, periods := sqlconnect.GetUncoveredPeriodsDbHandler(r.Context(), date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeCoverPeriods(w, periods)
+}
+
+// GetSubstitutionsHandler - Lists substitute assignments, filtered by ?date=, ?leave_id= or ?substitute_teacher_id=;
+func GetSubstitutionsHandler(w http.ResponseWriter, r *http.Request) {
+	err, substitutions := sqlconnect.GetSubstitutionsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status        string                `json:"status"`
+		Substitutions []models.Substitution `json:"substitutions"`
+		Count         int                   `json:"count"`
+	}{
+		Status:        "Success",
+		Substitutions: substitutions,
+		Count:         len(substitutions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AssignSubstituteHandler - Assigns a substitute teacher to a period on a date;
+func AssignSubstituteHandler(w http.ResponseWriter, r *http.Request) {
+	var substitution models.Substitution
+	err := json.NewDecoder(r.Body).Decode(&substitution)
+	if err != nil || substitution.SlotId == 0 || substitution.SubstituteTeacherId == 0 || substitution.Date == "" {
+		http.Error(w, "Err: slot_id, date and substitute_teacher_id are required!", http.StatusBadRequest)
+		return
+	}
+
+	substitution.Id = 0
+	substitution.AssignedBy = utils.GetUserId(r)
+
+	err, substitution = sqlconnect.AssignSubstituteDbHandler(r.Context(), substitution)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status       string              `json:"status"`
+		Substitution models.Substitution `json:"substitution"`
+	}{
+		Status:       "Success",
+		Substitution: substitution,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteSubstitutionHandler - Removes a substitute assignment;
+func DeleteSubstitutionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid substitution id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteSubstitutionDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"schoolManagement/internal/models"
 	"schoolManagement/internal/repositories/sqlconnect"
-	"schoolManagement/pkg/utils"
 	"strconv"
 )
 
@@ -42,7 +41,7 @@ func GetTeachersHandler(w http.ResponseWriter, r *http.Request) {
 func GetTeacherHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract path params;
 	idStr := r.PathValue("id")
-	err, teacher := sqlconnect.GetTeacherDbHandler(idStr)
+	err, teacher := sqlconnect.GetTeacherDbHandler(r.Context(), idStr)
 	if err != nil {
 		return
 	}
@@ -112,7 +111,7 @@ func UpdateTeachersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = sqlconnect.UpdateTeachersDbHandler(id, updatedTeachers)
+	err = sqlconnect.UpdateTeachersDbHandler(r.Context(), id, updatedTeachers)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -136,7 +135,7 @@ func PatchTeachersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = sqlconnect.PatchTeachersDbHandler(updates)
+	err = sqlconnect.PatchTeachersDbHandler(r.Context(), updates)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		return
@@ -164,7 +163,7 @@ func PatchTeacherHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, existingTeacher := sqlconnect.PatchTeacherDbHandler(id, updates)
+	err, existingTeacher := sqlconnect.PatchTeacherDbHandler(r.Context(), id, updates)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		return
@@ -187,7 +186,7 @@ func DeleteTeacherHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = sqlconnect.DeleteTeacherDbHandler(id)
+	err = sqlconnect.DeleteTeacherDbHandler(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -213,7 +212,7 @@ func DeleteTeachersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err, deletedIds := sqlconnect.DeleteTeachersDbHandler(ids)
+	err, deletedIds := sqlconnect.DeleteTeachersDbHandler(r.Context(), ids)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		return
@@ -236,7 +235,7 @@ func GetStudentsByTeacherHandler(w http.ResponseWriter, r *http.Request) {
 	teacherId := r.PathValue("id")
 	var students []models.Student
 
-	err, students := sqlconnect.GetStudentsByTeacherDbHandler(w, teacherId, students)
+	err, students := sqlconnect.GetStudentsByTeacherDbHandler(r.Context(), w, teacherId, students)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		return
@@ -262,14 +261,8 @@ func GetStudentsByTeacherHandler(w http.ResponseWriter, r *http.Request) {
 
 func GetStudentsCountByTeacherHandler(w http.ResponseWriter, r *http.Request) {
 
-	_, err := utils.AuthorizeUser(r.Context().Value(utils.ContextKey("role")).(string), "admin", "manager", "staff")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-
 	teacherId := r.PathValue("id")
-	err, count := sqlconnect.GetStudentsCountByTeacherDbHandler(w, teacherId)
+	err, count := sqlconnect.GetStudentsCountByTeacherDbHandler(r.Context(), w, teacherId)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		return
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"strconv"
+)
+
+// GetTimetableHandler - Lists timetable slots, filtered by ?teacher_id=, ?class=, ?subject= or ?day_of_week=;
+func GetTimetableHandler(w http.ResponseWriter, r *http.Request) {
+	err, slots := sqlconnect.GetTimetableSlotsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string                 `json:"status"`
+		Slots  []models.TimetableSlot `json:"slots"`
+		Count  int                    `json:"count"`
+	}{
+		Status: "Success",
+		Slots:  slots,
+		Count:  len(slots),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddTimetableSlotHandler - Adds a weekly timetable slot;
+func AddTimetableSlotHandler(w http.ResponseWriter, r *http.Request) {
+	var slot models.TimetableSlot
+	err := json.NewDecoder(r.Body).Decode(&slot)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateTimetableSlot(slot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, slot = sqlconnect.AddTimetableSlotDbHandler(r.Context(), slot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	response := struct {
+		Status string               `json:"status"`
+		Slot   models.TimetableSlot `json:"slot"`
+	}{
+		Status: "Success",
+		Slot:   slot,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteTimetableSlotHandler - Removes a timetable slot;
+func DeleteTimetableSlotHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid slot id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteTimetableSlotDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+func writeVehicle(w http.ResponseWriter, status int, vehicle models.Vehicle) {
+	response := struct {
+		Status  string         `json:"status"`
+		Vehicle models.Vehicle `json:"vehicle"`
+	}{
+		Status:  "Success",
+		Vehicle: vehicle,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeDriver(w http.ResponseWriter, status int, driver models.Driver) {
+	response := struct {
+		Status string        `json:"status"`
+		Driver models.Driver `json:"driver"`
+	}{
+		Status: "Success",
+		Driver: driver,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeTransportRoute(w http.ResponseWriter, status int, route models.TransportRoute) {
+	response := struct {
+		Status string                `json:"status"`
+		Route  models.TransportRoute `json:"route"`
+	}{
+		Status: "Success",
+		Route:  route,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeTransportAssignment(w http.ResponseWriter, status int, assignment models.TransportAssignment) {
+	response := struct {
+		Status     string                     `json:"status"`
+		Assignment models.TransportAssignment `json:"assignment"`
+	}{
+		Status:     "Success",
+		Assignment: assignment,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeRouteManifest(w http.ResponseWriter, status int, manifest models.RouteManifest) {
+	response := struct {
+		Status   string               `json:"status"`
+		Manifest models.RouteManifest `json:"manifest"`
+	}{
+		Status:   "Success",
+		Manifest: manifest,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeBoardingEvent(w http.ResponseWriter, status int, event models.BoardingEvent) {
+	response := struct {
+		Status string               `json:"status"`
+		Event  models.BoardingEvent `json:"boarding"`
+	}{
+		Status: "Success",
+		Event:  event,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func writeTransportDeleted(w http.ResponseWriter, id int) {
+	response := struct {
+		Status string `json:"status"`
+		Id     int    `json:"id"`
+	}{
+		Status: "Success",
+		Id:     id,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetVehiclesHandler - Lists the fleet, by ?active=true|false;
+func GetVehiclesHandler(w http.ResponseWriter, r *http.Request) {
+	err, vehicles := sqlconnect.GetVehiclesDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status   string           `json:"status"`
+		Vehicles []models.Vehicle `json:"vehicles"`
+		Count    int              `json:"count"`
+	}{
+		Status:   "Success",
+		Vehicles: vehicles,
+		Count:    len(vehicles),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddVehicleHandler - Adds a vehicle ({"registration", "model", "capacity"});
+func AddVehicleHandler(w http.ResponseWriter, r *http.Request) {
+	vehicle := models.Vehicle{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&vehicle)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateVehicle(vehicle)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, vehicle = sqlconnect.AddVehicleDbHandler(r.Context(), vehicle)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeVehicle(w, http.StatusCreated, vehicle)
+}
+
+// PatchVehicleHandler - Updates a vehicle; capacity cannot drop below the riders of its routes;
+func PatchVehicleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid vehicle id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, vehicle := sqlconnect.PatchVehicleDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeVehicle(w, http.StatusOK, vehicle)
+}
+
+// DeleteVehicleHandler - Removes a vehicle; its routes are left without one;
+func DeleteVehicleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid vehicle id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteVehicleDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeTransportDeleted(w, id)
+}
+
+// GetDriversHandler - Lists drivers by ?active= and ?license_expiring_before=;
+func GetDriversHandler(w http.ResponseWriter, r *http.Request) {
+	err, drivers := sqlconnect.GetDriversDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status  string          `json:"status"`
+		Drivers []models.Driver `json:"drivers"`
+		Count   int             `json:"count"`
+	}{
+		Status:  "Success",
+		Drivers: drivers,
+		Count:   len(drivers),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddDriverHandler - Adds a driver with their phone and licence;
+func AddDriverHandler(w http.ResponseWriter, r *http.Request) {
+	driver := models.Driver{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&driver)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateDriver(driver)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, driver = sqlconnect.AddDriverDbHandler(r.Context(), driver)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeDriver(w, http.StatusCreated, driver)
+}
+
+// PatchDriverHandler - Updates the contact, licence or active flag of a driver;
+func PatchDriverHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid driver id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, driver := sqlconnect.PatchDriverDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeDriver(w, http.StatusOK, driver)
+}
+
+// DeleteDriverHandler - Removes a driver; their routes are left without one;
+func DeleteDriverHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid driver id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteDriverDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeTransportDeleted(w, id)
+}
+
+// GetTransportRoutesHandler - Lists routes with their stops, by ?active=, ?vehicle_id= or ?driver_id=;
+func GetTransportRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	err, routes := sqlconnect.GetTransportRoutesDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string                  `json:"status"`
+		Routes []models.TransportRoute `json:"routes"`
+		Count  int                     `json:"count"`
+	}{
+		Status: "Success",
+		Routes: routes,
+		Count:  len(routes),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddTransportRouteHandler - Adds a route ({"name", "vehicle_id", "driver_id", "stops": [{"name", "address", "pickup_time", "dropoff_time"}]});
+func AddTransportRouteHandler(w http.ResponseWriter, r *http.Request) {
+	route := models.TransportRoute{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&route)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.ValidateTransportRoute(route)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, route = sqlconnect.AddTransportRouteDbHandler(r.Context(), route)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeTransportRoute(w, http.StatusCreated, route)
+}
+
+// GetTransportRouteHandler - Fetches a route with its stops, vehicle and driver;
+func GetTransportRouteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid route id!", http.StatusBadRequest)
+		return
+	}
+
+	err, route := sqlconnect.GetTransportRouteDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeTransportRoute(w, http.StatusOK, route)
+}
+
+// PatchTransportRouteHandler - Updates a route; "stops" replaces the whole list, keeping stops sent with their id;
+func PatchTransportRouteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid route id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, route := sqlconnect.PatchTransportRouteDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeTransportRoute(w, http.StatusOK, route)
+}
+
+// DeleteTransportRouteHandler - Deletes a route no student is assigned to anymore;
+func DeleteTransportRouteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid route id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteTransportRouteDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeTransportDeleted(w, id)
+}
+
+// GetRouteManifestHandler - Riders of a route by stop for ?date= (today by default), with boarding times once recorded;
+func GetRouteManifestHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid route id!", http.StatusBadRequest)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format(time.DateOnly)
+	}
+
+	err, manifest := sqlconnect.GetRouteManifestDbHandler(r.Context(), id, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeRouteManifest(w, http.StatusOK, manifest)
+}
+
+// RecordBoardingHandler - Records a rider getting on or off ({"student_id", "stop_id", "event": "boarded"|"alighted"}) on today's run;
+func RecordBoardingHandler(w http.ResponseWriter, r *http.Request) {
+	routeId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid route id!", http.StatusBadRequest)
+		return
+	}
+
+	var event models.BoardingEvent
+	err = json.NewDecoder(r.Body).Decode(&event)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+	event.RouteId = routeId
+	event.RecordedBy = utils.GetUserId(r)
+
+	err, event = sqlconnect.RecordBoardingDbHandler(r.Context(), event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeBoardingEvent(w, http.StatusCreated, event)
+}
+
+// GetTransportAssignmentsHandler - Lists rider assignments by ?route_id=, ?stop_id=, ?student_id=, ?class= and ?date=;
+func GetTransportAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	err, assignments := sqlconnect.GetTransportAssignmentsDbHandler(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Status      string                       `json:"status"`
+		Assignments []models.TransportAssignment `json:"assignments"`
+		Count       int                          `json:"count"`
+	}{
+		Status:      "Success",
+		Assignments: assignments,
+		Count:       len(assignments),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddTransportAssignmentHandler - Assigns a student to a route and stop; weekdays default to Monday to Friday and start_date to today;
+func AddTransportAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	var assignment models.TransportAssignment
+	err := json.NewDecoder(r.Body).Decode(&assignment)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+	if assignment.Weekdays == nil {
+		assignment.Weekdays = []int{1, 2, 3, 4, 5}
+	}
+	if assignment.StartDate == "" {
+		assignment.StartDate = time.Now().Format(time.DateOnly)
+	}
+
+	err = sqlconnect.ValidateTransportAssignment(assignment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err, assignment = sqlconnect.AddTransportAssignmentDbHandler(r.Context(), assignment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	writeTransportAssignment(w, http.StatusCreated, assignment)
+}
+
+// PatchTransportAssignmentHandler - Changes the stop, weekdays or dates of an assignment; set end_date when a student stops riding;
+func PatchTransportAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid assignment id!", http.StatusBadRequest)
+		return
+	}
+
+	var updates map[string]interface{}
+	err = json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	err, assignment := sqlconnect.PatchTransportAssignmentDbHandler(r.Context(), id, updates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeTransportAssignment(w, http.StatusOK, assignment)
+}
+
+// DeleteTransportAssignmentHandler - Removes an assignment entered by mistake;
+func DeleteTransportAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid assignment id!", http.StatusBadRequest)
+		return
+	}
+
+	err = sqlconnect.DeleteTransportAssignmentDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeTransportDeleted(w, id)
+}
+
+// GetStudentTransportHandler - The route, stop and pickup time of a student; guardians can see their own children's;
+func GetStudentTransportHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	if !callerCan(r, transportRidersAll) {
+		err, linked := sqlconnect.IsStudentGuardianDbHandler(r.Context(), studentId, utils.GetUserId(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !linked {
+			http.Error(w, "Err: Guardians can only see their own children!", http.StatusForbidden)
+			return
+		}
+	}
+
+	err, transport := sqlconnect.GetStudentTransportDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Status    string                    `json:"status"`
+		Transport []models.StudentTransport `json:"transport"`
+		Count     int                       `json:"count"`
+	}{
+		Status:    "Success",
+		Transport: transport,
+		Count:     len(transport),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// qrCodeScale - Pixels per QR code module in enrollment images;
+const qrCodeScale = 6
+
+// challengeSecondFactor - Answers a correct password with a login challenge when the exec has two-factor authentication,
+// or their role requires it; reports whether it did, otherwise the caller starts the session;
+func challengeSecondFactor(w http.ResponseWriter, r *http.Request, exec models.Exec) bool {
+	err, twoFactor := sqlconnect.GetTwoFactorDbHandler(r.Context(), exec.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	if !twoFactor.Enabled && !utils.TwoFactorRequired(exec.Role) {
+		return false
+	}
+
+	challenge, expires := utils.SignLoginChallenge(exec.Id, utils.GetTenant(r))
+	response := struct {
+		Status             string `json:"status"`
+		Challenge          string `json:"challenge"`
+		ExpiresAt          string `json:"expires_at"`
+		EnrollmentRequired bool   `json:"enrollment_required"`
+	}{
+		Status:             "TwoFactorRequired",
+		Challenge:          challenge,
+		ExpiresAt:          expires.Format(time.DateTime),
+		EnrollmentRequired: !twoFactor.Enabled,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+// challengeExec - Decodes a two-factor request and returns the active exec its login challenge was issued to;
+// Reports false when it already answered the request;
+func challengeExec(w http.ResponseWriter, r *http.Request) (models.TwoFactorRequest, models.Exec, bool) {
+	var req models.TwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return req, models.Exec{}, false
+	}
+
+	execId, err := utils.ParseLoginChallenge(req.Challenge, utils.GetTenant(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return req, models.Exec{}, false
+	}
+
+	err, exec := sqlconnect.GetLoginExecDbHandler(r.Context(), execId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return req, models.Exec{}, false
+	}
+	if exec.Inactive {
+		http.Error(w, "Err: Account is inactive!", http.StatusForbidden)
+		return req, models.Exec{}, false
+	}
+	return req, exec, true
+}
+
+// verifySecondFactor - Checks the TOTP code or recovery code of a login challenge, throttled like the password;
+// Reports false when it answered the request (wrong code, back-off or error);
+func verifySecondFactor(w http.ResponseWriter, r *http.Request, exec models.Exec, req models.TwoFactorRequest) bool {
+	if req.Code == "" && req.RecoveryCode == "" {
+		http.Error(w, "Err: Two-factor code or recovery code is missing!", http.StatusBadRequest)
+		return false
+	}
+
+	if checkLoginWait(w, r, exec.Username) {
+		return false
+	}
+
+	err, ok := sqlconnect.VerifyTwoFactorDbHandler(r.Context(), exec.Id, req.Code, req.RecoveryCode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		failLogin(w, r, exec.Username, &exec, invalidCodeMessage)
+		return false
+	}
+	return true
+}
+
+// checkSecondFactor - Checks the TOTP code or recovery code a logged-in exec gives in their account settings;
+// Not a login, so a wrong code does not count towards the login lockout; reports false when it answered the request;
+func checkSecondFactor(w http.ResponseWriter, r *http.Request, exec models.Exec, req models.TwoFactorRequest) bool {
+	if req.Code == "" && req.RecoveryCode == "" {
+		http.Error(w, "Err: Two-factor code or recovery code is missing!", http.StatusBadRequest)
+		return false
+	}
+
+	err, ok := sqlconnect.VerifyTwoFactorDbHandler(r.Context(), exec.Id, req.Code, req.RecoveryCode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		http.Error(w, invalidCodeMessage, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// enrollTwoFactor - Starts an enrollment for an exec: a new secret as otpauth URI and QR code PNG (base64), with new recovery codes;
+func enrollTwoFactor(w http.ResponseWriter, r *http.Request, exec models.Exec) {
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	codes, hashes, err := utils.NewRecoveryCodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = sqlconnect.StartTwoFactorEnrollmentDbHandler(r.Context(), exec.Id, secret, hashes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	uri := utils.TOTPURI(utils.TwoFactorIssuer(), exec.Username, secret)
+	qrCode, err := utils.QRCodePNG(uri, qrCodeScale)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status     string                     `json:"status"`
+		Enrollment models.TwoFactorEnrollment `json:"enrollment"`
+	}{
+		Status: "Success",
+		Enrollment: models.TwoFactorEnrollment{
+			Secret:        secret,
+			URI:           uri,
+			QRCodePNG:     base64.StdEncoding.EncodeToString(qrCode),
+			RecoveryCodes: codes,
+		},
+	}
+
+	// The secret and recovery codes must not end up in shared caches;
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// LoginTwoFactorHandler - Second login step: exchanges the login challenge and a TOTP code (or a recovery code) for a session;
+// The first code of a pending enrollment confirms it;
+func LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	req, exec, ok := challengeExec(w, r)
+	if !ok {
+		return
+	}
+
+	err, twoFactor := sqlconnect.GetTwoFactorDbHandler(r.Context(), exec.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !twoFactor.Enabled && !twoFactor.Pending {
+		http.Error(w, "Err: Two-factor authentication is not set up, enroll first!", http.StatusBadRequest)
+		return
+	}
+
+	if !verifySecondFactor(w, r, exec, req) {
+		return
+	}
+
+	err = clearLoginFailures(r, exec.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	startSession(w, r, exec)
+}
+
+// LoginTwoFactorEnrollHandler - Enrollment during login, for execs whose role requires two-factor authentication they do not have yet;
+func LoginTwoFactorEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	_, exec, ok := challengeExec(w, r)
+	if !ok {
+		return
+	}
+
+	// No new secrets for a client IP or username that is locked out;
+	if checkLoginWait(w, r, exec.Username) {
+		return
+	}
+
+	err, twoFactor := sqlconnect.GetTwoFactorDbHandler(r.Context(), exec.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if twoFactor.Enabled {
+		http.Error(w, "Err: Two-factor authentication is already enabled!", http.StatusConflict)
+		return
+	}
+
+	enrollTwoFactor(w, r, exec)
+}
+
+// myTwoFactorExec - The logged-in exec, for the two-factor routes; super-admins have no exec account in a school;
+func myTwoFactorExec(w http.ResponseWriter, r *http.Request) (models.Exec, models.TwoFactor, bool) {
+	userId := utils.GetUserId(r)
+	if userId == 0 || utils.GetUserRole(r) == "superadmin" {
+		http.Error(w, "Err: Unauthorized user!", http.StatusForbidden)
+		return models.Exec{}, models.TwoFactor{}, false
+	}
+
+	err, exec := sqlconnect.GetLoginExecDbHandler(r.Context(), userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return models.Exec{}, models.TwoFactor{}, false
+	}
+
+	err, twoFactor := sqlconnect.GetTwoFactorDbHandler(r.Context(), userId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return models.Exec{}, models.TwoFactor{}, false
+	}
+	twoFactor.Required = utils.TwoFactorRequired(exec.Role)
+	return exec, twoFactor, true
+}
+
+// GetMyTwoFactorHandler - Two-factor state of the logged-in exec;
+func GetMyTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	_, twoFactor, ok := myTwoFactorExec(w, r)
+	if !ok {
+		return
+	}
+
+	response := struct {
+		Status    string           `json:"status"`
+		TwoFactor models.TwoFactor `json:"two_factor"`
+	}{
+		Status:    "Success",
+		TwoFactor: twoFactor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// EnrollTwoFactorHandler - Starts (or restarts) two-factor enrollment of the logged-in exec; it takes effect once confirmed;
+func EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	exec, twoFactor, ok := myTwoFactorExec(w, r)
+	if !ok {
+		return
+	}
+	if twoFactor.Enabled {
+		http.Error(w, "Err: Two-factor authentication is already enabled!", http.StatusConflict)
+		return
+	}
+
+	enrollTwoFactor(w, r, exec)
+}
+
+// ConfirmTwoFactorHandler - Enables two-factor authentication of the logged-in exec with a first code from the authenticator app;
+func ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	exec, twoFactor, ok := myTwoFactorExec(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if twoFactor.Enabled {
+		http.Error(w, "Err: Two-factor authentication is already enabled!", http.StatusConflict)
+		return
+	}
+	if !twoFactor.Pending {
+		http.Error(w, "Err: Two-factor authentication is not set up, enroll first!", http.StatusBadRequest)
+		return
+	}
+
+	// Recovery codes cannot confirm an enrollment;
+	req.RecoveryCode = ""
+	if !checkSecondFactor(w, r, exec, req) {
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+	}{
+		Status: "Success",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RegenerateRecoveryCodesHandler - Replaces the recovery codes of the logged-in exec, given a current code;
+func RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	exec, twoFactor, ok := myTwoFactorExec(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if !twoFactor.Enabled {
+		http.Error(w, "Err: Two-factor authentication is not enabled!", http.StatusBadRequest)
+		return
+	}
+	if !checkSecondFactor(w, r, exec, req) {
+		return
+	}
+
+	codes, hashes, err := utils.NewRecoveryCodes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = sqlconnect.ReplaceRecoveryCodesDbHandler(r.Context(), exec.Id, hashes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status        string   `json:"status"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		Status:        "Success",
+		RecoveryCodes: codes,
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DisableTwoFactorHandler - Turns off two-factor authentication of the logged-in exec, given a current code or recovery code;
+// A pending enrollment is cancelled without one; roles that require two-factor authentication cannot turn it off;
+func DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	exec, twoFactor, ok := myTwoFactorExec(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	if twoFactor.Required && twoFactor.Enabled {
+		http.Error(w, "Err: Two-factor authentication is mandatory for your role!", http.StatusForbidden)
+		return
+	}
+	if !twoFactor.Enabled && !twoFactor.Pending {
+		http.Error(w, "Err: Two-factor authentication is not enabled!", http.StatusBadRequest)
+		return
+	}
+	if twoFactor.Enabled && !checkSecondFactor(w, r, exec, req) {
+		return
+	}
+
+	err, _ = sqlconnect.DisableTwoFactorDbHandler(r.Context(), exec.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+	}{
+		Status: "Success",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ResetTwoFactorHandler - Removes the two-factor authentication of an exec who lost their device and recovery codes;
+// They log in with the password alone again, or enroll anew at login when their role requires it; they are told by email;
+func ResetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid user id!", http.StatusBadRequest)
+		return
+	}
+
+	err, exec := sqlconnect.DisableTwoFactorDbHandler(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if exec.Email != "" {
+		go notifyTwoFactorReset(exec)
+	}
+
+	response := struct {
+		Status string `json:"status"`
+	}{
+		Status: "Success",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// notifyTwoFactorReset - Tells an exec that an administrator removed their two-factor authentication; failures are only logged;
+func notifyTwoFactorReset(exec models.Exec) {
+	body := fmt.Sprintf("Hello %s,<br>An administrator removed the two-factor authentication of your account. "+
+		"Please set it up again after your next login.<br>"+
+		"If you did not ask for this, please contact your school's administration.",
+		html.EscapeString(exec.FirstName))
+	err := utils.SendMail(exec.Email, "Two-factor authentication was reset", body)
+	if err != nil {
+		log.Println("Two-factor reset notification failed : ", err)
+	}
+}
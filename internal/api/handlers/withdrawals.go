@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"schoolManagement/internal/models"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// WithdrawStudentHandler - Records that a student left (withdrawal or transfer) and marks them inactive instead of deleting them;
+func WithdrawStudentHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	var withdrawal models.Withdrawal
+	err = json.NewDecoder(r.Body).Decode(&withdrawal)
+	if err != nil {
+		http.Error(w, "Err: Invalid request body!", http.StatusBadRequest)
+		return
+	}
+
+	// Data validation;
+	if withdrawal.Type != "withdrawal" && withdrawal.Type != "transfer" {
+		http.Error(w, "Err: Type must be withdrawal or transfer!", http.StatusBadRequest)
+		return
+	}
+	if withdrawal.Reason == "" {
+		http.Error(w, "Err: Reason is required!", http.StatusBadRequest)
+		return
+	}
+	if withdrawal.Type == "transfer" && withdrawal.DestinationSchool == "" {
+		http.Error(w, "Err: Destination school is required for transfers!", http.StatusBadRequest)
+		return
+	}
+	if withdrawal.LeavingDate == "" {
+		withdrawal.LeavingDate = time.Now().Format(time.DateOnly)
+	}
+	_, err = time.Parse(time.DateOnly, withdrawal.LeavingDate)
+	if err != nil {
+		http.Error(w, "Err: Leaving date must be in YYYY-MM-DD format!", http.StatusBadRequest)
+		return
+	}
+	if withdrawal.WorkingDays < 0 || withdrawal.DaysPresent < 0 || withdrawal.DaysPresent > withdrawal.WorkingDays {
+		http.Error(w, "Err: Invalid attendance figures!", http.StatusBadRequest)
+		return
+	}
+	withdrawal.RecordedBy = utils.GetUserId(r)
+
+	err, withdrawal = sqlconnect.WithdrawStudentDbHandler(r.Context(), studentId, withdrawal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeWithdrawal(w, withdrawal)
+}
+
+// GetStudentWithdrawalHandler - Gets the withdrawal/transfer record of a student;
+func GetStudentWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, withdrawal := sqlconnect.GetStudentWithdrawalDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeWithdrawal(w, withdrawal)
+}
+
+func writeWithdrawal(w http.ResponseWriter, withdrawal models.Withdrawal) {
+	response := struct {
+		Status     string            `json:"status"`
+		Withdrawal models.Withdrawal `json:"withdrawal"`
+	}{
+		Status:     "Success",
+		Withdrawal: withdrawal,
+	}
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetTransferCertificateHandler - Renders the transfer certificate as HTML (default) or PDF (?format=pdf);
+func GetTransferCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	studentId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Err: Invalid student id!", http.StatusBadRequest)
+		return
+	}
+
+	if !studentVisible(w, r, studentId) {
+		return
+	}
+
+	err, certificate := sqlconnect.GetTransferCertificateDbHandler(r.Context(), studentId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	certificate.SchoolName = os.Getenv("SCHOOL_NAME")
+	if certificate.SchoolName == "" {
+		certificate.SchoolName = "School"
+	}
+	certificate.IssuedOn = time.Now().Format(time.DateOnly)
+
+	if r.URL.Query().Get("format") == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", certificate.CertificateNumber))
+		_, err = w.Write(transferCertificatePDF(certificate))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = transferCertificateTemplate.Execute(w, certificate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// transferCertificateRows - Label/value pairs shared by the HTML and PDF certificates;
+func transferCertificateRows(certificate models.TransferCertificate) [][2]string {
+	withdrawal := certificate.Withdrawal
+	destination := withdrawal.DestinationSchool
+	if destination == "" {
+		destination = "-"
+	}
+	admissionDate := certificate.AdmissionDate
+	if admissionDate == "" {
+		admissionDate = "-"
+	}
+
+	return [][2]string{
+		{"Name of the student", certificate.Student.FirstName + " " + certificate.Student.LastName},
+		{"Student ID", strconv.Itoa(certificate.Student.Id)},
+		{"Date of admission", admissionDate},
+		{"Last class attended", withdrawal.LastClass},
+		{"Date of leaving", withdrawal.LeavingDate},
+		{"Reason for leaving", withdrawal.Reason},
+		{"School transferred to", destination},
+		{"Working days", strconv.Itoa(withdrawal.WorkingDays)},
+		{"Days present", strconv.Itoa(withdrawal.DaysPresent)},
+		{"Attendance", fmt.Sprintf("%.1f%%", certificate.AttendancePercentage)},
+	}
+}
+
+var transferCertificateTemplate = template.Must(template.New("tc").Funcs(template.FuncMap{
+	"rows": transferCertificateRows,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Transfer Certificate {{.CertificateNumber}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 40px; }
+h1, h2 { text-align: center; margin: 4px; }
+table { width: 100%; border-collapse: collapse; margin-top: 24px; }
+td { border: 1px solid #444; padding: 8px; }
+td.label { width: 40%; font-weight: bold; }
+.footer { margin-top: 48px; display: flex; justify-content: space-between; }
+</style>
+</head>
+<body>
+<h1>{{.SchoolName}}</h1>
+<h2>Transfer Certificate</h2>
+<p>Certificate No: {{.CertificateNumber}}<br>Issued on: {{.IssuedOn}}</p>
+<table>
+{{range rows .}}<tr><td class="label">{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+<div class="footer"><span>Date: {{.IssuedOn}}</span><span>Signature of the Principal</span></div>
+</body>
+</html>
+`))
+
+// transferCertificatePDF - Lays the certificate out on a single A4 page;
+func transferCertificatePDF(certificate models.TransferCertificate) []byte {
+	doc := utils.NewPDFDocument(utils.PDFA4Width, utils.PDFA4Height)
+	page := doc.AddPage()
+
+	page.Text(60, 780, 20, true, certificate.SchoolName)
+	page.Text(60, 752, 16, true, "Transfer Certificate")
+	page.Line(60, 740, 535, 740, 1)
+	page.Text(60, 720, 11, false, "Certificate No: "+certificate.CertificateNumber)
+	page.Text(380, 720, 11, false, "Issued on: "+certificate.IssuedOn)
+
+	y := 680.0
+	for _, row := range transferCertificateRows(certificate) {
+		page.Text(60, y, 12, true, row[0])
+		page.Text(260, y, 12, false, row[1])
+		page.Line(60, y-8, 535, y-8, 0.3)
+		y -= 30
+	}
+
+	page.Line(380, 140, 535, 140, 0.5)
+	page.Text(380, 125, 10, false, "Signature of the Principal")
+	page.Text(60, 125, 10, false, "Date: "+certificate.IssuedOn)
+
+	return doc.Bytes()
+}
@@ -19,26 +19,24 @@ func CompressionMiddleware(next http.Handler) http.Handler {
 			return // Important! Prevents continuing to write gzip when client doesn't support it
 		}
 
-		// Step 2: Set the Content-Encoding header to tell the client we are using gzip
-		w.Header().Set("Content-Encoding", "gzip")
+		// Step 2: Wrap the ResponseWriter with our custom gzipResponseWriter
+		// Compression is decided on the first write, once the handler has set the Content-Type:
+		// binary content such as images, PDFs or archives is already compressed and is passed through as is
+		gw := &gzipResponseWriter{ResponseWriter: w}
 
-		// Step 3: Create a new gzip writer that wraps the original ResponseWriter
-		gz := gzip.NewWriter(w)
-
-		// Step 4: Make sure to close the gzip writer when the handler completes
+		// Step 3: Make sure to close the gzip writer (if one was created) when the handler completes
 		defer func() {
-			err := gz.Close()
+			if gw.writer == nil {
+				return
+			}
+			err := gw.writer.Close()
 			if err != nil {
 				// If closing the gzip writer fails, return a 500 error
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
 		}()
 
-		// Step 5: Wrap the ResponseWriter with our custom gzipResponseWriter
-		// This ensures all writes go through gzip.Writer
-		gw := &gzipResponseWriter{ResponseWriter: w, writer: gz}
-
-		// Step 6: Call the next handler, passing in our wrapped writer
+		// Step 4: Call the next handler, passing in our wrapped writer
 		next.ServeHTTP(gw, r)
 
 		// Optional logging for debug purposes
@@ -47,18 +45,66 @@ func CompressionMiddleware(next http.Handler) http.Handler {
 }
 
 // gzipResponseWriter is a custom writer that wraps the original ResponseWriter
-// and compresses the response using gzip.
+// and compresses the response using gzip when the content type benefits from it.
 type gzipResponseWriter struct {
 	http.ResponseWriter // Embedded interface: allows our struct to behave like a ResponseWriter
 
-	writer *gzip.Writer // Pointer to gzip.Writer: handles actual compression.
+	writer *gzip.Writer // Pointer to gzip.Writer: handles actual compression, nil while undecided or when passing through.
 	// We use a pointer so that we are writing to the same gzip stream instance
 	// and can later close it. This avoids copying and allows shared state.
+
+	decided bool // Whether the compress / pass through decision has been made
+}
+
+// compressibleTypes lists the content type prefixes worth compressing; everything else is sent as is
+var compressibleTypes = []string{"text/", "application/json", "application/xml", "application/javascript", "image/svg+xml"}
+
+// decide picks gzip or pass through based on the response headers, before anything is sent
+func (g *gzipResponseWriter) decide(b []byte) {
+	if g.decided {
+		return
+	}
+	g.decided = true
+
+	header := g.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(b)
+	}
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			// Set the Content-Encoding header to tell the client we are using gzip
+			header.Set("Content-Encoding", "gzip")
+			header.Add("Vary", "Accept-Encoding")
+			// The length of the compressed body is not known upfront
+			header.Del("Content-Length")
+			g.writer = gzip.NewWriter(g.ResponseWriter)
+			return
+		}
+	}
+}
+
+// WriteHeader makes the decision before the headers are sent
+func (g *gzipResponseWriter) WriteHeader(statusCode int) {
+	// Responses without a body must not get a gzip stream either
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		g.decided = true
+	}
+	g.decide(nil)
+	g.ResponseWriter.WriteHeader(statusCode)
 }
 
 // Write overrides the default Write method of http.ResponseWriter
-// It writes the data through gzip.Writer instead of sending it directly.
-func (g gzipResponseWriter) Write(b []byte) (int, error) {
+// It writes the data through gzip.Writer when compressing, otherwise directly.
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	g.decide(b)
+	if g.writer == nil {
+		return g.ResponseWriter.Write(b)
+	}
 	// Data gets compressed before being written to the underlying response
 	return g.writer.Write(b)
 }
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"schoolManagement/pkg/utils"
+)
+
+// CSRFMiddleware - Double-submit CSRF check for state-changing requests (POST, PUT, PATCH, DELETE) that ride on our cookies:
+// the X-CSRF-Token header must match the XSRF-TOKEN cookie issued at login;
+// Requests authenticated by an Authorization header, and requests carrying none of the session cookies, cannot be forged and pass;
+// Runs after the JWT middleware (which records the auth source); paths are exempted with MiddleWareExcludePaths;
+func CSRFMiddleware(next http.Handler) http.Handler {
+	fmt.Println("-------------( CSRF MIDDLEWARE STARTED )-------------")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if utils.GetAuthSource(r) == utils.AuthSourceHeader || !hasSessionCookie(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(utils.CSRFCookieName)
+		header := r.Header.Get(utils.CSRFHeaderName)
+		if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			http.Error(w, "Err: CSRF token missing or invalid!", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasSessionCookie - Whether the browser sent one of the cookies that authenticate a request;
+func hasSessionCookie(r *http.Request) bool {
+	for _, name := range []string{"Bearer", "Refresh"} {
+		_, err := r.Cookie(name)
+		if err == nil {
+			return true
+		}
+	}
+	return false
+}
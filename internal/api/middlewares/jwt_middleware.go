@@ -8,21 +8,23 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"schoolManagement/internal/repositories/sqlconnect"
 	"schoolManagement/pkg/utils"
+	"strconv"
 )
 
 func JWTMiddleware(next http.Handler) http.Handler {
 	fmt.Println("-------------( JWT MIDDLEWARE STARTED )-------------")
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("-------------( INSIDE JWT MIDDLEWARE )-------------")
-		token, err := r.Cookie("Bearer")
-		if err != nil {
+		token, source := utils.RequestToken(r)
+		if token == "" {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 		jwtSecret := os.Getenv("JWT_SECRET")
 
-		parsedToken, err := jwt.Parse(token.Value, func(token *jwt.Token) (interface{}, error) {
+		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 			// hmacSampleSecret is a []byte containing your secret, e.g. []byte("my_secret_key")
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -44,6 +46,7 @@ func JWTMiddleware(next http.Handler) http.Handler {
 		} else {
 			http.Error(w, "Token invalid!", http.StatusUnauthorized)
 			log.Println("Invalid JWT token")
+			return
 		}
 
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
@@ -57,8 +60,73 @@ func JWTMiddleware(next http.Handler) http.Handler {
 		ctx = context.WithValue(ctx, utils.ContextKey("expiry"), claims["exp"])
 		ctx = context.WithValue(ctx, utils.ContextKey("username"), claims["user"])
 		ctx = context.WithValue(ctx, utils.ContextKey("userid"), claims["uid"])
+		ctx = context.WithValue(ctx, utils.ContextKey("auth_source"), source)
+
+		// A token is only valid for the school it was issued by; super-admins are not bound to a school but may only read;
+		role, _ := claims["role"].(string)
+		tokenTenant, _ := claims["tid"].(string)
+		tenant := utils.TenantFromContext(ctx)
+		if role == "superadmin" {
+			if tenant != "" && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "Err: Super-admins have read-only access to schools!", http.StatusForbidden)
+				return
+			}
+		} else if tenant == "" {
+			ctx = utils.WithTenant(ctx, tokenTenant)
+		} else if tenant != tokenTenant {
+			http.Error(w, "Err: Token was issued for another school!", http.StatusForbidden)
+			return
+		}
+
+		revoked, err := tokenRevoked(ctx, claims, role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token revoked!", http.StatusUnauthorized)
+			return
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 		fmt.Println("-------------( SENT RESPONSE FROM JWT MIDDLEWARE )-------------")
 	})
 }
+
+// tokenRevoked - Whether a valid token was revoked: logged out (its jti), or issued before the user's
+// last password change or "log out everywhere"; super-admin tokens can only be revoked in memory;
+func tokenRevoked(ctx context.Context, claims jwt.MapClaims, role string) (bool, error) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" || utils.IsTokenRevoked(jti) {
+		return true, nil
+	}
+	if role == "superadmin" {
+		return false, nil
+	}
+
+	if utils.RevocationStoreInDb() {
+		err, revoked := sqlconnect.IsTokenRevokedDbHandler(ctx, jti)
+		if err != nil {
+			return false, err
+		}
+		if revoked {
+			return true, nil
+		}
+	}
+
+	uid, _ := claims["uid"].(string)
+	userId, err := strconv.Atoi(uid)
+	if err != nil {
+		return true, nil
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return true, nil
+	}
+
+	err, cutoff := sqlconnect.GetTokenCutoffDbHandler(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return issuedAt.Time.Before(cutoff), nil
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+	"schoolManagement/pkg/rbac"
+	"schoolManagement/pkg/utils"
+)
+
+// RequirePermission - Wraps a route handler so that only roles holding the permission (see pkg/rbac) reach it;
+// Routes are declared with their permission in the routers package; the role comes from the JWT middleware;
+func RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	rbac.Declare(permission)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rbac.Can(utils.GetUserRole(r), permission) {
+			http.Error(w, "Err: Permission "+permission+" required!", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -1,7 +1,7 @@
 package middlewares
 
 import (
-	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -44,31 +44,30 @@ func (rl *RateLimiter) resetVisitorCount() {
 
 // Middleware is the actual HTTP middleware function that applies rate limiting logic.
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	fmt.Println("RATE LIMITER MIDDLEWARE STARTED")
-
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// Step 1: Lock the mutex to protect the visitor map from concurrent access
-		rl.mu.Lock()
-		defer rl.mu.Unlock() // Always unlock at the end of this function
-
-		// Step 2: Use the client's IP address to identify them
-		visitorIP := r.RemoteAddr // Note: This includes port; may want to strip it in real-world cases
+		// Step 1: Use the client's IP address to identify them
+		// RemoteAddr is "ip:port" and the port changes per connection, so only the host part is used
+		visitorIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			visitorIP = r.RemoteAddr
+		}
 
-		// Step 3: Increment request count for this visitor
+		// Step 2: Increment request count for this visitor, holding the lock only around the map access
+		// so that slow handlers do not block the requests of other visitors
+		rl.mu.Lock()
 		rl.visitor[visitorIP]++
-		fmt.Printf("Visitor count from %s is %d\n", visitorIP, rl.visitor[visitorIP])
+		count := rl.visitor[visitorIP]
+		rl.mu.Unlock()
 
-		// Step 4: Check if the visitor has exceeded the request limit
-		if rl.visitor[visitorIP] > rl.limit {
+		// Step 3: Check if the visitor has exceeded the request limit
+		if count > rl.limit {
 			// Respond with HTTP 429 Too Many Requests if limit is exceeded
 			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
 			return
 		}
 
-		// Step 5: Forward the request to the next handler if within the limit
+		// Step 4: Forward the request to the next handler if within the limit
 		next.ServeHTTP(w, r)
-		fmt.Println("RATE LIMITER MIDDLEWARE ENDED")
-
 	})
 }
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"schoolManagement/internal/repositories/sqlconnect"
+	"schoolManagement/pkg/utils"
+)
+
+// TenantMiddleware - Resolves the school (tenant) of the request and stores its slug in the context;
+// The school is taken from the X-Tenant header or ?tenant= query when given, else from the host name;
+// Requests that resolve to no school are passed on unresolved, the JWT middleware then falls back to the token's school;
+func TenantMiddleware(next http.Handler) http.Handler {
+	fmt.Println("-------------( TENANT MIDDLEWARE STARTED )-------------")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug := r.Header.Get("X-Tenant")
+		if slug == "" {
+			slug = r.URL.Query().Get("tenant")
+		}
+
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		err, tenant, ok := sqlconnect.ResolveTenantDbHandler(slug, host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !tenant.Active {
+			http.Error(w, "Err: The school is not active!", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(utils.WithTenant(r.Context(), tenant.Slug)))
+	})
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+	"time"
+)
+
+func AdmissionsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Public application form (excluded from JWT in main), rate limited per client IP;
+	applyLimiter := mw.NewRateLimiter(5, time.Minute)
+	mux.Handle("POST /admissions/apply", applyLimiter.Middleware(http.HandlerFunc(handlers.SubmitApplicationHandler)))
+
+	// Admissions pipeline for staff;
+	mux.HandleFunc("GET /applicants", mw.RequirePermission("admissions:read", handlers.GetApplicantsHandler))
+	mux.HandleFunc("GET /applicants/{id}", mw.RequirePermission("admissions:read", handlers.GetApplicantHandler))
+	mux.HandleFunc("GET /applicants/{id}/history", mw.RequirePermission("admissions:read", handlers.GetApplicantHistoryHandler))
+	mux.HandleFunc("POST /applicants/{id}/transitions", mw.RequirePermission("admissions:write", handlers.TransitionApplicantHandler))
+
+	return mux
+}
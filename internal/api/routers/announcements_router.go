@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func AnnouncementsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for announcements route;
+	mux.HandleFunc("GET /announcements", mw.RequirePermission("announcements:read", handlers.GetAnnouncementsHandler))
+	mux.HandleFunc("POST /announcements", mw.RequirePermission("announcements:write", handlers.AddAnnouncementHandler))
+
+	// By ID handlers for announcements route;
+	mux.HandleFunc("GET /announcements/{id}", mw.RequirePermission("announcements:read", handlers.GetAnnouncementHandler))
+	mux.HandleFunc("PATCH /announcements/{id}", mw.RequirePermission("announcements:write", handlers.PatchAnnouncementHandler))
+	mux.HandleFunc("DELETE /announcements/{id}", mw.RequirePermission("announcements:write", handlers.DeleteAnnouncementHandler))
+	mux.HandleFunc("GET /announcements/{id}/reads", mw.RequirePermission("announcements:read", handlers.GetAnnouncementReadsHandler))
+
+	return mux
+}
@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func ClubsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /clubs", mw.RequirePermission("clubs:read", handlers.GetClubsHandler))
+	mux.HandleFunc("POST /clubs", mw.RequirePermission("clubs:write", handlers.AddClubHandler))
+
+	// By ID handlers for clubs route;
+	mux.HandleFunc("GET /clubs/{id}", mw.RequirePermission("clubs:read", handlers.GetClubHandler))
+	mux.HandleFunc("PATCH /clubs/{id}", mw.RequirePermission("clubs:write", handlers.PatchClubHandler))
+	mux.HandleFunc("DELETE /clubs/{id}", mw.RequirePermission("clubs:write", handlers.DeleteClubHandler))
+	mux.HandleFunc("GET /clubs/{id}/members", mw.RequirePermission("clubs:read", handlers.GetClubMembersHandler))
+	mux.HandleFunc("POST /clubs/{id}/members", mw.RequirePermission("clubs:run", handlers.AddClubMemberHandler))
+	mux.HandleFunc("DELETE /clubs/{id}/members/{studentId}", mw.RequirePermission("clubs:run", handlers.RemoveClubMemberHandler))
+	mux.HandleFunc("GET /clubs/{id}/sessions", mw.RequirePermission("clubs:read", handlers.GetClubSessionsHandler))
+	mux.HandleFunc("POST /clubs/{id}/sessions", mw.RequirePermission("clubs:run", handlers.AddClubSessionHandler))
+	mux.HandleFunc("GET /clubs/{id}/sessions/{sessionId}/attendance", mw.RequirePermission("clubs:read", handlers.GetSessionAttendanceHandler))
+	mux.HandleFunc("PUT /clubs/{id}/sessions/{sessionId}/attendance", mw.RequirePermission("clubs:run", handlers.SaveSessionAttendanceHandler))
+	mux.HandleFunc("GET /clubs/{id}/report", mw.RequirePermission("clubs:read", handlers.GetClubReportHandler))
+
+	return mux
+}
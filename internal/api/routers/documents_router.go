@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func DocumentsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// By ID handlers for documents route;
+	mux.HandleFunc("GET /documents/{id}", mw.RequirePermission("documents:read", handlers.GetDocumentHandler))
+	mux.HandleFunc("DELETE /documents/{id}", mw.RequirePermission("documents:admin", handlers.DeleteDocumentHandler))
+	mux.HandleFunc("POST /documents/{id}/versions", mw.RequirePermission("documents:write", handlers.AddDocumentVersionHandler))
+	mux.HandleFunc("GET /documents/{id}/link", mw.RequirePermission("documents:read", handlers.GetDocumentLinkHandler))
+
+	// Signed downloads, served without the JWT;
+	mux.HandleFunc("GET /files/documents/{id}/versions/{version}", handlers.DownloadDocumentHandler)
+
+	return mux
+}
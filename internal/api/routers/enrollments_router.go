@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func EnrollmentsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Enrollment history search, e.g. /enrollments?class=6B&academic_year=2024;
+	mux.HandleFunc("GET /enrollments", mw.RequirePermission("students:read", handlers.GetEnrollmentsHandler))
+
+	return mux
+}
@@ -3,27 +3,33 @@ package routers
 import (
 	"net/http"
 	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
 )
 
 func ExecsRouter() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/execs", handlers.ExecsHandler)
+	mux.HandleFunc("/execs", mw.RequirePermission("execs:admin", handlers.ExecsHandler))
 
-	mux.HandleFunc("GET /execs", handlers.GetExecsHandler)
-	mux.HandleFunc("POST /execs", handlers.AddExecsHandler)
-	mux.HandleFunc("PATCH /execs", handlers.PatchExecsHandler)
+	mux.HandleFunc("GET /execs", mw.RequirePermission("execs:read", handlers.GetExecsHandler))
+	mux.HandleFunc("POST /execs", mw.RequirePermission("execs:admin", handlers.AddExecsHandler))
+	mux.HandleFunc("PATCH /execs", mw.RequirePermission("execs:admin", handlers.PatchExecsHandler))
 
 	// By ID handlers for students route;
-	mux.HandleFunc("GET /execs/{id}", handlers.GetExecByIdHandler)
-	mux.HandleFunc("PATCH /execs/{id}", handlers.PatchExecByIdHandler)
-	mux.HandleFunc("DELETE /execs/{id}", handlers.DeleteExecByIdHandler)
+	mux.HandleFunc("GET /execs/{id}", mw.RequirePermission("execs:read", handlers.GetExecByIdHandler))
+	mux.HandleFunc("PATCH /execs/{id}", mw.RequirePermission("execs:admin", handlers.PatchExecByIdHandler))
+	mux.HandleFunc("DELETE /execs/{id}", mw.RequirePermission("execs:admin", handlers.DeleteExecByIdHandler))
+	mux.HandleFunc("POST /execs/{id}/unlock", mw.RequirePermission("execs:admin", handlers.UnlockExecHandler))
+	mux.HandleFunc("DELETE /execs/{id}/2fa", mw.RequirePermission("execs:admin", handlers.ResetTwoFactorHandler))
 
-	// Auth routes
+	// Auth routes; failed logins are throttled per username and client IP by the handlers themselves;
 	mux.HandleFunc("POST /execs/login", handlers.LoginHandler)
+	mux.HandleFunc("POST /execs/login/2fa", handlers.LoginTwoFactorHandler)
+	mux.HandleFunc("POST /execs/login/2fa/enroll", handlers.LoginTwoFactorEnrollHandler)
 	mux.HandleFunc("POST /execs/logout", handlers.LogoutHandler)
+	mux.HandleFunc("POST /execs/refresh", handlers.RefreshHandler)
 	mux.HandleFunc("POST /execs/forgot-password", handlers.ForgotPasswordHandler)
 	mux.HandleFunc("POST /execs/reset-password/reset/{resetCode}", handlers.ResetPasswordHandler)
-	mux.HandleFunc("POST /execs/{id}/update-password", handlers.UpdatePasswordHandler)
+	mux.HandleFunc("POST /execs/{id}/update-password", mw.RequirePermission("me:write", handlers.UpdatePasswordHandler))
 	return mux
 }
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func IncidentsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for incidents route;
+	mux.HandleFunc("GET /incidents", mw.RequirePermission("incidents:read", handlers.GetIncidentsHandler))
+	mux.HandleFunc("POST /incidents", mw.RequirePermission("incidents:write", handlers.AddIncidentHandler))
+
+	// By ID handlers for incidents route;
+	mux.HandleFunc("GET /incidents/{id}", mw.RequirePermission("incidents:read", handlers.GetIncidentHandler))
+	mux.HandleFunc("PATCH /incidents/{id}", mw.RequirePermission("incidents:write", handlers.PatchIncidentHandler))
+	mux.HandleFunc("DELETE /incidents/{id}", mw.RequirePermission("incidents:admin", handlers.DeleteIncidentHandler))
+
+	// Summaries;
+	mux.HandleFunc("GET /incidents/summary/students/{id}", mw.RequirePermission("incidents:read", handlers.GetStudentIncidentSummaryHandler))
+	mux.HandleFunc("GET /incidents/summary/classes/{class}", mw.RequirePermission("incidents:read", handlers.GetClassIncidentSummaryHandler))
+
+	return mux
+}
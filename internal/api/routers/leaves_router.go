@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func LeavesRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for leaves route;
+	mux.HandleFunc("GET /leaves", mw.RequirePermission("leaves:read", handlers.GetLeaveRequestsHandler))
+	mux.HandleFunc("POST /leaves", mw.RequirePermission("leaves:write", handlers.AddLeaveRequestHandler))
+
+	// By ID handlers for leaves route;
+	mux.HandleFunc("GET /leaves/{id}", mw.RequirePermission("leaves:read", handlers.GetLeaveRequestHandler))
+	mux.HandleFunc("GET /leaves/{id}/cover", mw.RequirePermission("leaves:read", handlers.GetLeaveCoverHandler))
+	mux.HandleFunc("POST /leaves/{id}/approve", mw.RequirePermission("leaves:approve", handlers.ApproveLeaveRequestHandler))
+	mux.HandleFunc("POST /leaves/{id}/reject", mw.RequirePermission("leaves:approve", handlers.RejectLeaveRequestHandler))
+
+	return mux
+}
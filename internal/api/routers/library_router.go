@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func LibraryRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Catalogue and copies;
+	mux.HandleFunc("GET /library/books", mw.RequirePermission("library:read", handlers.GetBooksHandler))
+	mux.HandleFunc("POST /library/books", mw.RequirePermission("library:write", handlers.AddBookHandler))
+	mux.HandleFunc("GET /library/books/{id}", mw.RequirePermission("library:read", handlers.GetBookHandler))
+	mux.HandleFunc("PATCH /library/books/{id}", mw.RequirePermission("library:write", handlers.PatchBookHandler))
+	mux.HandleFunc("DELETE /library/books/{id}", mw.RequirePermission("library:write", handlers.DeleteBookHandler))
+	mux.HandleFunc("POST /library/books/{id}/copies", mw.RequirePermission("library:write", handlers.AddBookCopyHandler))
+	mux.HandleFunc("PATCH /library/copies/{id}", mw.RequirePermission("library:write", handlers.PatchBookCopyHandler))
+
+	// Circulation desk;
+	mux.HandleFunc("GET /library/loans", mw.RequirePermission("library:borrow", handlers.GetLoansHandler))
+	mux.HandleFunc("POST /library/loans", mw.RequirePermission("library:circulate", handlers.CheckoutHandler))
+	mux.HandleFunc("POST /library/returns", mw.RequirePermission("library:circulate", handlers.ReturnHandler))
+	mux.HandleFunc("POST /library/loans/{id}/renew", mw.RequirePermission("library:circulate", handlers.RenewLoanHandler))
+
+	// Holds queue;
+	mux.HandleFunc("POST /library/books/{id}/holds", mw.RequirePermission("library:borrow", handlers.PlaceHoldHandler))
+	mux.HandleFunc("GET /library/holds", mw.RequirePermission("library:borrow", handlers.GetHoldsHandler))
+	mux.HandleFunc("DELETE /library/holds/{id}", mw.RequirePermission("library:borrow", handlers.CancelHoldHandler))
+
+	mux.HandleFunc("GET /library/reports/overdue", mw.RequirePermission("library:reports", handlers.GetOverdueReportHandler))
+
+	return mux
+}
@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+// MeRouter - Routes scoped to the logged-in user;
+func MeRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Announcement feed;
+	mux.HandleFunc("GET /me/announcements", mw.RequirePermission("me:read", handlers.GetMyAnnouncementsHandler))
+	mux.HandleFunc("POST /me/announcements/{id}/read", mw.RequirePermission("me:write", handlers.MarkAnnouncementReadHandler))
+
+	// Sessions;
+	mux.HandleFunc("POST /me/logout-everywhere", mw.RequirePermission("me:write", handlers.LogoutEverywhereHandler))
+
+	// Two-factor authentication;
+	mux.HandleFunc("GET /me/2fa", mw.RequirePermission("me:read", handlers.GetMyTwoFactorHandler))
+	mux.HandleFunc("POST /me/2fa/enroll", mw.RequirePermission("me:write", handlers.EnrollTwoFactorHandler))
+	mux.HandleFunc("POST /me/2fa/confirm", mw.RequirePermission("me:write", handlers.ConfirmTwoFactorHandler))
+	mux.HandleFunc("POST /me/2fa/recovery-codes", mw.RequirePermission("me:write", handlers.RegenerateRecoveryCodesHandler))
+	mux.HandleFunc("POST /me/2fa/disable", mw.RequirePermission("me:write", handlers.DisableTwoFactorHandler))
+
+	// Effective permissions of the caller's role;
+	mux.HandleFunc("GET /me/permissions", mw.RequirePermission("me:read", handlers.GetMyPermissionsHandler))
+
+	return mux
+}
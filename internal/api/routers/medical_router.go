@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func MedicalRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for medical route;
+	mux.HandleFunc("GET /medical/alerts", mw.RequirePermission("medical:alerts", handlers.GetClassMedicalAlertsHandler))
+	mux.HandleFunc("GET /medical/audit", mw.RequirePermission("medical:audit", handlers.GetMedicalAuditHandler))
+
+	return mux
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func PlatformRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /platform/login", handlers.PlatformLoginHandler)
+
+	// Schools of the trust;
+	mux.HandleFunc("GET /platform/tenants", mw.RequirePermission("platform:admin", handlers.GetTenantsHandler))
+	mux.HandleFunc("POST /platform/tenants", mw.RequirePermission("platform:admin", handlers.AddTenantHandler))
+	mux.HandleFunc("GET /platform/tenants/summary", mw.RequirePermission("platform:admin", handlers.GetTenantSummariesHandler))
+	mux.HandleFunc("PATCH /platform/tenants/{id}", mw.RequirePermission("platform:admin", handlers.PatchTenantHandler))
+
+	return mux
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func PromotionsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Year-end promotion workflow: preview, commit and undo;
+	mux.HandleFunc("GET /promotions", mw.RequirePermission("promotions:read", handlers.GetPromotionsHandler))
+	mux.HandleFunc("POST /promotions", mw.RequirePermission("promotions:write", handlers.AddPromotionHandler))
+	mux.HandleFunc("POST /promotions/preview", mw.RequirePermission("promotions:read", handlers.PreviewPromotionHandler))
+
+	// By ID handlers for promotions route;
+	mux.HandleFunc("GET /promotions/{id}", mw.RequirePermission("promotions:read", handlers.GetPromotionHandler))
+	mux.HandleFunc("POST /promotions/{id}/undo", mw.RequirePermission("promotions:write", handlers.UndoPromotionHandler))
+
+	return mux
+}
@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func RoomsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for rooms route;
+	mux.HandleFunc("GET /rooms", mw.RequirePermission("rooms:read", handlers.GetRoomsHandler))
+	mux.HandleFunc("POST /rooms", mw.RequirePermission("rooms:write", handlers.AddRoomHandler))
+	mux.HandleFunc("GET /rooms/availability", mw.RequirePermission("rooms:read", handlers.GetRoomAvailabilityHandler))
+
+	// By ID handlers for rooms route;
+	mux.HandleFunc("GET /rooms/{id}", mw.RequirePermission("rooms:read", handlers.GetRoomHandler))
+	mux.HandleFunc("PATCH /rooms/{id}", mw.RequirePermission("rooms:write", handlers.PatchRoomHandler))
+	mux.HandleFunc("DELETE /rooms/{id}", mw.RequirePermission("rooms:write", handlers.DeleteRoomHandler))
+	mux.HandleFunc("POST /rooms/{id}/bookings", mw.RequirePermission("rooms:book", handlers.AddRoomBookingHandler))
+
+	// Bookings across rooms;
+	mux.HandleFunc("GET /bookings", mw.RequirePermission("rooms:read", handlers.GetRoomBookingsHandler))
+	mux.HandleFunc("GET /bookings/{id}", mw.RequirePermission("rooms:read", handlers.GetRoomBookingHandler))
+	mux.HandleFunc("DELETE /bookings/{id}", mw.RequirePermission("rooms:book", handlers.CancelRoomBookingHandler))
+	mux.HandleFunc("POST /bookings/{id}/approve", mw.RequirePermission("rooms:approve", handlers.ApproveRoomBookingHandler))
+	mux.HandleFunc("POST /bookings/{id}/reject", mw.RequirePermission("rooms:approve", handlers.RejectRoomBookingHandler))
+
+	return mux
+}
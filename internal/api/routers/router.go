@@ -9,7 +9,41 @@ func MainRouter() *http.ServeMux {
 	eRouter := ExecsRouter()
 	tRouter := TeachersRouter()
 	sRouter := StudentsRouter()
+	iRouter := IncidentsRouter()
+	pRouter := PromotionsRouter()
+	enRouter := EnrollmentsRouter()
+	aRouter := AdmissionsRouter()
+	ttRouter := TimetableRouter()
+	lRouter := LeavesRouter()
+	subRouter := SubstitutionsRouter()
+	anRouter := AnnouncementsRouter()
+	meRouter := MeRouter()
+	thRouter := ThreadsRouter()
+	mdRouter := MedicalRouter()
+	dRouter := DocumentsRouter()
+	cRouter := ClubsRouter()
+	rmRouter := RoomsRouter()
+	libRouter := LibraryRouter()
+	trRouter := TransportRouter()
+	plRouter := PlatformRouter()
 
+	trRouter.Handle("/", plRouter)
+	libRouter.Handle("/", trRouter)
+	rmRouter.Handle("/", libRouter)
+	cRouter.Handle("/", rmRouter)
+	dRouter.Handle("/", cRouter)
+	mdRouter.Handle("/", dRouter)
+	thRouter.Handle("/", mdRouter)
+	meRouter.Handle("/", thRouter)
+	anRouter.Handle("/", meRouter)
+	subRouter.Handle("/", anRouter)
+	lRouter.Handle("/", subRouter)
+	ttRouter.Handle("/", lRouter)
+	aRouter.Handle("/", ttRouter)
+	enRouter.Handle("/", aRouter)
+	pRouter.Handle("/", enRouter)
+	iRouter.Handle("/", pRouter)
+	eRouter.Handle("/", iRouter)
 	sRouter.Handle("/", eRouter)
 	tRouter.Handle("/", sRouter)
 	return tRouter
@@ -3,21 +3,49 @@ package routers
 import (
 	"net/http"
 	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
 )
 
 func StudentsRouter() *http.ServeMux {
 	mux := http.NewServeMux()
 	// General handlers for students route;
-	mux.HandleFunc("GET /students/", handlers.GetStudentsHandler)
-	mux.HandleFunc("POST /students/", handlers.AddStudentsHandler)
-	mux.HandleFunc("PATCH /students/", handlers.PatchStudentsHandler)
-	mux.HandleFunc("DELETE /students/", handlers.DeleteStudentsHandler)
+	mux.HandleFunc("GET /students/", mw.RequirePermission("students:read", handlers.GetStudentsHandler))
+	mux.HandleFunc("POST /students/", mw.RequirePermission("students:write", handlers.AddStudentsHandler))
+	mux.HandleFunc("PATCH /students/", mw.RequirePermission("students:write", handlers.PatchStudentsHandler))
+	mux.HandleFunc("DELETE /students/", mw.RequirePermission("students:admin", handlers.DeleteStudentsHandler))
+
+	mux.HandleFunc("GET /students/id-cards", mw.RequirePermission("idcards:read", handlers.GetClassIdCardsHandler))
+	mux.HandleFunc("POST /students/assign-ids", mw.RequirePermission("idcards:write", handlers.AssignStudentIdsHandler))
 
 	// By ID handlers for students route;
-	mux.HandleFunc("GET /students/{id}", handlers.GetStudentHandler)
-	mux.HandleFunc("PUT /students/{id}", handlers.UpdateStudentHandler)
-	mux.HandleFunc("PATCH /students/{id}", handlers.PatchStudentHandler)
-	mux.HandleFunc("DELETE /students/{id}", handlers.DeleteStudentHandler)
+	mux.HandleFunc("GET /students/{id}", mw.RequirePermission("students:read", handlers.GetStudentHandler))
+	mux.HandleFunc("PUT /students/{id}", mw.RequirePermission("students:write", handlers.UpdateStudentHandler))
+	// Teachers hold students:annotate only, the repository limits them to the notes of the students of their classes;
+	mux.HandleFunc("PATCH /students/{id}", mw.RequirePermission("students:annotate", handlers.PatchStudentHandler))
+	mux.HandleFunc("DELETE /students/{id}", mw.RequirePermission("students:admin", handlers.DeleteStudentHandler))
+
+	// Sub routes for student;
+	mux.HandleFunc("GET /students/{id}/enrollments", mw.RequirePermission("students:read", handlers.GetStudentEnrollmentsHandler))
+	mux.HandleFunc("POST /students/{id}/withdraw", mw.RequirePermission("withdrawals:write", handlers.WithdrawStudentHandler))
+	mux.HandleFunc("GET /students/{id}/withdrawal", mw.RequirePermission("withdrawals:read", handlers.GetStudentWithdrawalHandler))
+	mux.HandleFunc("GET /students/{id}/transfer-certificate", mw.RequirePermission("withdrawals:read", handlers.GetTransferCertificateHandler))
+	mux.HandleFunc("GET /students/{id}/guardians", mw.RequirePermission("guardians:read", handlers.GetStudentGuardiansHandler))
+	mux.HandleFunc("POST /students/{id}/guardians", mw.RequirePermission("guardians:write", handlers.AddStudentGuardianHandler))
+	mux.HandleFunc("DELETE /students/{id}/guardians/{execId}", mw.RequirePermission("guardians:write", handlers.DeleteStudentGuardianHandler))
+	mux.HandleFunc("GET /students/{id}/medical", mw.RequirePermission("medical:read", handlers.GetMedicalProfileHandler))
+	mux.HandleFunc("PUT /students/{id}/medical", mw.RequirePermission("medical:write", handlers.SaveMedicalProfileHandler))
+	mux.HandleFunc("GET /students/{id}/medical/visits", mw.RequirePermission("medical:read", handlers.GetNurseVisitsHandler))
+	mux.HandleFunc("POST /students/{id}/medical/visits", mw.RequirePermission("medical:write", handlers.AddNurseVisitHandler))
+	mux.HandleFunc("GET /students/{id}/medical/alerts", mw.RequirePermission("medical:alerts", handlers.GetStudentMedicalAlertsHandler))
+	mux.HandleFunc("GET /students/{id}/documents", mw.RequirePermission("documents:read", handlers.GetStudentDocumentsHandler))
+	mux.HandleFunc("POST /students/{id}/documents", mw.RequirePermission("documents:write", handlers.AddStudentDocumentHandler))
+	mux.HandleFunc("GET /students/{id}/photo", mw.RequirePermission("photos:read", handlers.GetStudentPhotoHandler))
+	mux.HandleFunc("POST /students/{id}/photo", mw.RequirePermission("photos:write", handlers.UploadStudentPhotoHandler))
+	mux.HandleFunc("DELETE /students/{id}/photo", mw.RequirePermission("photos:write", handlers.DeleteStudentPhotoHandler))
+	mux.HandleFunc("GET /students/{id}/id-card", mw.RequirePermission("idcards:read", handlers.GetStudentIdCardHandler))
+	mux.HandleFunc("GET /students/{id}/activities", mw.RequirePermission("clubs:read", handlers.GetStudentActivitiesHandler))
+	mux.HandleFunc("GET /students/{id}/library-loans", mw.RequirePermission("library:read", handlers.GetStudentLibraryLoansHandler))
+	mux.HandleFunc("GET /students/{id}/transport", mw.RequirePermission("transport:riders", handlers.GetStudentTransportHandler))
 
 	return mux
 }
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func SubstitutionsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for substitutions route;
+	mux.HandleFunc("GET /substitutions", mw.RequirePermission("substitutions:read", handlers.GetSubstitutionsHandler))
+	mux.HandleFunc("POST /substitutions", mw.RequirePermission("substitutions:manage", handlers.AssignSubstituteHandler))
+	mux.HandleFunc("GET /substitutions/uncovered", mw.RequirePermission("substitutions:manage", handlers.GetUncoveredPeriodsHandler))
+
+	// By ID handlers for substitutions route;
+	mux.HandleFunc("DELETE /substitutions/{id}", mw.RequirePermission("substitutions:manage", handlers.DeleteSubstitutionHandler))
+
+	return mux
+}
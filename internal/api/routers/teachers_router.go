@@ -3,6 +3,7 @@ package routers
 import (
 	"net/http"
 	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
 )
 
 func TeachersRouter() *http.ServeMux {
@@ -12,20 +13,28 @@ func TeachersRouter() *http.ServeMux {
 	//mux.HandleFunc("GET /", handlers.RootHandler)
 
 	// General handlers for teachers route;
-	mux.HandleFunc("GET /teachers", handlers.GetTeachersHandler)
-	mux.HandleFunc("POST /teachers", handlers.AddTeachersHandler)
-	mux.HandleFunc("PATCH /teachers", handlers.PatchTeachersHandler)
-	mux.HandleFunc("DELETE /teachers", handlers.DeleteTeachersHandler)
+	mux.HandleFunc("GET /teachers", mw.RequirePermission("teachers:read", handlers.GetTeachersHandler))
+	mux.HandleFunc("POST /teachers", mw.RequirePermission("teachers:write", handlers.AddTeachersHandler))
+	mux.HandleFunc("PATCH /teachers", mw.RequirePermission("teachers:write", handlers.PatchTeachersHandler))
+	mux.HandleFunc("DELETE /teachers", mw.RequirePermission("teachers:admin", handlers.DeleteTeachersHandler))
 
 	// By ID handlers for teachers route;
-	mux.HandleFunc("GET /teachers/{id}", handlers.GetTeachersHandler)
-	mux.HandleFunc("PUT /teachers/{id}", handlers.UpdateTeachersHandler)
-	mux.HandleFunc("PATCH /teachers/{id}", handlers.PatchTeacherHandler)
-	mux.HandleFunc("DELETE /teachers/{id}", handlers.DeleteTeacherHandler)
+	mux.HandleFunc("GET /teachers/{id}", mw.RequirePermission("teachers:read", handlers.GetTeachersHandler))
+	mux.HandleFunc("PUT /teachers/{id}", mw.RequirePermission("teachers:write", handlers.UpdateTeachersHandler))
+	mux.HandleFunc("PATCH /teachers/{id}", mw.RequirePermission("teachers:write", handlers.PatchTeacherHandler))
+	mux.HandleFunc("DELETE /teachers/{id}", mw.RequirePermission("teachers:admin", handlers.DeleteTeacherHandler))
 
 	// Sub routes for teacher;
-	mux.HandleFunc("GET /teachers/{id}/students", handlers.GetStudentsByTeacherHandler)
-	mux.HandleFunc("GET /teachers/{id}/studentCount", handlers.GetStudentsCountByTeacherHandler)
+	mux.HandleFunc("GET /teachers/{id}/students", mw.RequirePermission("students:read", handlers.GetStudentsByTeacherHandler))
+	mux.HandleFunc("GET /teachers/{id}/studentCount", mw.RequirePermission("reports:read", handlers.GetStudentsCountByTeacherHandler))
+	mux.HandleFunc("GET /teachers/{id}/documents", mw.RequirePermission("documents:read", handlers.GetTeacherDocumentsHandler))
+	mux.HandleFunc("GET /teachers/{id}/library-loans", mw.RequirePermission("library:borrow", handlers.GetTeacherLibraryLoansHandler))
+	mux.HandleFunc("POST /teachers/{id}/documents", mw.RequirePermission("documents:write", handlers.AddTeacherDocumentHandler))
+	mux.HandleFunc("GET /teachers/{id}/photo", mw.RequirePermission("photos:read", handlers.GetTeacherPhotoHandler))
+	mux.HandleFunc("POST /teachers/{id}/photo", mw.RequirePermission("photos:write", handlers.UploadTeacherPhotoHandler))
+	mux.HandleFunc("DELETE /teachers/{id}/photo", mw.RequirePermission("photos:write", handlers.DeleteTeacherPhotoHandler))
+	mux.HandleFunc("GET /teachers/{id}/id-card", mw.RequirePermission("idcards:read", handlers.GetTeacherIdCardHandler))
+	mux.HandleFunc("POST /teachers/assign-ids", mw.RequirePermission("idcards:write", handlers.AssignTeacherIdsHandler))
 
 	return mux
 }
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func ThreadsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for threads route;
+	mux.HandleFunc("GET /threads", mw.RequirePermission("messages:read", handlers.GetThreadsHandler))
+	mux.HandleFunc("POST /threads", mw.RequirePermission("messages:write", handlers.AddThreadHandler))
+
+	// By ID handlers for threads route;
+	mux.HandleFunc("GET /threads/{id}", mw.RequirePermission("messages:read", handlers.GetThreadHandler))
+	mux.HandleFunc("PATCH /threads/{id}", mw.RequirePermission("messages:moderate", handlers.PatchThreadHandler))
+	mux.HandleFunc("POST /threads/{id}/messages", mw.RequirePermission("messages:write", handlers.AddMessageHandler))
+	mux.HandleFunc("GET /threads/{id}/attachments/{attachmentId}", mw.RequirePermission("messages:read", handlers.GetMessageAttachmentHandler))
+
+	// Moderation;
+	mux.HandleFunc("POST /threads/{id}/messages/{messageId}/hide", mw.RequirePermission("messages:moderate", handlers.HideMessageHandler))
+	mux.HandleFunc("POST /threads/{id}/messages/{messageId}/unhide", mw.RequirePermission("messages:moderate", handlers.UnhideMessageHandler))
+
+	return mux
+}
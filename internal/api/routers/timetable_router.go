@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func TimetableRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// General handlers for timetable route;
+	mux.HandleFunc("GET /timetable", mw.RequirePermission("timetable:read", handlers.GetTimetableHandler))
+	mux.HandleFunc("POST /timetable", mw.RequirePermission("timetable:write", handlers.AddTimetableSlotHandler))
+
+	// By ID handlers for timetable route;
+	mux.HandleFunc("DELETE /timetable/{id}", mw.RequirePermission("timetable:write", handlers.DeleteTimetableSlotHandler))
+
+	return mux
+}
@@ -0,0 +1,38 @@
+package routers
+
+import (
+	"net/http"
+	"schoolManagement/internal/api/handlers"
+	mw "schoolManagement/internal/api/middlewares"
+)
+
+func TransportRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Fleet and drivers;
+	mux.HandleFunc("GET /transport/vehicles", mw.RequirePermission("transport:read", handlers.GetVehiclesHandler))
+	mux.HandleFunc("POST /transport/vehicles", mw.RequirePermission("transport:manage", handlers.AddVehicleHandler))
+	mux.HandleFunc("PATCH /transport/vehicles/{id}", mw.RequirePermission("transport:manage", handlers.PatchVehicleHandler))
+	mux.HandleFunc("DELETE /transport/vehicles/{id}", mw.RequirePermission("transport:manage", handlers.DeleteVehicleHandler))
+	mux.HandleFunc("GET /transport/drivers", mw.RequirePermission("transport:manage", handlers.GetDriversHandler))
+	mux.HandleFunc("POST /transport/drivers", mw.RequirePermission("transport:manage", handlers.AddDriverHandler))
+	mux.HandleFunc("PATCH /transport/drivers/{id}", mw.RequirePermission("transport:manage", handlers.PatchDriverHandler))
+	mux.HandleFunc("DELETE /transport/drivers/{id}", mw.RequirePermission("transport:manage", handlers.DeleteDriverHandler))
+
+	// Routes with their stops;
+	mux.HandleFunc("GET /transport/routes", mw.RequirePermission("transport:read", handlers.GetTransportRoutesHandler))
+	mux.HandleFunc("POST /transport/routes", mw.RequirePermission("transport:manage", handlers.AddTransportRouteHandler))
+	mux.HandleFunc("GET /transport/routes/{id}", mw.RequirePermission("transport:read", handlers.GetTransportRouteHandler))
+	mux.HandleFunc("PATCH /transport/routes/{id}", mw.RequirePermission("transport:manage", handlers.PatchTransportRouteHandler))
+	mux.HandleFunc("DELETE /transport/routes/{id}", mw.RequirePermission("transport:manage", handlers.DeleteTransportRouteHandler))
+	mux.HandleFunc("GET /transport/routes/{id}/manifest", mw.RequirePermission("transport:read", handlers.GetRouteManifestHandler))
+	mux.HandleFunc("POST /transport/routes/{id}/boardings", mw.RequirePermission("transport:board", handlers.RecordBoardingHandler))
+
+	// Rider assignments;
+	mux.HandleFunc("GET /transport/assignments", mw.RequirePermission("transport:read", handlers.GetTransportAssignmentsHandler))
+	mux.HandleFunc("POST /transport/assignments", mw.RequirePermission("transport:manage", handlers.AddTransportAssignmentHandler))
+	mux.HandleFunc("PATCH /transport/assignments/{id}", mw.RequirePermission("transport:manage", handlers.PatchTransportAssignmentHandler))
+	mux.HandleFunc("DELETE /transport/assignments/{id}", mw.RequirePermission("transport:manage", handlers.DeleteTransportAssignmentHandler))
+
+	return mux
+}
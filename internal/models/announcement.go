@@ -0,0 +1,32 @@
+package models
+
+type Announcement struct {
+	Id            int      `json:"id,omitempty" db:"id,omitempty"`
+	Title         string   `json:"title,omitempty" db:"title,omitempty"`
+	Body          string   `json:"body,omitempty" db:"body,omitempty"`
+	AuthorId      int      `json:"author_id,omitempty" db:"author_id,omitempty"`
+	AuthorRole    string   `json:"author_role,omitempty" db:"author_role,omitempty"`
+	TargetAll     bool     `json:"target_all" db:"target_all"`
+	TargetRoles   []string `json:"target_roles,omitempty"`
+	TargetClasses []string `json:"target_classes,omitempty"`
+	TargetUserIds []int    `json:"target_user_ids,omitempty"`
+	Pinned        bool     `json:"pinned" db:"pinned"`
+	PublishAt     string   `json:"publish_at,omitempty" db:"publish_at,omitempty"`
+	ExpireAt      string   `json:"expire_at,omitempty" db:"expire_at,omitempty"`
+	CreatedAt     string   `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt     string   `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+	ReadAt        string   `json:"read_at,omitempty"`
+}
+
+// AnnouncementRead - Read receipt of an announcement;
+type AnnouncementRead struct {
+	AnnouncementId int    `json:"announcement_id"`
+	UserId         int    `json:"user_id"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Role           string `json:"role"`
+	ReadAt         string `json:"read_at"`
+}
+
+// AnnouncementRoles - Roles an announcement can be targeted at;
+var AnnouncementRoles = []string{"admin", "manager", "staff", "counsellor", "teacher", "guardian", "nurse", "librarian", "transport"}
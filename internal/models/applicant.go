@@ -0,0 +1,74 @@
+package models
+
+type Applicant struct {
+	Id               int    `json:"id,omitempty" db:"id,omitempty"`
+	FirstName        string `json:"first_name,omitempty" db:"first_name,omitempty"`
+	LastName         string `json:"last_name,omitempty" db:"last_name,omitempty"`
+	Email            string `json:"email,omitempty" db:"email,omitempty"`
+	DateOfBirth      string `json:"date_of_birth,omitempty" db:"date_of_birth,omitempty"`
+	ApplyingForClass string `json:"applying_for_class,omitempty" db:"applying_for_class,omitempty"`
+	AcademicYear     string `json:"academic_year,omitempty" db:"academic_year,omitempty"`
+	GuardianName     string `json:"guardian_name,omitempty" db:"guardian_name,omitempty"`
+	GuardianEmail    string `json:"guardian_email,omitempty" db:"guardian_email,omitempty"`
+	GuardianPhone    string `json:"guardian_phone,omitempty" db:"guardian_phone,omitempty"`
+	PreviousSchool   string `json:"previous_school,omitempty" db:"previous_school,omitempty"`
+	Status           string `json:"status,omitempty" db:"status,omitempty"`
+	InterviewAt      string `json:"interview_at,omitempty" db:"interview_at,omitempty"`
+	StudentId        int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	CreatedAt        string `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt        string `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
+
+// ApplicationRequest - Body of the public application submission route;
+type ApplicationRequest struct {
+	FirstName        string `json:"first_name"`
+	LastName         string `json:"last_name"`
+	Email            string `json:"email"`
+	DateOfBirth      string `json:"date_of_birth"`
+	ApplyingForClass string `json:"applying_for_class"`
+	AcademicYear     string `json:"academic_year"`
+	GuardianName     string `json:"guardian_name"`
+	GuardianEmail    string `json:"guardian_email"`
+	GuardianPhone    string `json:"guardian_phone"`
+	PreviousSchool   string `json:"previous_school"`
+}
+
+// ApplicantTransitionRequest - Moves an applicant to another state;
+// InterviewAt is required for interview_scheduled, Class optionally overrides the class the student is enrolled in;
+type ApplicantTransitionRequest struct {
+	Status      string `json:"status"`
+	Note        string `json:"note"`
+	InterviewAt string `json:"interview_at,omitempty"`
+	Class       string `json:"class,omitempty"`
+}
+
+type ApplicantTransition struct {
+	Id          int    `json:"id,omitempty" db:"id,omitempty"`
+	ApplicantId int    `json:"applicant_id,omitempty" db:"applicant_id,omitempty"`
+	FromStatus  string `json:"from_status,omitempty" db:"from_status,omitempty"`
+	ToStatus    string `json:"to_status,omitempty" db:"to_status,omitempty"`
+	ActorId     int    `json:"actor_id,omitempty" db:"actor_id,omitempty"`
+	ActorRole   string `json:"actor_role,omitempty" db:"actor_role,omitempty"`
+	Note        string `json:"note,omitempty" db:"note,omitempty"`
+	ChangedAt   string `json:"changed_at,omitempty" db:"changed_at,omitempty"`
+}
+
+// ApplicantTransitions - The admissions state machine; maps a state to the states it may move to;
+// "enrolled" is only reached through accepting an offer, which creates the student record;
+var ApplicantTransitions = map[string][]string{
+	"submitted":           {"under_review", "rejected", "withdrawn"},
+	"under_review":        {"interview_scheduled", "offered", "rejected", "withdrawn"},
+	"interview_scheduled": {"under_review", "offered", "rejected", "withdrawn"},
+	"offered":             {"accepted", "rejected", "withdrawn"},
+	"accepted":            {"enrolled", "withdrawn"},
+}
+
+// CanTransition - Checks whether an applicant can move from one state to another;
+func CanTransition(from, to string) bool {
+	for _, next := range ApplicantTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
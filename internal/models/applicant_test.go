@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{"submitted", "under_review", true},
+		{"submitted", "rejected", true},
+		{"submitted", "withdrawn", true},
+		{"submitted", "offered", false},
+		{"submitted", "enrolled", false},
+		{"under_review", "interview_scheduled", true},
+		{"under_review", "offered", true},
+		{"interview_scheduled", "under_review", true},
+		{"interview_scheduled", "offered", true},
+		{"offered", "accepted", true},
+		{"offered", "under_review", false},
+		{"accepted", "enrolled", true},
+		{"accepted", "rejected", false},
+		{"accepted", "withdrawn", true},
+		// Final states cannot be left;
+		{"enrolled", "withdrawn", false},
+		{"rejected", "under_review", false},
+		{"withdrawn", "submitted", false},
+		{"submitted", "submitted", false},
+		{"unknown", "under_review", false},
+		{"submitted", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestApplicantTransitionsReachEnrolled(t *testing.T) {
+	// Every state of the machine must still lead to an enrolled student;
+	for from := range ApplicantTransitions {
+		seen := map[string]bool{from: true}
+		queue := []string{from}
+		for len(queue) > 0 {
+			state := queue[0]
+			queue = queue[1:]
+			for _, next := range ApplicantTransitions[state] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		if !seen["enrolled"] {
+			t.Errorf("state %q cannot reach enrolled", from)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+// Club - An extracurricular club or activity run by one or more supervising teachers;
+type Club struct {
+	Id            int              `json:"id"`
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	Capacity      int              `json:"capacity"`
+	Active        bool             `json:"active"`
+	SupervisorIds []int            `json:"supervisor_ids"`
+	Schedule      []ClubMeeting    `json:"schedule"`
+	Supervisors   []ClubSupervisor `json:"supervisors,omitempty"`
+	MemberCount   int              `json:"member_count"`
+	WaitlistCount int              `json:"waitlist_count"`
+	CreatedAt     string           `json:"created_at"`
+}
+
+// ClubMeeting - A recurring weekly meeting; DayOfWeek runs from 1 (Monday) to 7 (Sunday), times are HH:MM:SS;
+type ClubMeeting struct {
+	DayOfWeek int    `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Location  string `json:"location"`
+}
+
+type ClubSupervisor struct {
+	TeacherId int    `json:"teacher_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Subject   string `json:"subject"`
+}
+
+// ClubMember - A student's membership; students over capacity are waitlisted and promoted in order of arrival;
+type ClubMember struct {
+	Id        int    `json:"id"`
+	ClubId    int    `json:"club_id"`
+	StudentId int    `json:"student_id"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Class     string `json:"class,omitempty"`
+	Status    string `json:"status"`
+	Position  int    `json:"waitlist_position,omitempty"`
+	JoinedAt  string `json:"joined_at"`
+	LeftAt    string `json:"left_at,omitempty"`
+}
+
+type ClubSession struct {
+	Id          int    `json:"id"`
+	ClubId      int    `json:"club_id"`
+	SessionDate string `json:"session_date"`
+	StartTime   string `json:"start_time"`
+	Notes       string `json:"notes"`
+	CreatedBy   int    `json:"created_by"`
+	CreatedAt   string `json:"created_at"`
+	Present     int    `json:"present"`
+	Absent      int    `json:"absent"`
+	Excused     int    `json:"excused"`
+}
+
+type ClubAttendance struct {
+	SessionId int    `json:"session_id"`
+	StudentId int    `json:"student_id"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Status    string `json:"status"`
+}
+
+// ClubParticipation - Attendance summary of one student in one club (participation reports, student activities);
+type ClubParticipation struct {
+	ClubId        int     `json:"club_id"`
+	ClubName      string  `json:"club_name"`
+	StudentId     int     `json:"student_id"`
+	FirstName     string  `json:"first_name,omitempty"`
+	LastName      string  `json:"last_name,omitempty"`
+	Class         string  `json:"class,omitempty"`
+	Status        string  `json:"status"`
+	JoinedAt      string  `json:"joined_at"`
+	Sessions      int     `json:"sessions"`
+	Present       int     `json:"present"`
+	Absent        int     `json:"absent"`
+	Excused       int     `json:"excused"`
+	AttendancePct float64 `json:"attendance_pct"`
+}
+
+var ClubMemberStatuses = []string{"active", "waitlisted", "left"}
+
+var ClubAttendanceStatuses = []string{"present", "absent", "excused"}
@@ -0,0 +1,40 @@
+package models
+
+type Document struct {
+	Id             int               `json:"id"`
+	OwnerType      string            `json:"owner_type"`
+	OwnerId        int               `json:"owner_id"`
+	Category       string            `json:"category"`
+	Title          string            `json:"title"`
+	CurrentVersion int               `json:"current_version"`
+	CreatedBy      int               `json:"created_by"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	Latest         *DocumentVersion  `json:"latest,omitempty"`
+	Versions       []DocumentVersion `json:"versions,omitempty"`
+}
+
+// DocumentVersion - One uploaded file of a document; a new upload adds a version and keeps the old ones;
+type DocumentVersion struct {
+	Id          int    `json:"id"`
+	DocumentId  int    `json:"document_id"`
+	Version     int    `json:"version"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	StorageKey  string `json:"-"`
+	UploadedBy  int    `json:"uploaded_by"`
+	UploadedAt  string `json:"uploaded_at"`
+}
+
+// DocumentLink - A time limited download URL for a document version;
+type DocumentLink struct {
+	Url       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// DocumentOwnerTypes - Records a document can be attached to;
+var DocumentOwnerTypes = []string{"student", "teacher"}
+
+var DocumentCategories = []string{"birth_certificate", "transfer_letter", "photo", "identity", "report", "medical", "contract", "other"}
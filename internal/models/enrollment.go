@@ -0,0 +1,14 @@
+package models
+
+type Enrollment struct {
+	Id           int    `json:"id,omitempty" db:"id,omitempty"`
+	StudentId    int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	Class        string `json:"class,omitempty" db:"class,omitempty"`
+	AcademicYear string `json:"academic_year,omitempty" db:"academic_year,omitempty"`
+	StartDate    string `json:"start_date,omitempty" db:"start_date,omitempty"`
+	EndDate      string `json:"end_date,omitempty" db:"end_date,omitempty"`
+	Status       string `json:"status,omitempty" db:"status,omitempty"`
+	PromotionId  int    `json:"promotion_id,omitempty" db:"promotion_id,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// StudentGuardian - Links a guardian user account (an exec with the guardian role) to a student;
+type StudentGuardian struct {
+	StudentId    int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	ExecId       int    `json:"exec_id,omitempty" db:"exec_id,omitempty"`
+	Relationship string `json:"relationship,omitempty" db:"relationship,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	Email        string `json:"email,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// IdCard - What gets printed on a student or staff ID card;
+type IdCard struct {
+	Kind        string `json:"kind"`
+	Id          int    `json:"id"`
+	Number      string `json:"number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Class       string `json:"class"`
+	Subject     string `json:"subject,omitempty"`
+	PhotoPrefix string `json:"-"`
+}
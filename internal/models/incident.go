@@ -0,0 +1,30 @@
+package models
+
+type Incident struct {
+	Id                 int    `json:"id,omitempty" db:"id,omitempty"`
+	IncidentDate       string `json:"incident_date,omitempty" db:"incident_date,omitempty"`
+	StudentIds         []int  `json:"student_ids,omitempty"`
+	ReportingTeacherId int    `json:"reporting_teacher_id,omitempty" db:"reporting_teacher_id,omitempty"`
+	ReportedBy         int    `json:"reported_by,omitempty" db:"reported_by,omitempty"`
+	Category           string `json:"category,omitempty" db:"category,omitempty"`
+	Severity           string `json:"severity,omitempty" db:"severity,omitempty"`
+	Description        string `json:"description,omitempty" db:"description,omitempty"`
+	ActionsTaken       string `json:"actions_taken,omitempty" db:"actions_taken,omitempty"`
+	FollowUpStatus     string `json:"follow_up_status,omitempty" db:"follow_up_status,omitempty"`
+	CreatedAt          string `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// IncidentSummary - Aggregated incident counts for a student or a class;
+type IncidentSummary struct {
+	StudentId    int            `json:"student_id,omitempty"`
+	Class        string         `json:"class,omitempty"`
+	Total        int            `json:"total"`
+	Open         int            `json:"open"`
+	ByCategory   map[string]int `json:"by_category"`
+	BySeverity   map[string]int `json:"by_severity"`
+	LastIncident string         `json:"last_incident,omitempty"`
+}
+
+// Allowed values for incident severity and follow-up status;
+var IncidentSeverities = []string{"low", "medium", "high", "critical"}
+var IncidentFollowUpStatuses = []string{"open", "in_progress", "resolved", "closed"}
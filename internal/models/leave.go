@@ -0,0 +1,49 @@
+package models
+
+type LeaveRequest struct {
+	Id           int    `json:"id,omitempty" db:"id,omitempty"`
+	TeacherId    int    `json:"teacher_id,omitempty" db:"teacher_id,omitempty"`
+	StartDate    string `json:"start_date,omitempty" db:"start_date,omitempty"`
+	EndDate      string `json:"end_date,omitempty" db:"end_date,omitempty"`
+	Reason       string `json:"reason,omitempty" db:"reason,omitempty"`
+	Status       string `json:"status,omitempty" db:"status,omitempty"`
+	RequestedBy  int    `json:"requested_by,omitempty" db:"requested_by,omitempty"`
+	DecidedBy    int    `json:"decided_by,omitempty" db:"decided_by,omitempty"`
+	DecidedAt    string `json:"decided_at,omitempty" db:"decided_at,omitempty"`
+	DecisionNote string `json:"decision_note,omitempty" db:"decision_note,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// LeaveDecisionRequest - Body of the approve/reject routes;
+type LeaveDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+type Substitution struct {
+	Id                  int    `json:"id,omitempty" db:"id,omitempty"`
+	LeaveId             int    `json:"leave_id,omitempty" db:"leave_id,omitempty"`
+	SlotId              int    `json:"slot_id,omitempty" db:"slot_id,omitempty"`
+	Date                string `json:"date,omitempty" db:"date,omitempty"`
+	SubstituteTeacherId int    `json:"substitute_teacher_id,omitempty" db:"substitute_teacher_id,omitempty"`
+	AssignedBy          int    `json:"assigned_by,omitempty" db:"assigned_by,omitempty"`
+	CreatedAt           string `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// SubstituteSuggestion - A teacher who is free in a period; Qualified means they teach the period's subject;
+type SubstituteSuggestion struct {
+	TeacherId int    `json:"teacher_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Subject   string `json:"subject"`
+	Qualified bool   `json:"qualified"`
+}
+
+// CoverPeriod - A timetable slot on a given date whose teacher is on leave;
+// Substitution is set once a substitute has been assigned, otherwise Suggestions lists free teachers;
+type CoverPeriod struct {
+	Date         string                 `json:"date"`
+	LeaveId      int                    `json:"leave_id"`
+	Slot         TimetableSlot          `json:"slot"`
+	Substitution *Substitution          `json:"substitution,omitempty"`
+	Suggestions  []SubstituteSuggestion `json:"suggestions,omitempty"`
+}
@@ -0,0 +1,91 @@
+package models
+
+// Book - A catalogue entry; Isbn is stored as ISBN-13;
+type Book struct {
+	Id              int        `json:"id"`
+	Isbn            string     `json:"isbn"`
+	Title           string     `json:"title"`
+	Author          string     `json:"author"`
+	Publisher       string     `json:"publisher"`
+	PublishedYear   int        `json:"published_year,omitempty"`
+	Subject         string     `json:"subject"`
+	CreatedAt       string     `json:"created_at"`
+	TotalCopies     int        `json:"total_copies"`
+	AvailableCopies int        `json:"available_copies"`
+	HoldsWaiting    int        `json:"holds_waiting"`
+	Copies          []BookCopy `json:"copies,omitempty"`
+}
+
+type BookCopy struct {
+	Id         int    `json:"id"`
+	BookId     int    `json:"book_id"`
+	Barcode    string `json:"barcode"`
+	Location   string `json:"location"`
+	Status     string `json:"status"`
+	AcquiredAt string `json:"acquired_at"`
+}
+
+// LibraryLoan - A copy lent to a student or teacher; the fine of open loans is what would be due if returned today;
+type LibraryLoan struct {
+	Id           int    `json:"id"`
+	CopyId       int    `json:"copy_id"`
+	Barcode      string `json:"barcode"`
+	BookId       int    `json:"book_id"`
+	Title        string `json:"title"`
+	Isbn         string `json:"isbn"`
+	BorrowerType string `json:"borrower_type"`
+	BorrowerId   int    `json:"borrower_id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Class        string `json:"class"`
+	CheckedOutAt string `json:"checked_out_at"`
+	CheckedOutBy int    `json:"checked_out_by"`
+	DueDate      string `json:"due_date"`
+	Renewals     int    `json:"renewals"`
+	ReturnedAt   string `json:"returned_at,omitempty"`
+	DaysOverdue  int    `json:"days_overdue"`
+	FineCents    int    `json:"fine_cents"`
+}
+
+// BookHold - A borrower queued for a book; ready holds have a copy set aside until ExpiresAt;
+type BookHold struct {
+	Id           int    `json:"id"`
+	BookId       int    `json:"book_id"`
+	Title        string `json:"title"`
+	BorrowerType string `json:"borrower_type"`
+	BorrowerId   int    `json:"borrower_id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Status       string `json:"status"`
+	CopyId       int    `json:"copy_id,omitempty"`
+	Position     int    `json:"position,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	ReadyAt      string `json:"ready_at,omitempty"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+}
+
+// Borrower - Identifies a student or teacher in checkout and hold requests;
+type Borrower struct {
+	BorrowerType string `json:"borrower_type"`
+	BorrowerId   int    `json:"borrower_id"`
+}
+
+// CheckoutRequest - Body of the checkout route; the copy is identified by its barcode;
+type CheckoutRequest struct {
+	Barcode string `json:"barcode"`
+	Borrower
+}
+
+// OverdueClassReport - Overdue loans of the students of one class (teachers are grouped under "staff");
+type OverdueClassReport struct {
+	Class     string        `json:"class"`
+	Count     int           `json:"count"`
+	FineCents int           `json:"fine_cents"`
+	Loans     []LibraryLoan `json:"loans"`
+}
+
+var BorrowerTypes = []string{"student", "teacher"}
+
+var BookCopyStatuses = []string{"available", "on_loan", "on_hold", "lost", "withdrawn"}
+
+var BookHoldStatuses = []string{"waiting", "ready", "fulfilled", "cancelled", "expired"}
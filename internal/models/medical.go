@@ -0,0 +1,82 @@
+package models
+
+// MedicalProfile - Confidential health data of a student; everything except the ids and timestamps is encrypted at rest;
+type MedicalProfile struct {
+	StudentId    int                `json:"student_id,omitempty"`
+	BloodGroup   string             `json:"blood_group,omitempty"`
+	Allergies    []Allergy          `json:"allergies"`
+	Conditions   []MedicalCondition `json:"conditions"`
+	Medications  []Medication       `json:"medications"`
+	Doctor       DoctorContact      `json:"doctor"`
+	Vaccinations []Vaccination      `json:"vaccinations"`
+	UpdatedBy    int                `json:"updated_by,omitempty"`
+	UpdatedAt    string             `json:"updated_at,omitempty"`
+}
+
+type Allergy struct {
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	Reaction string `json:"reaction,omitempty"`
+}
+
+// MedicalCondition - A diagnosed condition; Alert marks conditions teachers need to know about (e.g. epilepsy);
+type MedicalCondition struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes,omitempty"`
+	Alert bool   `json:"alert"`
+}
+
+type Medication struct {
+	Name     string `json:"name"`
+	Dosage   string `json:"dosage,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type DoctorContact struct {
+	Name  string `json:"name,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type Vaccination struct {
+	Vaccine string `json:"vaccine"`
+	Dose    string `json:"dose,omitempty"`
+	Date    string `json:"date"`
+}
+
+// NurseVisit - An entry of the nurse-visit log; complaint, treatment and notes are encrypted at rest;
+type NurseVisit struct {
+	Id         int    `json:"id,omitempty" db:"id,omitempty"`
+	StudentId  int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	VisitedAt  string `json:"visited_at,omitempty" db:"visited_at,omitempty"`
+	Complaint  string `json:"complaint,omitempty" db:"complaint,omitempty"`
+	Treatment  string `json:"treatment,omitempty" db:"treatment,omitempty"`
+	Outcome    string `json:"outcome,omitempty" db:"outcome,omitempty"`
+	Notes      string `json:"notes,omitempty" db:"notes,omitempty"`
+	RecordedBy int    `json:"recorded_by,omitempty" db:"recorded_by,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// MedicalAlert - The minimal view shown to teachers: only what they must act on;
+type MedicalAlert struct {
+	StudentId int    `json:"student_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+}
+
+// MedicalAccess - Audit entry written for every read or change of medical data;
+type MedicalAccess struct {
+	Id         int    `json:"id,omitempty" db:"id,omitempty"`
+	UserId     int    `json:"user_id" db:"user_id"`
+	Role       string `json:"role" db:"role"`
+	StudentId  int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	Class      string `json:"class,omitempty" db:"class,omitempty"`
+	Action     string `json:"action" db:"action"`
+	IpAddress  string `json:"ip_address,omitempty" db:"ip_address,omitempty"`
+	AccessedAt string `json:"accessed_at,omitempty" db:"accessed_at,omitempty"`
+}
+
+var AllergySeverities = []string{"mild", "moderate", "severe"}
+var NurseVisitOutcomes = []string{"returned_to_class", "rested", "sent_home", "referred", "emergency"}
@@ -0,0 +1,55 @@
+package models
+
+// MessageThread - A conversation between a staff member and a guardian about a student;
+type MessageThread struct {
+	Id            int    `json:"id,omitempty" db:"id,omitempty"`
+	StudentId     int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	Subject       string `json:"subject,omitempty" db:"subject,omitempty"`
+	StaffId       int    `json:"staff_id,omitempty" db:"staff_id,omitempty"`
+	GuardianId    int    `json:"guardian_id,omitempty" db:"guardian_id,omitempty"`
+	CreatedBy     int    `json:"created_by,omitempty" db:"created_by,omitempty"`
+	Locked        bool   `json:"locked" db:"locked"`
+	CreatedAt     string `json:"created_at,omitempty" db:"created_at,omitempty"`
+	LastMessageAt string `json:"last_message_at,omitempty" db:"last_message_at,omitempty"`
+	Unread        int    `json:"unread"`
+}
+
+type Message struct {
+	Id          int                 `json:"id,omitempty" db:"id,omitempty"`
+	ThreadId    int                 `json:"thread_id,omitempty" db:"thread_id,omitempty"`
+	SenderId    int                 `json:"sender_id,omitempty" db:"sender_id,omitempty"`
+	SenderRole  string              `json:"sender_role,omitempty" db:"sender_role,omitempty"`
+	Body        string              `json:"body,omitempty" db:"body,omitempty"`
+	Hidden      bool                `json:"hidden,omitempty" db:"hidden,omitempty"`
+	Attachments []MessageAttachment `json:"attachments,omitempty"`
+	CreatedAt   string              `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// MessageAttachment - A file sent with a message; Content (base64) is only used when uploading;
+type MessageAttachment struct {
+	Id          int    `json:"id,omitempty" db:"id,omitempty"`
+	MessageId   int    `json:"message_id,omitempty" db:"message_id,omitempty"`
+	FileName    string `json:"file_name,omitempty" db:"file_name,omitempty"`
+	ContentType string `json:"content_type,omitempty" db:"content_type,omitempty"`
+	Size        int    `json:"size,omitempty" db:"size,omitempty"`
+	Content     string `json:"content,omitempty"`
+	Data        []byte `json:"-" db:"data"`
+}
+
+// NewThreadRequest - Starts a thread; staff pass the guardian_id, guardians the staff_id they are writing to;
+type NewThreadRequest struct {
+	StudentId   int                 `json:"student_id"`
+	Subject     string              `json:"subject"`
+	StaffId     int                 `json:"staff_id"`
+	GuardianId  int                 `json:"guardian_id"`
+	Body        string              `json:"body"`
+	Attachments []MessageAttachment `json:"attachments"`
+	Notify      bool                `json:"notify"`
+}
+
+// NewMessageRequest - Replies in a thread; Notify emails the other participant;
+type NewMessageRequest struct {
+	Body        string              `json:"body"`
+	Attachments []MessageAttachment `json:"attachments"`
+	Notify      bool                `json:"notify"`
+}
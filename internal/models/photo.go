@@ -0,0 +1,22 @@
+package models
+
+// ProfilePhoto - The current photo of a student or teacher; the files live in storage below StoragePrefix;
+type ProfilePhoto struct {
+	OwnerType     string   `json:"owner_type"`
+	OwnerId       int      `json:"owner_id"`
+	Version       int      `json:"version"`
+	Width         int      `json:"width"`
+	Height        int      `json:"height"`
+	StoragePrefix string   `json:"-"`
+	Sizes         []string `json:"sizes,omitempty"`
+	UpdatedBy     int      `json:"updated_by"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+// PhotoSizes - Longest side in pixels of every variant generated on upload;
+var PhotoSizes = map[string]int{
+	"small":    64,
+	"medium":   160,
+	"large":    400,
+	"original": 1024,
+}
@@ -0,0 +1,35 @@
+package models
+
+// PromotionRequest - Body of the preview and commit promotion routes;
+// Progression maps the current class to the next one (e.g. "6A": "7A"), when empty the configured map is used;
+type PromotionRequest struct {
+	AcademicYear string            `json:"academic_year,omitempty"`
+	Progression  map[string]string `json:"progression,omitempty"`
+	ExcludeIds   []int             `json:"exclude_ids,omitempty"`
+}
+
+type PromotionMove struct {
+	StudentId int    `json:"student_id" db:"student_id"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	FromClass string `json:"from_class" db:"from_class"`
+	ToClass   string `json:"to_class,omitempty" db:"to_class"`
+}
+
+// PromotionPreview - Proposed moves before anything is written to the DB;
+type PromotionPreview struct {
+	Moves    []PromotionMove `json:"moves"`
+	HeldBack []PromotionMove `json:"held_back"`
+	Unmapped []PromotionMove `json:"unmapped"`
+}
+
+type Promotion struct {
+	Id           int             `json:"id,omitempty" db:"id,omitempty"`
+	AcademicYear string          `json:"academic_year,omitempty" db:"academic_year,omitempty"`
+	PromotedBy   int             `json:"promoted_by,omitempty" db:"promoted_by,omitempty"`
+	Status       string          `json:"status,omitempty" db:"status,omitempty"`
+	UndoUntil    string          `json:"undo_until,omitempty" db:"undo_until,omitempty"`
+	CreatedAt    string          `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UndoneAt     string          `json:"undone_at,omitempty" db:"undone_at,omitempty"`
+	Moves        []PromotionMove `json:"moves,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+// Room - A bookable room or facility; restricted rooms need an approved booking;
+type Room struct {
+	Id         int             `json:"id"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Building   string          `json:"building"`
+	Capacity   int             `json:"capacity"`
+	Restricted bool            `json:"restricted"`
+	Active     bool            `json:"active"`
+	Equipment  []RoomEquipment `json:"equipment"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+type RoomEquipment struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// RoomBooking - A room booked for a time range on one day; StartAt and EndAt are YYYY-MM-DD HH:MM:SS;
+type RoomBooking struct {
+	Id           int    `json:"id"`
+	RoomId       int    `json:"room_id"`
+	RoomName     string `json:"room_name,omitempty"`
+	Title        string `json:"title"`
+	StartAt      string `json:"start_at"`
+	EndAt        string `json:"end_at"`
+	Status       string `json:"status"`
+	SeriesId     int    `json:"series_id,omitempty"`
+	BookedBy     int    `json:"booked_by"`
+	DecidedBy    int    `json:"decided_by,omitempty"`
+	DecidedAt    string `json:"decided_at,omitempty"`
+	DecisionNote string `json:"decision_note,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// RoomBookingRequest - Body of the booking route; with a recurrence the same time range is booked on every occurrence;
+type RoomBookingRequest struct {
+	Title      string             `json:"title"`
+	StartAt    string             `json:"start_at"`
+	EndAt      string             `json:"end_at"`
+	Recurrence *BookingRecurrence `json:"recurrence,omitempty"`
+}
+
+// BookingRecurrence - Repeats a booking daily or weekly up to and including Until (YYYY-MM-DD);
+type BookingRecurrence struct {
+	Frequency string `json:"frequency"`
+	Until     string `json:"until"`
+}
+
+// RoomConflict - An existing booking or timetable period overlapping a requested time range;
+type RoomConflict struct {
+	Source  string `json:"source"`
+	Id      int    `json:"id"`
+	Title   string `json:"title"`
+	StartAt string `json:"start_at"`
+	EndAt   string `json:"end_at"`
+}
+
+// RoomDecisionRequest - Body of the approve/reject routes;
+type RoomDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+var RoomTypes = []string{"classroom", "lab", "auditorium", "sports_ground", "other"}
+
+var RoomBookingStatuses = []string{"pending", "approved", "rejected", "cancelled"}
@@ -0,0 +1,15 @@
+package models
+
+// Session - A login of an exec, identified by its current refresh token (only the hash is stored);
+// All sessions descending from one login share a FamilyId, which is revoked as a whole when a used token reappears;
+type Session struct {
+	Id        int    `json:"id"`
+	FamilyId  string `json:"family_id"`
+	ExecId    int    `json:"exec_id"`
+	ExpiresAt string `json:"expires_at"`
+	UsedAt    string `json:"used_at,omitempty"`
+	RevokedAt string `json:"revoked_at,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IpAddress string `json:"ip_address,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
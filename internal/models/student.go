@@ -6,4 +6,10 @@ type Student struct {
 	LastName  string `json:"last_name,omitempty" db:"last_name,omitempty"`
 	Email     string `json:"email,omitempty" db:"email,omitempty"`
 	Class     string `json:"class,omitempty" db:"class,omitempty"`
+	Inactive  bool   `json:"inactive_status,omitempty" db:"inactive_status,omitempty"`
+	HasPhoto  bool   `json:"has_photo"`
+	// RollNumber - Printable id generated on creation (STUDENT_ID_FORMAT), never taken from the request;
+	RollNumber string `json:"roll_number,omitempty"`
+	// Notes - Free-text notes, kept out of the insert and changed with PATCH;
+	Notes string `json:"notes,omitempty"`
 }
@@ -7,4 +7,7 @@ type Teacher struct {
 	Class     string `json:"class,omitempty" db:"class"`
 	Subject   string `json:"subject,omitempty" db:"subject"`
 	Email     string `json:"email,omitempty" db:"email"`
+	HasPhoto  bool   `json:"has_photo"`
+	// EmployeeId - Printable id generated on creation (STAFF_ID_FORMAT), never taken from the request;
+	EmployeeId string `json:"employee_id,omitempty"`
 }
@@ -0,0 +1,33 @@
+package models
+
+// Tenant - A school of the trust; each school keeps its data in its own database (DbName);
+// Host is the host name the school's users reach the API on, if it has its own;
+type Tenant struct {
+	Id        int    `json:"id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	DbName    string `json:"db_name"`
+	Host      string `json:"host,omitempty"`
+	Active    bool   `json:"active"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SuperAdmin - A trust-level account with read access to every school;
+type SuperAdmin struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	Inactive bool   `json:"inactive_status,omitempty"`
+}
+
+// TenantSummary - Head counts of one school for the cross-school overview; Error is set when its database could not be read;
+type TenantSummary struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Students int    `json:"students"`
+	Teachers int    `json:"teachers"`
+	Execs    int    `json:"execs"`
+	Error    string `json:"error,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// TimetableSlot - A recurring weekly period taught by a teacher;
+// DayOfWeek runs from 1 (Monday) to 7 (Sunday), times are HH:MM:SS;
+type TimetableSlot struct {
+	Id        int    `json:"id,omitempty" db:"id,omitempty"`
+	TeacherId int    `json:"teacher_id,omitempty" db:"teacher_id,omitempty"`
+	Class     string `json:"class,omitempty" db:"class,omitempty"`
+	Subject   string `json:"subject,omitempty" db:"subject,omitempty"`
+	DayOfWeek int    `json:"day_of_week,omitempty" db:"day_of_week,omitempty"`
+	Period    int    `json:"period,omitempty" db:"period,omitempty"`
+	StartTime string `json:"start_time,omitempty" db:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty" db:"end_time,omitempty"`
+	RoomId    int    `json:"room_id,omitempty" db:"room_id,omitempty"`
+}
@@ -0,0 +1,114 @@
+package models
+
+type Vehicle struct {
+	Id           int    `json:"id"`
+	Registration string `json:"registration"`
+	Model        string `json:"model"`
+	Capacity     int    `json:"capacity"`
+	Active       bool   `json:"active"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type Driver struct {
+	Id            int    `json:"id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Phone         string `json:"phone"`
+	LicenseNumber string `json:"license_number,omitempty"`
+	LicenseExpiry string `json:"license_expiry,omitempty"`
+	Active        bool   `json:"active"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+// TransportRoute - A bus route with its vehicle, driver and stops in running order;
+type TransportRoute struct {
+	Id        int             `json:"id"`
+	Name      string          `json:"name"`
+	VehicleId int             `json:"vehicle_id,omitempty"`
+	DriverId  int             `json:"driver_id,omitempty"`
+	Active    bool            `json:"active"`
+	Capacity  int             `json:"capacity"`
+	Riders    int             `json:"riders"`
+	Vehicle   *Vehicle        `json:"vehicle,omitempty"`
+	Driver    *Driver         `json:"driver,omitempty"`
+	Stops     []TransportStop `json:"stops"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// TransportStop - A stop of a route; PickupTime and DropoffTime are HH:MM:SS;
+type TransportStop struct {
+	Id          int    `json:"id"`
+	RouteId     int    `json:"route_id"`
+	Sequence    int    `json:"sequence"`
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	PickupTime  string `json:"pickup_time"`
+	DropoffTime string `json:"dropoff_time,omitempty"`
+}
+
+// TransportAssignment - A student riding a route from a stop on the given ISO weekdays (1 = Monday);
+type TransportAssignment struct {
+	Id          int    `json:"id"`
+	StudentId   int    `json:"student_id"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Class       string `json:"class"`
+	RouteId     int    `json:"route_id"`
+	RouteName   string `json:"route_name"`
+	StopId      int    `json:"stop_id"`
+	StopName    string `json:"stop_name"`
+	PickupTime  string `json:"pickup_time"`
+	DropoffTime string `json:"dropoff_time,omitempty"`
+	Weekdays    []int  `json:"weekdays"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// RouteManifest - Riders of a route on one day, grouped by stop in running order;
+type RouteManifest struct {
+	RouteId      int            `json:"route_id"`
+	RouteName    string         `json:"route_name"`
+	Date         string         `json:"date"`
+	Vehicle      *Vehicle       `json:"vehicle,omitempty"`
+	Driver       *Driver        `json:"driver,omitempty"`
+	Capacity     int            `json:"capacity"`
+	Count        int            `json:"count"`
+	OverCapacity bool           `json:"over_capacity"`
+	Stops        []ManifestStop `json:"stops"`
+}
+
+type ManifestStop struct {
+	TransportStop
+	Riders []ManifestRider `json:"riders"`
+}
+
+// ManifestRider - A student expected on the run, with the boarding events recorded that day;
+type ManifestRider struct {
+	StudentId  int    `json:"student_id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Class      string `json:"class"`
+	BoardedAt  string `json:"boarded_at,omitempty"`
+	AlightedAt string `json:"alighted_at,omitempty"`
+}
+
+// BoardingEvent - A student getting on or off the bus during a run;
+type BoardingEvent struct {
+	Id         int    `json:"id"`
+	RouteId    int    `json:"route_id"`
+	StudentId  int    `json:"student_id"`
+	StopId     int    `json:"stop_id,omitempty"`
+	Event      string `json:"event"`
+	RunDate    string `json:"run_date"`
+	RecordedAt string `json:"recorded_at"`
+	RecordedBy int    `json:"recorded_by"`
+}
+
+// StudentTransport - A student's current and upcoming assignments with the routes they ride, as shown to guardians;
+type StudentTransport struct {
+	Assignment TransportAssignment `json:"assignment"`
+	Route      TransportRoute      `json:"route"`
+}
+
+var BoardingEvents = []string{"boarded", "alighted"}
@@ -0,0 +1,25 @@
+package models
+
+// TwoFactor - Two-factor authentication state of an exec; Pending means enrollment started but no code was confirmed yet;
+type TwoFactor struct {
+	Enabled           bool   `json:"enabled"`
+	Pending           bool   `json:"pending"`
+	Required          bool   `json:"required"`
+	RecoveryCodesLeft int    `json:"recovery_codes_left"`
+	EnabledAt         string `json:"enabled_at,omitempty"`
+}
+
+// TwoFactorEnrollment - Secret and recovery codes of a new enrollment; they are shown only this once;
+type TwoFactorEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	QRCodePNG     string   `json:"qr_code_png"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorRequest - A TOTP code or a recovery code, with the login challenge during the second login step;
+type TwoFactorRequest struct {
+	Challenge    string `json:"challenge,omitempty"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
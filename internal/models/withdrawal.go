@@ -0,0 +1,29 @@
+package models
+
+// Withdrawal - Records why and when a student left the school;
+// Type is either "withdrawal" or "transfer" (DestinationSchool is required for transfers);
+type Withdrawal struct {
+	Id                int    `json:"id,omitempty" db:"id,omitempty"`
+	StudentId         int    `json:"student_id,omitempty" db:"student_id,omitempty"`
+	Type              string `json:"type,omitempty" db:"type,omitempty"`
+	Reason            string `json:"reason,omitempty" db:"reason,omitempty"`
+	LeavingDate       string `json:"leaving_date,omitempty" db:"leaving_date,omitempty"`
+	DestinationSchool string `json:"destination_school,omitempty" db:"destination_school,omitempty"`
+	LastClass         string `json:"last_class,omitempty" db:"last_class,omitempty"`
+	WorkingDays       int    `json:"working_days,omitempty" db:"working_days,omitempty"`
+	DaysPresent       int    `json:"days_present,omitempty" db:"days_present,omitempty"`
+	CertificateNumber string `json:"certificate_number,omitempty" db:"certificate_number,omitempty"`
+	RecordedBy        int    `json:"recorded_by,omitempty" db:"recorded_by,omitempty"`
+	CreatedAt         string `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+// TransferCertificate - Data printed on the transfer certificate of a student who left;
+type TransferCertificate struct {
+	SchoolName           string     `json:"school_name"`
+	CertificateNumber    string     `json:"certificate_number"`
+	IssuedOn             string     `json:"issued_on"`
+	AdmissionDate        string     `json:"admission_date,omitempty"`
+	AttendancePercentage float64    `json:"attendance_percentage"`
+	Student              Student    `json:"student"`
+	Withdrawal           Withdrawal `json:"withdrawal"`
+}
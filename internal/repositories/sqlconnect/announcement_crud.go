@@ -0,0 +1,532 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const announcementColumns = "a.id, a.title, a.body, a.author_id, a.author_role, a.target_all, a.pinned, a.publish_at, a.expire_at, a.created_at, a.updated_at"
+
+// scanAnnouncement - Scans a single announcement row selected with announcementColumns (plus any extra destinations);
+func scanAnnouncement(scanner interface{ Scan(...interface{}) error }, announcement *models.Announcement, extra ...interface{}) error {
+	var expireAt, updatedAt sql.NullString
+	dest := []interface{}{&announcement.Id, &announcement.Title, &announcement.Body, &announcement.AuthorId, &announcement.AuthorRole, &announcement.TargetAll, &announcement.Pinned, &announcement.PublishAt, &expireAt, &announcement.CreatedAt, &updatedAt}
+	err := scanner.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+	announcement.ExpireAt = expireAt.String
+	announcement.UpdatedAt = updatedAt.String
+	return nil
+}
+
+// nullableString - Maps an empty string to NULL;
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// ValidateAnnouncement - Checks the content, the audience and the schedule of an announcement;
+func ValidateAnnouncement(announcement models.Announcement) error {
+	if strings.TrimSpace(announcement.Title) == "" || strings.TrimSpace(announcement.Body) == "" {
+		return utils.HandleError(errors.New("missing fields"), "Err: Title and body are required!")
+	}
+
+	if !announcement.TargetAll && len(announcement.TargetRoles) == 0 && len(announcement.TargetClasses) == 0 && len(announcement.TargetUserIds) == 0 {
+		return utils.HandleError(errors.New("no audience"), "Err: Target the whole school or at least one role, class or user!")
+	}
+
+	for _, role := range announcement.TargetRoles {
+		if !isAllowedValue(role, models.AnnouncementRoles) {
+			return utils.HandleError(errors.New("invalid role"), "Err: Invalid target role!")
+		}
+	}
+
+	publishAt, err := time.Parse(time.DateTime, announcement.PublishAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Publish time must be in YYYY-MM-DD HH:MM:SS format!")
+	}
+
+	if announcement.ExpireAt != "" {
+		expireAt, err := time.Parse(time.DateTime, announcement.ExpireAt)
+		if err != nil || !expireAt.After(publishAt) {
+			return utils.HandleError(errors.New("invalid expiry"), "Err: Expiry time must be in YYYY-MM-DD HH:MM:SS format and after the publish time!")
+		}
+	}
+	return nil
+}
+
+// getAnnouncementTargets - Loads the roles, classes and users an announcement is targeted at;
+func getAnnouncementTargets(db dbExecutor, announcement *models.Announcement) error {
+	rows, err := db.Query("SELECT target_type, target_value FROM announcement_targets WHERE announcement_id = ? ORDER BY target_type, target_value", announcement.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetType, targetValue string
+		err = rows.Scan(&targetType, &targetValue)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		switch targetType {
+		case "role":
+			announcement.TargetRoles = append(announcement.TargetRoles, targetValue)
+		case "class":
+			announcement.TargetClasses = append(announcement.TargetClasses, targetValue)
+		case "user":
+			userId, _ := strconv.Atoi(targetValue)
+			announcement.TargetUserIds = append(announcement.TargetUserIds, userId)
+		}
+	}
+	return nil
+}
+
+// insertAnnouncementTargets - Stores the audience of an announcement;
+func insertAnnouncementTargets(tx *sql.Tx, announcement models.Announcement) error {
+	statement, err := tx.Prepare("INSERT IGNORE INTO announcement_targets (announcement_id, target_type, target_value) VALUES (?, ?, ?)")
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot prepare statement!")
+	}
+	defer statement.Close()
+
+	targets := map[string][]string{
+		"role":  announcement.TargetRoles,
+		"class": announcement.TargetClasses,
+	}
+	for _, userId := range announcement.TargetUserIds {
+		targets["user"] = append(targets["user"], strconv.Itoa(userId))
+	}
+
+	for targetType, values := range targets {
+		for _, value := range values {
+			_, err = statement.Exec(announcement.Id, targetType, value)
+			if err != nil {
+				return utils.HandleError(err, "Err: Cannot store announcement audience!")
+			}
+		}
+	}
+	return nil
+}
+
+// AddAnnouncementDbHandler - Stores an announcement together with its audience;
+func AddAnnouncementDbHandler(ctx context.Context, announcement models.Announcement) (error, models.Announcement) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Announcement{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Announcement{}
+	}
+
+	announcement.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO announcements (title, body, author_id, author_role, target_all, pinned, publish_at, expire_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		announcement.Title, announcement.Body, announcement.AuthorId, announcement.AuthorRole, announcement.TargetAll, announcement.Pinned, announcement.PublishAt, nullableString(announcement.ExpireAt), announcement.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add announcement!"), models.Announcement{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add announcement!"), models.Announcement{}
+	}
+	announcement.Id = int(lastId)
+
+	err = insertAnnouncementTargets(tx, announcement)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Announcement{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Announcement{}
+	}
+
+	return nil, announcement
+}
+
+// GetAnnouncementsDbHandler - Lists announcements for management; authorId > 0 restricts the list to that author;
+// ?state=scheduled|active|expired filters by schedule;
+func GetAnnouncementsDbHandler(r *http.Request, authorId int) (error, []models.Announcement) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + announcementColumns + " FROM announcements a WHERE 1=1"
+	var args []interface{}
+
+	if authorId > 0 {
+		query += " AND a.author_id = ?"
+		args = append(args, authorId)
+	}
+
+	now := time.Now().Format(time.DateTime)
+	switch r.URL.Query().Get("state") {
+	case "scheduled":
+		query += " AND a.publish_at > ?"
+		args = append(args, now)
+	case "active":
+		query += " AND a.publish_at <= ? AND (a.expire_at IS NULL OR a.expire_at > ?)"
+		args = append(args, now, now)
+	case "expired":
+		query += " AND a.expire_at <= ?"
+		args = append(args, now)
+	}
+
+	query += " ORDER BY a.pinned DESC, a.publish_at DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		err = scanAnnouncement(rows, &announcement)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		announcements = append(announcements, announcement)
+	}
+	rows.Close()
+
+	for i := range announcements {
+		err = getAnnouncementTargets(db, &announcements[i])
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, announcements
+}
+
+// getAnnouncement - Fetches a single announcement with its audience; authorId > 0 hides other authors' announcements;
+func getAnnouncement(db dbExecutor, id, authorId int) (error, models.Announcement) {
+	var announcement models.Announcement
+	err := scanAnnouncement(db.QueryRow("SELECT "+announcementColumns+" FROM announcements a WHERE a.id = ?", id), &announcement)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No announcement found!"), models.Announcement{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Announcement{}
+	}
+
+	if authorId > 0 && announcement.AuthorId != authorId {
+		return utils.HandleError(errors.New("not author"), "Err: No announcement found!"), models.Announcement{}
+	}
+
+	err = getAnnouncementTargets(db, &announcement)
+	if err != nil {
+		return err, models.Announcement{}
+	}
+	return nil, announcement
+}
+
+// GetAnnouncementDbHandler - Fetches a single announcement for management;
+func GetAnnouncementDbHandler(ctx context.Context, id, authorId int) (error, models.Announcement) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Announcement{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getAnnouncement(db, id, authorId)
+}
+
+// PatchAnnouncementDbHandler - Updates the content, pinning or schedule of an announcement;
+func PatchAnnouncementDbHandler(ctx context.Context, id, authorId int, updates map[string]interface{}) (error, models.Announcement) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Announcement{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, announcement := getAnnouncement(db, id, authorId)
+	if err != nil {
+		return err, models.Announcement{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "title", "body", "publish_at", "expire_at":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Announcement{}
+			}
+			switch k {
+			case "title":
+				announcement.Title = value
+			case "body":
+				announcement.Body = value
+			case "publish_at":
+				announcement.PublishAt = value
+			case "expire_at":
+				announcement.ExpireAt = value
+			}
+		case "pinned":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for pinned!"), models.Announcement{}
+			}
+			announcement.Pinned = value
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Announcement{}
+		}
+	}
+
+	err = ValidateAnnouncement(announcement)
+	if err != nil {
+		return err, models.Announcement{}
+	}
+
+	announcement.UpdatedAt = time.Now().Format(time.DateTime)
+	_, err = db.Exec("UPDATE announcements SET title = ?, body = ?, pinned = ?, publish_at = ?, expire_at = ?, updated_at = ? WHERE id = ?",
+		announcement.Title, announcement.Body, announcement.Pinned, announcement.PublishAt, nullableString(announcement.ExpireAt), announcement.UpdatedAt, announcement.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update announcement!"), models.Announcement{}
+	}
+
+	return nil, announcement
+}
+
+// DeleteAnnouncementDbHandler - Deletes an announcement with its audience and read receipts;
+func DeleteAnnouncementDbHandler(ctx context.Context, id, authorId int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "DELETE FROM announcements WHERE id = ?"
+	args := []interface{}{id}
+	if authorId > 0 {
+		query += " AND author_id = ?"
+		args = append(args, authorId)
+	}
+
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete announcement!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete announcement!")
+	}
+	if rowsAffected == 0 {
+		return utils.HandleError(errors.New("not found"), "Err: No announcement found!")
+	}
+	return nil
+}
+
+// GetAnnouncementReadsDbHandler - Lists the read receipts of an announcement;
+func GetAnnouncementReadsDbHandler(ctx context.Context, id, authorId int) (error, []models.AnnouncementRead) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, _ = getAnnouncement(db, id, authorId)
+	if err != nil {
+		return err, nil
+	}
+
+	rows, err := db.Query("SELECT ar.announcement_id, ar.user_id, e.first_name, e.last_name, e.role, ar.read_at FROM announcement_reads ar JOIN execs e ON e.id = ar.user_id WHERE ar.announcement_id = ? ORDER BY ar.read_at", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	reads := []models.AnnouncementRead{}
+	for rows.Next() {
+		var read models.AnnouncementRead
+		err = rows.Scan(&read.AnnouncementId, &read.UserId, &read.FirstName, &read.LastName, &read.Role, &read.ReadAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		reads = append(reads, read)
+	}
+	return nil, reads
+}
+
+// feedCondition - Builds the condition matching the live announcements (alias a) addressed to a user;
+// A user is reached through the whole school, their role, their own id or a class they teach or have a child in;
+func feedCondition(db dbExecutor, userId int, role string) (error, string, []interface{}) {
+	rows, err := db.Query(`SELECT t.class FROM teachers t WHERE t.exec_id = ?
+		UNION SELECT s.class FROM student_guardians g JOIN students s ON s.id = g.student_id WHERE g.exec_id = ? AND s.inactive_status = 0`, userId, userId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), "", nil
+	}
+	defer rows.Close()
+
+	var classes []interface{}
+	for rows.Next() {
+		var class string
+		err = rows.Scan(&class)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), "", nil
+		}
+		classes = append(classes, class)
+	}
+
+	now := time.Now().Format(time.DateTime)
+	condition := `a.publish_at <= ? AND (a.expire_at IS NULL OR a.expire_at > ?) AND (a.target_all = 1 OR EXISTS (
+		SELECT 1 FROM announcement_targets tg WHERE tg.announcement_id = a.id AND (
+			(tg.target_type = 'role' AND tg.target_value = ?) OR (tg.target_type = 'user' AND tg.target_value = ?)`
+	args := []interface{}{now, now, role, strconv.Itoa(userId)}
+
+	if len(classes) > 0 {
+		condition += " OR (tg.target_type = 'class' AND tg.target_value IN (?" + strings.Repeat(", ?", len(classes)-1) + "))"
+		args = append(args, classes...)
+	}
+	condition += ")))"
+
+	return nil, condition, args
+}
+
+// GetAnnouncementFeedDbHandler - Personalised feed of a user: pinned first, newest first, with their read time;
+// ?unread=true only returns the announcements the user has not read yet;
+func GetAnnouncementFeedDbHandler(r *http.Request, userId int, role string) (error, []models.Announcement) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, condition, conditionArgs := feedCondition(db, userId, role)
+	if err != nil {
+		return err, nil
+	}
+
+	query := "SELECT " + announcementColumns + ", ar.read_at FROM announcements a LEFT JOIN announcement_reads ar ON ar.announcement_id = a.id AND ar.user_id = ? WHERE " + condition
+	args := append([]interface{}{userId}, conditionArgs...)
+
+	if r.URL.Query().Get("unread") == "true" {
+		query += " AND ar.read_at IS NULL"
+	}
+	query += " ORDER BY a.pinned DESC, a.publish_at DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	// The audience is not part of the feed, recipients should not see who else was addressed;
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		var readAt sql.NullString
+		err = scanAnnouncement(rows, &announcement, &readAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		announcement.ReadAt = readAt.String
+		announcements = append(announcements, announcement)
+	}
+	return nil, announcements
+}
+
+// MarkAnnouncementReadDbHandler - Records the read receipt of a user for an announcement in their feed;
+func MarkAnnouncementReadDbHandler(ctx context.Context, id, userId int, role string) (error, string) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), ""
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, condition, conditionArgs := feedCondition(db, userId, role)
+	if err != nil {
+		return err, ""
+	}
+
+	var visible int
+	err = db.QueryRow("SELECT COUNT(*) FROM announcements a WHERE a.id = ? AND "+condition, append([]interface{}{id}, conditionArgs...)...).Scan(&visible)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), ""
+	}
+	if visible == 0 {
+		return utils.HandleError(errors.New("not in feed"), "Err: No announcement found!"), ""
+	}
+
+	// Only the first read is kept;
+	readAt := time.Now().Format(time.DateTime)
+	_, err = db.Exec("INSERT IGNORE INTO announcement_reads (announcement_id, user_id, read_at) VALUES (?, ?, ?)", id, userId, readAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot record read receipt!"), ""
+	}
+
+	err = db.QueryRow("SELECT read_at FROM announcement_reads WHERE announcement_id = ? AND user_id = ?", id, userId).Scan(&readAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Data retrieval failed!"), ""
+	}
+	return nil, readAt
+}
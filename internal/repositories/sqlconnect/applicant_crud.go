@@ -0,0 +1,356 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// Admissions errors that are the client's to fix; handlers tell them apart from database failures with errors.Is;
+var (
+	ErrApplicantNotFound    = errors.New("Err: No applicant found!")
+	ErrDuplicateApplication = errors.New("Err: An application for this applicant is already in progress!")
+	ErrInvalidTransition    = errors.New("Err: Invalid applicant transition")
+)
+
+const applicantColumns = "id, first_name, last_name, email, date_of_birth, applying_for_class, academic_year, guardian_name, guardian_email, guardian_phone, previous_school, status, interview_at, student_id, created_at, updated_at"
+
+// scanApplicant - Scans a single applicant row selected with applicantColumns;
+func scanApplicant(scanner interface{ Scan(...interface{}) error }, applicant *models.Applicant) error {
+	var interviewAt, updatedAt sql.NullString
+	var studentId sql.NullInt64
+	err := scanner.Scan(&applicant.Id, &applicant.FirstName, &applicant.LastName, &applicant.Email, &applicant.DateOfBirth, &applicant.ApplyingForClass, &applicant.AcademicYear,
+		&applicant.GuardianName, &applicant.GuardianEmail, &applicant.GuardianPhone, &applicant.PreviousSchool, &applicant.Status, &interviewAt, &studentId, &applicant.CreatedAt, &updatedAt)
+	applicant.InterviewAt = interviewAt.String
+	applicant.UpdatedAt = updatedAt.String
+	applicant.StudentId = int(studentId.Int64)
+	return err
+}
+
+// recordApplicantTransition - Writes an entry to the applicant state history;
+func recordApplicantTransition(db dbExecutor, transition models.ApplicantTransition) error {
+	var actorId interface{}
+	if transition.ActorId > 0 {
+		actorId = transition.ActorId
+	}
+
+	_, err := db.Exec("INSERT INTO applicant_transitions (applicant_id, from_status, to_status, actor_id, actor_role, note, changed_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		transition.ApplicantId, transition.FromStatus, transition.ToStatus, actorId, transition.ActorRole, transition.Note, transition.ChangedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot record applicant history!")
+	}
+	return nil
+}
+
+// AddApplicantDbHandler - Stores a new application in the submitted state;
+func AddApplicantDbHandler(ctx context.Context, request models.ApplicationRequest) (error, models.Applicant) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Applicant{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	// Blocks duplicate applications that are still in progress;
+	var existing int
+	err = db.QueryRow("SELECT COUNT(*) FROM applicants WHERE email = ? AND academic_year = ? AND status NOT IN ('rejected', 'withdrawn')", request.Email, request.AcademicYear).Scan(&existing)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Applicant{}
+	}
+	if existing > 0 {
+		return ErrDuplicateApplication, models.Applicant{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Applicant{}
+	}
+
+	now := time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO applicants (first_name, last_name, email, date_of_birth, applying_for_class, academic_year, guardian_name, guardian_email, guardian_phone, previous_school, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'submitted', ?)",
+		request.FirstName, request.LastName, request.Email, request.DateOfBirth, request.ApplyingForClass, request.AcademicYear, request.GuardianName, request.GuardianEmail, request.GuardianPhone, request.PreviousSchool, now)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add applicant to database!"), models.Applicant{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add applicant to database!"), models.Applicant{}
+	}
+
+	err = recordApplicantTransition(tx, models.ApplicantTransition{
+		ApplicantId: int(lastId),
+		ToStatus:    "submitted",
+		ActorRole:   "applicant",
+		Note:        "Application submitted",
+		ChangedAt:   now,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err, models.Applicant{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Applicant{}
+	}
+
+	return nil, models.Applicant{
+		Id:               int(lastId),
+		FirstName:        request.FirstName,
+		LastName:         request.LastName,
+		Email:            request.Email,
+		ApplyingForClass: request.ApplyingForClass,
+		AcademicYear:     request.AcademicYear,
+		Status:           "submitted",
+		CreatedAt:        now,
+	}
+}
+
+// GetApplicantsDbHandler - Lists applicants, filterable by status, class and academic year;
+func GetApplicantsDbHandler(r *http.Request) (error, []models.Applicant) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + applicantColumns + " FROM applicants WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"status":             "status",
+		"applying_for_class": "applying_for_class",
+		"academic_year":      "academic_year",
+		"email":              "email",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	var applicants []models.Applicant
+	for rows.Next() {
+		var applicant models.Applicant
+		err = scanApplicant(rows, &applicant)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		applicants = append(applicants, applicant)
+	}
+
+	return nil, applicants
+}
+
+// GetApplicantDbHandler - Fetches a single applicant;
+func GetApplicantDbHandler(ctx context.Context, id int) (error, models.Applicant) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Applicant{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var applicant models.Applicant
+	err = scanApplicant(db.QueryRow("SELECT "+applicantColumns+" FROM applicants WHERE id = ?", id), &applicant)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No applicant found!"), models.Applicant{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Applicant{}
+	}
+	return nil, applicant
+}
+
+// GetApplicantHistoryDbHandler - Fetches the state changes of an applicant, oldest first;
+func GetApplicantHistoryDbHandler(ctx context.Context, id int) (error, []models.ApplicantTransition) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	rows, err := db.Query("SELECT id, applicant_id, from_status, to_status, actor_id, actor_role, note, changed_at FROM applicant_transitions WHERE applicant_id = ? ORDER BY id", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	history := []models.ApplicantTransition{}
+	for rows.Next() {
+		var transition models.ApplicantTransition
+		var fromStatus sql.NullString
+		var actorId sql.NullInt64
+		err = rows.Scan(&transition.Id, &transition.ApplicantId, &fromStatus, &transition.ToStatus, &actorId, &transition.ActorRole, &transition.Note, &transition.ChangedAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		transition.FromStatus = fromStatus.String
+		transition.ActorId = int(actorId.Int64)
+		history = append(history, transition)
+	}
+
+	return nil, history
+}
+
+// TransitionApplicantDbHandler - Validates and applies a state change of an applicant;
+// Accepting an offer creates the student record and its enrollment, and moves the applicant on to enrolled, in one transaction;
+func TransitionApplicantDbHandler(ctx context.Context, id int, request models.ApplicantTransitionRequest, actorId int, actorRole string) (error, models.Applicant) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Applicant{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Applicant{}
+	}
+
+	var applicant models.Applicant
+	err = scanApplicant(tx.QueryRow("SELECT "+applicantColumns+" FROM applicants WHERE id = ? FOR UPDATE", id), &applicant)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrApplicantNotFound, models.Applicant{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Applicant{}
+	}
+
+	if request.Status == "enrolled" || !models.CanTransition(applicant.Status, request.Status) {
+		tx.Rollback()
+		return fmt.Errorf("%w: cannot move applicant from %s to %s!", ErrInvalidTransition, applicant.Status, request.Status), models.Applicant{}
+	}
+
+	now := time.Now().Format(time.DateTime)
+	transition := models.ApplicantTransition{
+		ApplicantId: applicant.Id,
+		FromStatus:  applicant.Status,
+		ToStatus:    request.Status,
+		ActorId:     actorId,
+		ActorRole:   actorRole,
+		Note:        request.Note,
+		ChangedAt:   now,
+	}
+
+	if request.Status == "interview_scheduled" {
+		applicant.InterviewAt = request.InterviewAt
+	}
+
+	err = recordApplicantTransition(tx, transition)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Applicant{}
+	}
+	applicant.Status = request.Status
+
+	if request.Status == "accepted" {
+		class := applicant.ApplyingForClass
+		if request.Class != "" {
+			class = request.Class
+		}
+
+		res, err := tx.Exec("INSERT INTO students (first_name, last_name, email, class) VALUES (?, ?, ?, ?)", applicant.FirstName, applicant.LastName, applicant.Email, class)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot add student to database!"), models.Applicant{}
+		}
+
+		lastId, err := res.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot add student to database!"), models.Applicant{}
+		}
+		applicant.StudentId = int(lastId)
+
+		// Numbered like students added directly, within the same transaction;
+		_, err = assignRollNumber(tx, applicant.StudentId, class)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Applicant{}
+		}
+
+		err = openEnrollment(tx, applicant.StudentId, class, applicant.AcademicYear, 0)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Applicant{}
+		}
+
+		transition.FromStatus = "accepted"
+		transition.ToStatus = "enrolled"
+		transition.Note = fmt.Sprintf("Enrolled as student %d in %s", applicant.StudentId, class)
+		err = recordApplicantTransition(tx, transition)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Applicant{}
+		}
+		applicant.Status = "enrolled"
+	}
+
+	var interviewAt, studentId interface{}
+	if applicant.InterviewAt != "" {
+		interviewAt = applicant.InterviewAt
+	}
+	if applicant.StudentId > 0 {
+		studentId = applicant.StudentId
+	}
+
+	_, err = tx.Exec("UPDATE applicants SET status = ?, interview_at = ?, student_id = ?, updated_at = ? WHERE id = ?", applicant.Status, interviewAt, studentId, now, applicant.Id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update applicant in db!"), models.Applicant{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Applicant{}
+	}
+
+	applicant.UpdatedAt = now
+	return nil, applicant
+}
@@ -0,0 +1,923 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strings"
+	"time"
+)
+
+// maxClubCapacity - Upper bound for the capacity of a club;
+const maxClubCapacity = 1000
+
+const clubColumns = "c.id, c.name, c.description, c.capacity, c.active, c.created_at, " +
+	"(SELECT COUNT(*) FROM club_members m WHERE m.club_id = c.id AND m.status = 'active'), " +
+	"(SELECT COUNT(*) FROM club_members m WHERE m.club_id = c.id AND m.status = 'waitlisted')"
+
+func scanClub(scanner interface{ Scan(...interface{}) error }, club *models.Club) error {
+	return scanner.Scan(&club.Id, &club.Name, &club.Description, &club.Capacity, &club.Active, &club.CreatedAt, &club.MemberCount, &club.WaitlistCount)
+}
+
+// ValidateClub - Checks the name, capacity, supervisors and weekly schedule of a club;
+func ValidateClub(club models.Club) error {
+	if strings.TrimSpace(club.Name) == "" || len(club.Name) > 255 {
+		return errors.New("Err: Club name must be between 1 and 255 characters!")
+	}
+	if club.Capacity < 1 || club.Capacity > maxClubCapacity {
+		return errors.New("Err: Capacity must be between 1 and 1000!")
+	}
+	if len(club.SupervisorIds) == 0 {
+		return errors.New("Err: At least one supervising teacher is required!")
+	}
+
+	for _, meeting := range club.Schedule {
+		if meeting.DayOfWeek < 1 || meeting.DayOfWeek > 7 {
+			return errors.New("Err: Day of week must be between 1 (Monday) and 7 (Sunday)!")
+		}
+		start, err := time.Parse(time.TimeOnly, meeting.StartTime)
+		if err != nil {
+			return errors.New("Err: Start time must be in HH:MM:SS format!")
+		}
+		end, err := time.Parse(time.TimeOnly, meeting.EndTime)
+		if err != nil || !end.After(start) {
+			return errors.New("Err: End time must be in HH:MM:SS format and after the start time!")
+		}
+	}
+	return nil
+}
+
+// replaceClubDetails - Rewrites the supervisors and weekly schedule of a club;
+func replaceClubDetails(tx *sql.Tx, club models.Club) error {
+	_, err := tx.Exec("DELETE FROM club_supervisors WHERE club_id = ?", club.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update supervisors!")
+	}
+	for _, teacherId := range club.SupervisorIds {
+		_, err = tx.Exec("INSERT IGNORE INTO club_supervisors (club_id, teacher_id) VALUES (?, ?)", club.Id, teacherId)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot add supervisor, the teacher may not exist!")
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM club_schedules WHERE club_id = ?", club.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update schedule!")
+	}
+	for _, meeting := range club.Schedule {
+		_, err = tx.Exec("INSERT INTO club_schedules (club_id, day_of_week, start_time, end_time, location) VALUES (?, ?, ?, ?, ?)",
+			club.Id, meeting.DayOfWeek, meeting.StartTime, meeting.EndTime, meeting.Location)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot update schedule!")
+		}
+	}
+	return nil
+}
+
+// getClubDetails - Loads the supervisors and weekly schedule of a club;
+func getClubDetails(db dbExecutor, club *models.Club) error {
+	rows, err := db.Query("SELECT t.id, t.first_name, t.last_name, t.subject FROM club_supervisors cs JOIN teachers t ON t.id = cs.teacher_id WHERE cs.club_id = ? ORDER BY t.last_name, t.first_name", club.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+
+	club.SupervisorIds = []int{}
+	club.Supervisors = []models.ClubSupervisor{}
+	for rows.Next() {
+		var supervisor models.ClubSupervisor
+		err = rows.Scan(&supervisor.TeacherId, &supervisor.FirstName, &supervisor.LastName, &supervisor.Subject)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		club.SupervisorIds = append(club.SupervisorIds, supervisor.TeacherId)
+		club.Supervisors = append(club.Supervisors, supervisor)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT day_of_week, start_time, end_time, location FROM club_schedules WHERE club_id = ? ORDER BY day_of_week, start_time", club.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	defer rows.Close()
+
+	club.Schedule = []models.ClubMeeting{}
+	for rows.Next() {
+		var meeting models.ClubMeeting
+		err = rows.Scan(&meeting.DayOfWeek, &meeting.StartTime, &meeting.EndTime, &meeting.Location)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		club.Schedule = append(club.Schedule, meeting)
+	}
+	return nil
+}
+
+func getClub(db dbExecutor, id int) (error, models.Club) {
+	var club models.Club
+	err := scanClub(db.QueryRow("SELECT "+clubColumns+" FROM clubs c WHERE c.id = ?", id), &club)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No club found!"), models.Club{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Club{}
+	}
+
+	err = getClubDetails(db, &club)
+	if err != nil {
+		return err, models.Club{}
+	}
+	return nil, club
+}
+
+// AddClubDbHandler - Creates a club with its supervisors and schedule;
+func AddClubDbHandler(ctx context.Context, club models.Club) (error, models.Club) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Club{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Club{}
+	}
+
+	club.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO clubs (name, description, capacity, active, created_at) VALUES (?, ?, ?, ?, ?)",
+		club.Name, club.Description, club.Capacity, club.Active, club.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add club, the name may already be taken!"), models.Club{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add club!"), models.Club{}
+	}
+	club.Id = int(lastId)
+
+	err = replaceClubDetails(tx, club)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Club{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Club{}
+	}
+	return getClub(db, club.Id)
+}
+
+// GetClubsDbHandler - Lists clubs, filtered by ?active=true|false and ?teacher_id= (supervisor);
+func GetClubsDbHandler(r *http.Request) (error, []models.Club) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + clubColumns + " FROM clubs c WHERE 1=1"
+	var args []interface{}
+
+	switch r.URL.Query().Get("active") {
+	case "true":
+		query += " AND c.active = 1"
+	case "false":
+		query += " AND c.active = 0"
+	}
+	if teacherId := r.URL.Query().Get("teacher_id"); teacherId != "" {
+		query += " AND EXISTS (SELECT 1 FROM club_supervisors cs WHERE cs.club_id = c.id AND cs.teacher_id = ?)"
+		args = append(args, teacherId)
+	}
+	query += " ORDER BY c.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	clubs := []models.Club{}
+	for rows.Next() {
+		var club models.Club
+		err = scanClub(rows, &club)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		clubs = append(clubs, club)
+	}
+	rows.Close()
+
+	for i := range clubs {
+		err = getClubDetails(db, &clubs[i])
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, clubs
+}
+
+// GetClubDbHandler - Fetches a club with its supervisors, schedule and member counts;
+func GetClubDbHandler(ctx context.Context, id int) (error, models.Club) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Club{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getClub(db, id)
+}
+
+// IsClubSupervisorDbHandler - Whether a teacher supervises a club;
+func IsClubSupervisorDbHandler(ctx context.Context, clubId, teacherId int) (error, bool) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM club_supervisors WHERE club_id = ? AND teacher_id = ?", clubId, teacherId).Scan(&count)
+	if err != nil {
+		return utils.HandleError(err, "Err: Data retrieval failed!"), false
+	}
+	return nil, count > 0
+}
+
+// PatchClubDbHandler - Updates name, description, capacity, active, supervisor_ids or schedule;
+// Raising the capacity promotes waitlisted students;
+func PatchClubDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Club) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Club{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, club := getClub(db, id)
+	if err != nil {
+		return err, models.Club{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "name", "description":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Club{}
+			}
+			if k == "name" {
+				club.Name = value
+			} else {
+				club.Description = value
+			}
+		case "capacity":
+			value, ok := v.(float64)
+			if !ok || value != math.Trunc(value) {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for capacity!"), models.Club{}
+			}
+			club.Capacity = int(value)
+		case "active":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for active!"), models.Club{}
+			}
+			club.Active = value
+		case "supervisor_ids", "schedule":
+			// Lists are decoded again into their typed form;
+			raw, err := json.Marshal(v)
+			if err == nil {
+				if k == "supervisor_ids" {
+					err = json.Unmarshal(raw, &club.SupervisorIds)
+				} else {
+					err = json.Unmarshal(raw, &club.Schedule)
+				}
+			}
+			if err != nil {
+				return utils.HandleError(err, "Err: Invalid value for "+k+"!"), models.Club{}
+			}
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Club{}
+		}
+	}
+
+	err = ValidateClub(club)
+	if err != nil {
+		return err, models.Club{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Club{}
+	}
+
+	_, err = tx.Exec("UPDATE clubs SET name = ?, description = ?, capacity = ?, active = ? WHERE id = ?", club.Name, club.Description, club.Capacity, club.Active, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update club, the name may already be taken!"), models.Club{}
+	}
+
+	_, supervisorsChanged := updates["supervisor_ids"]
+	_, scheduleChanged := updates["schedule"]
+	if supervisorsChanged || scheduleChanged {
+		err = replaceClubDetails(tx, club)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Club{}
+		}
+	}
+
+	_, err = promoteWaitlist(tx, id, club.Capacity)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Club{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Club{}
+	}
+	return getClub(db, id)
+}
+
+// DeleteClubDbHandler - Deletes a club with its memberships, sessions and attendance;
+func DeleteClubDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("DELETE FROM clubs WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete club!")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete club!")
+	}
+	if affected == 0 {
+		return utils.HandleError(sql.ErrNoRows, "Err: No club found!")
+	}
+	return nil
+}
+
+// promoteWaitlist - Moves waitlisted students (first come, first served) into free places; returns the promoted student ids;
+func promoteWaitlist(tx *sql.Tx, clubId, capacity int) ([]int, error) {
+	var active int
+	err := tx.QueryRow("SELECT COUNT(*) FROM club_members WHERE club_id = ? AND status = 'active'", clubId).Scan(&active)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+
+	free := capacity - active
+	if free <= 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query("SELECT id, student_id FROM club_members WHERE club_id = ? AND status = 'waitlisted' ORDER BY joined_at, id LIMIT ?", clubId, free)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Query execution failed!")
+	}
+
+	var memberIds, studentIds []int
+	for rows.Next() {
+		var memberId, studentId int
+		err = rows.Scan(&memberId, &studentId)
+		if err != nil {
+			rows.Close()
+			return nil, utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		memberIds = append(memberIds, memberId)
+		studentIds = append(studentIds, studentId)
+	}
+	rows.Close()
+
+	for _, memberId := range memberIds {
+		_, err = tx.Exec("UPDATE club_members SET status = 'active' WHERE id = ?", memberId)
+		if err != nil {
+			return nil, utils.HandleError(err, "Err: Cannot promote waitlisted student!")
+		}
+	}
+	return studentIds, nil
+}
+
+// JoinClubDbHandler - Adds a student to a club, or to its waitlist when the club is full;
+func JoinClubDbHandler(ctx context.Context, clubId, studentId int) (error, models.ClubMember) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.ClubMember{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.ClubMember{}
+	}
+
+	// Locking the club serialises joins so the capacity cannot be overrun;
+	var capacity int
+	var active bool
+	err = tx.QueryRow("SELECT capacity, active FROM clubs WHERE id = ? FOR UPDATE", clubId).Scan(&capacity, &active)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No club found!"), models.ClubMember{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.ClubMember{}
+	}
+	if !active {
+		tx.Rollback()
+		return utils.HandleError(errors.New("inactive club"), "Err: The club is not active!"), models.ClubMember{}
+	}
+
+	member := models.ClubMember{ClubId: clubId, StudentId: studentId}
+	var inactive bool
+	err = tx.QueryRow("SELECT first_name, last_name, class, inactive_status FROM students WHERE id = ?", studentId).Scan(&member.FirstName, &member.LastName, &member.Class, &inactive)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No student found!"), models.ClubMember{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.ClubMember{}
+	}
+	if inactive {
+		tx.Rollback()
+		return utils.HandleError(errors.New("inactive student"), "Err: The student is no longer enrolled!"), models.ClubMember{}
+	}
+
+	var existingId int
+	var existingStatus string
+	err = tx.QueryRow("SELECT id, status FROM club_members WHERE club_id = ? AND student_id = ?", clubId, studentId).Scan(&existingId, &existingStatus)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.ClubMember{}
+	}
+	if existingStatus == "active" || existingStatus == "waitlisted" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("already member"), "Err: The student is already "+existingStatus+" in this club!"), models.ClubMember{}
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM club_members WHERE club_id = ? AND status = 'active'", clubId).Scan(&count)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.ClubMember{}
+	}
+
+	member.Status = "active"
+	if count >= capacity {
+		member.Status = "waitlisted"
+	}
+	member.JoinedAt = time.Now().Format(time.DateTime)
+
+	if existingId != 0 {
+		// Students who left before re-join at the back of the queue;
+		member.Id = existingId
+		_, err = tx.Exec("UPDATE club_members SET status = ?, joined_at = ?, left_at = NULL WHERE id = ?", member.Status, member.JoinedAt, existingId)
+	} else {
+		var res sql.Result
+		res, err = tx.Exec("INSERT INTO club_members (club_id, student_id, status, joined_at) VALUES (?, ?, ?, ?)", clubId, studentId, member.Status, member.JoinedAt)
+		if err == nil {
+			var lastId int64
+			lastId, err = res.LastInsertId()
+			member.Id = int(lastId)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add member!"), models.ClubMember{}
+	}
+
+	if member.Status == "waitlisted" {
+		err = tx.QueryRow("SELECT COUNT(*) FROM club_members WHERE club_id = ? AND status = 'waitlisted'", clubId).Scan(&member.Position)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.ClubMember{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.ClubMember{}
+	}
+	return nil, member
+}
+
+// LeaveClubDbHandler - Takes a student out of a club (or its waitlist); a freed place goes to the waitlist;
+// Returns the ids of the students promoted from the waitlist;
+func LeaveClubDbHandler(ctx context.Context, clubId, studentId int) (error, []int) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), nil
+	}
+
+	var capacity int
+	err = tx.QueryRow("SELECT capacity FROM clubs WHERE id = ? FOR UPDATE", clubId).Scan(&capacity)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No club found!"), nil
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+	}
+
+	res, err := tx.Exec("UPDATE club_members SET status = 'left', left_at = ? WHERE club_id = ? AND student_id = ? AND status <> 'left'",
+		time.Now().Format(time.DateTime), clubId, studentId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot remove member!"), nil
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("not a member"), "Err: The student is not a member of this club!"), nil
+	}
+
+	promoted, err := promoteWaitlist(tx, clubId, capacity)
+	if err != nil {
+		tx.Rollback()
+		return err, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), nil
+	}
+	if promoted == nil {
+		promoted = []int{}
+	}
+	return nil, promoted
+}
+
+// GetClubMembersDbHandler - Lists the members of a club (?status=active|waitlisted|left, default active and waitlisted);
+func GetClubMembersDbHandler(ctx context.Context, clubId int, status string) (error, []models.ClubMember) {
+	if status != "" && !isAllowedValue(status, models.ClubMemberStatuses) {
+		return utils.HandleError(errors.New("invalid status"), "Err: Status must be active, waitlisted or left!"), nil
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, _ = getClub(db, clubId)
+	if err != nil {
+		return err, nil
+	}
+
+	query := "SELECT m.id, m.club_id, m.student_id, s.first_name, s.last_name, s.class, m.status, m.joined_at, m.left_at FROM club_members m JOIN students s ON s.id = m.student_id WHERE m.club_id = ?"
+	args := []interface{}{clubId}
+	if status != "" {
+		query += " AND m.status = ?"
+		args = append(args, status)
+	} else {
+		query += " AND m.status <> 'left'"
+	}
+	// Waitlisted students come last, in queue order;
+	query += " ORDER BY m.status = 'waitlisted', m.joined_at, m.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	members := []models.ClubMember{}
+	position := 0
+	for rows.Next() {
+		var member models.ClubMember
+		var leftAt sql.NullString
+		err = rows.Scan(&member.Id, &member.ClubId, &member.StudentId, &member.FirstName, &member.LastName, &member.Class, &member.Status, &member.JoinedAt, &leftAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		member.LeftAt = leftAt.String
+		if member.Status == "waitlisted" {
+			position++
+			member.Position = position
+		}
+		members = append(members, member)
+	}
+	return nil, members
+}
+
+// AddClubSessionDbHandler - Records a club session; attendance is taken on it afterwards;
+func AddClubSessionDbHandler(ctx context.Context, session models.ClubSession) (error, models.ClubSession) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.ClubSession{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	session.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := db.Exec("INSERT INTO club_sessions (club_id, session_date, start_time, notes, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		session.ClubId, session.SessionDate, session.StartTime, session.Notes, session.CreatedBy, session.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add session, it may already exist!"), models.ClubSession{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add session!"), models.ClubSession{}
+	}
+	session.Id = int(lastId)
+	return nil, session
+}
+
+// GetClubSessionsDbHandler - Lists the sessions of a club with their attendance totals, newest first;
+func GetClubSessionsDbHandler(ctx context.Context, clubId int) (error, []models.ClubSession) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	rows, err := db.Query(`SELECT cs.id, cs.club_id, cs.session_date, cs.start_time, cs.notes, cs.created_by, cs.created_at,
+		COALESCE(SUM(a.status = 'present'), 0), COALESCE(SUM(a.status = 'absent'), 0), COALESCE(SUM(a.status = 'excused'), 0)
+		FROM club_sessions cs LEFT JOIN club_attendance a ON a.session_id = cs.id
+		WHERE cs.club_id = ? GROUP BY cs.id ORDER BY cs.session_date DESC, cs.start_time DESC`, clubId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	sessions := []models.ClubSession{}
+	for rows.Next() {
+		var session models.ClubSession
+		err = rows.Scan(&session.Id, &session.ClubId, &session.SessionDate, &session.StartTime, &session.Notes, &session.CreatedBy, &session.CreatedAt,
+			&session.Present, &session.Absent, &session.Excused)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		sessions = append(sessions, session)
+	}
+	return nil, sessions
+}
+
+// checkClubSession - Makes sure the session belongs to the club;
+func checkClubSession(db dbExecutor, clubId, sessionId int) error {
+	var id int
+	err := db.QueryRow("SELECT id FROM club_sessions WHERE id = ? AND club_id = ?", sessionId, clubId).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No session found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	return nil
+}
+
+// GetSessionAttendanceDbHandler - The register of a session: current members plus anyone already marked; status is empty when unmarked;
+func GetSessionAttendanceDbHandler(ctx context.Context, clubId, sessionId int) (error, []models.ClubAttendance) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = checkClubSession(db, clubId, sessionId)
+	if err != nil {
+		return err, nil
+	}
+
+	rows, err := db.Query(`SELECT m.student_id, s.first_name, s.last_name, COALESCE(a.status, '')
+		FROM club_members m JOIN students s ON s.id = m.student_id
+		LEFT JOIN club_attendance a ON a.session_id = ? AND a.student_id = m.student_id
+		WHERE m.club_id = ? AND (m.status = 'active' OR a.status IS NOT NULL)
+		ORDER BY s.last_name, s.first_name`, sessionId, clubId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	register := []models.ClubAttendance{}
+	for rows.Next() {
+		record := models.ClubAttendance{SessionId: sessionId}
+		err = rows.Scan(&record.StudentId, &record.FirstName, &record.LastName, &record.Status)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		register = append(register, record)
+	}
+	return nil, register
+}
+
+// SaveSessionAttendanceDbHandler - Marks attendance for a session; only active members can be marked;
+func SaveSessionAttendanceDbHandler(ctx context.Context, clubId, sessionId int, records []models.ClubAttendance) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = checkClubSession(db, clubId, sessionId)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	for _, record := range records {
+		if !isAllowedValue(record.Status, models.ClubAttendanceStatuses) {
+			tx.Rollback()
+			return utils.HandleError(errors.New("invalid status"), "Err: Attendance status must be present, absent or excused!")
+		}
+
+		var count int
+		err = tx.QueryRow("SELECT COUNT(*) FROM club_members WHERE club_id = ? AND student_id = ? AND status = 'active'", clubId, record.StudentId).Scan(&count)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		if count == 0 {
+			tx.Rollback()
+			return utils.HandleError(errors.New("not a member"), "Err: Student is not an active member of this club!")
+		}
+
+		_, err = tx.Exec("INSERT INTO club_attendance (session_id, student_id, status) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE status = VALUES(status)",
+			sessionId, record.StudentId, record.Status)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot save attendance!")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
+
+// participationQuery - Per membership: the club sessions held while the student was a member and how they attended;
+const participationQuery = `SELECT c.id, c.name, m.student_id, s.first_name, s.last_name, s.class, m.status, m.joined_at,
+	(SELECT COUNT(*) FROM club_sessions cs WHERE cs.club_id = m.club_id AND cs.session_date >= DATE(m.joined_at) AND (m.left_at IS NULL OR cs.session_date <= DATE(m.left_at))),
+	(SELECT COUNT(*) FROM club_attendance a JOIN club_sessions cs ON cs.id = a.session_id WHERE cs.club_id = m.club_id AND a.student_id = m.student_id AND a.status = 'present'),
+	(SELECT COUNT(*) FROM club_attendance a JOIN club_sessions cs ON cs.id = a.session_id WHERE cs.club_id = m.club_id AND a.student_id = m.student_id AND a.status = 'absent'),
+	(SELECT COUNT(*) FROM club_attendance a JOIN club_sessions cs ON cs.id = a.session_id WHERE cs.club_id = m.club_id AND a.student_id = m.student_id AND a.status = 'excused')
+	FROM club_members m JOIN clubs c ON c.id = m.club_id JOIN students s ON s.id = m.student_id`
+
+func getParticipation(db dbExecutor, condition string, args ...interface{}) (error, []models.ClubParticipation) {
+	rows, err := db.Query(participationQuery+" WHERE "+condition, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	list := []models.ClubParticipation{}
+	for rows.Next() {
+		var p models.ClubParticipation
+		err = rows.Scan(&p.ClubId, &p.ClubName, &p.StudentId, &p.FirstName, &p.LastName, &p.Class, &p.Status, &p.JoinedAt,
+			&p.Sessions, &p.Present, &p.Absent, &p.Excused)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+
+		// Excused sessions do not count against the student;
+		if expected := p.Sessions - p.Excused; expected > 0 {
+			p.AttendancePct = math.Round(float64(p.Present)*1000/float64(expected)) / 10
+		}
+		list = append(list, p)
+	}
+	return nil, list
+}
+
+// GetClubReportDbHandler - Participation report of a club: every current and former member with their attendance;
+func GetClubReportDbHandler(ctx context.Context, clubId int) (error, models.Club, []models.ClubParticipation) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Club{}, nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, club := getClub(db, clubId)
+	if err != nil {
+		return err, models.Club{}, nil
+	}
+
+	err, report := getParticipation(db, "m.club_id = ? AND m.status <> 'waitlisted' ORDER BY m.status, s.last_name, s.first_name", clubId)
+	if err != nil {
+		return err, models.Club{}, nil
+	}
+	return nil, club, report
+}
+
+// GetStudentActivitiesDbHandler - The clubs a student belongs to, is waitlisted for or has left, with attendance;
+func GetStudentActivitiesDbHandler(ctx context.Context, studentId int) (error, []models.ClubParticipation) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getParticipation(db, "m.student_id = ? ORDER BY m.status, c.name", studentId)
+}
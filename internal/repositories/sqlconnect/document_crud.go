@@ -0,0 +1,350 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+const documentVersionColumns = "v.id, v.document_id, v.version, v.file_name, v.content_type, v.size, v.checksum, v.storage_key, v.uploaded_by, v.uploaded_at"
+
+// maxDocumentTitleLength - Matches the documents.title column;
+const maxDocumentTitleLength = 255
+
+// ValidateDocument - Checks the owner type, category and title of a new document;
+func ValidateDocument(document models.Document) error {
+	if !isAllowedValue(document.OwnerType, models.DocumentOwnerTypes) {
+		return errors.New("Err: Invalid document owner type!")
+	}
+	if !isAllowedValue(document.Category, models.DocumentCategories) {
+		return errors.New("Err: Invalid document category!")
+	}
+	if document.Title == "" || len(document.Title) > maxDocumentTitleLength {
+		return errors.New("Err: Document title must be between 1 and 255 characters!")
+	}
+	return nil
+}
+
+func scanDocumentVersion(scanner interface{ Scan(...interface{}) error }, version *models.DocumentVersion) error {
+	return scanner.Scan(&version.Id, &version.DocumentId, &version.Version, &version.FileName, &version.ContentType,
+		&version.Size, &version.Checksum, &version.StorageKey, &version.UploadedBy, &version.UploadedAt)
+}
+
+// documentOwnerExists - Checks that the student or teacher a document is attached to exists;
+func documentOwnerExists(db dbExecutor, ownerType string, ownerId int) error {
+	table := "students"
+	if ownerType == "teacher" {
+		table = "teachers"
+	}
+
+	var id int
+	err := db.QueryRow("SELECT id FROM "+table+" WHERE id = ?", ownerId).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No "+ownerType+" found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	return nil
+}
+
+// insertDocumentVersion - Adds a version row; the caller bumps documents.current_version;
+func insertDocumentVersion(tx *sql.Tx, version *models.DocumentVersion) error {
+	version.UploadedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO document_versions (document_id, version, file_name, content_type, size, checksum, storage_key, uploaded_by, uploaded_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		version.DocumentId, version.Version, version.FileName, version.ContentType, version.Size, version.Checksum, version.StorageKey, version.UploadedBy, version.UploadedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot save document version!")
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot save document version!")
+	}
+	version.Id = int(lastId)
+	return nil
+}
+
+// AddDocumentDbHandler - Creates a document with its first version; the file must already be in storage;
+func AddDocumentDbHandler(ctx context.Context, document models.Document, version models.DocumentVersion) (error, models.Document) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Document{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = documentOwnerExists(db, document.OwnerType, document.OwnerId)
+	if err != nil {
+		return err, models.Document{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Document{}
+	}
+
+	document.CurrentVersion = 1
+	document.CreatedAt = time.Now().Format(time.DateTime)
+	document.UpdatedAt = document.CreatedAt
+	res, err := tx.Exec("INSERT INTO documents (owner_type, owner_id, category, title, current_version, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		document.OwnerType, document.OwnerId, document.Category, document.Title, document.CurrentVersion, document.CreatedBy, document.CreatedAt, document.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot save document!"), models.Document{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot save document!"), models.Document{}
+	}
+	document.Id = int(lastId)
+
+	version.DocumentId = document.Id
+	version.Version = 1
+	version.UploadedBy = document.CreatedBy
+	err = insertDocumentVersion(tx, &version)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Document{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Document{}
+	}
+
+	document.Latest = &version
+	return nil, document
+}
+
+// AddDocumentVersionDbHandler - Adds a new version to a document and makes it the current one;
+func AddDocumentVersionDbHandler(ctx context.Context, version models.DocumentVersion) (error, models.Document) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Document{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Document{}
+	}
+
+	// Lock the document so concurrent uploads get distinct version numbers;
+	var current int
+	err = tx.QueryRow("SELECT current_version FROM documents WHERE id = ? FOR UPDATE", version.DocumentId).Scan(&current)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No document found!"), models.Document{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Document{}
+	}
+
+	version.Version = current + 1
+	err = insertDocumentVersion(tx, &version)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Document{}
+	}
+
+	_, err = tx.Exec("UPDATE documents SET current_version = ?, updated_at = ? WHERE id = ?", version.Version, version.UploadedAt, version.DocumentId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update document!"), models.Document{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Document{}
+	}
+
+	return getDocument(db, version.DocumentId, true)
+}
+
+// GetDocumentsDbHandler - Lists the documents of a student or teacher with their current version (?category=);
+func GetDocumentsDbHandler(r *http.Request, ownerType string, ownerId int) (error, []models.Document) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT d.id, d.owner_type, d.owner_id, d.category, d.title, d.current_version, d.created_by, d.created_at, d.updated_at, " + documentVersionColumns +
+		" FROM documents d JOIN document_versions v ON v.document_id = d.id AND v.version = d.current_version WHERE d.owner_type = ? AND d.owner_id = ?"
+	args := []interface{}{ownerType, ownerId}
+
+	params := map[string]string{
+		"category": "d.category",
+	}
+	for param, column := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + column + " = ?"
+			args = append(args, value)
+		}
+	}
+	query += " ORDER BY d.category, d.title, d.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	documents := []models.Document{}
+	for rows.Next() {
+		var document models.Document
+		var latest models.DocumentVersion
+		err = rows.Scan(&document.Id, &document.OwnerType, &document.OwnerId, &document.Category, &document.Title, &document.CurrentVersion,
+			&document.CreatedBy, &document.CreatedAt, &document.UpdatedAt,
+			&latest.Id, &latest.DocumentId, &latest.Version, &latest.FileName, &latest.ContentType,
+			&latest.Size, &latest.Checksum, &latest.StorageKey, &latest.UploadedBy, &latest.UploadedAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		document.Latest = &latest
+		documents = append(documents, document)
+	}
+	return nil, documents
+}
+
+// getDocument - Reads a document, optionally with its full version history (newest first);
+func getDocument(db dbExecutor, id int, withVersions bool) (error, models.Document) {
+	var document models.Document
+	err := db.QueryRow("SELECT id, owner_type, owner_id, category, title, current_version, created_by, created_at, updated_at FROM documents WHERE id = ?", id).Scan(
+		&document.Id, &document.OwnerType, &document.OwnerId, &document.Category, &document.Title, &document.CurrentVersion,
+		&document.CreatedBy, &document.CreatedAt, &document.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No document found!"), models.Document{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Document{}
+	}
+
+	if !withVersions {
+		return nil, document
+	}
+
+	rows, err := db.Query("SELECT "+documentVersionColumns+" FROM document_versions v WHERE v.document_id = ? ORDER BY v.version DESC", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Document{}
+	}
+	defer rows.Close()
+
+	document.Versions = []models.DocumentVersion{}
+	for rows.Next() {
+		var version models.DocumentVersion
+		err = scanDocumentVersion(rows, &version)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.Document{}
+		}
+		document.Versions = append(document.Versions, version)
+	}
+	return nil, document
+}
+
+// GetDocumentDbHandler - Returns a document with its version history;
+func GetDocumentDbHandler(ctx context.Context, id int) (error, models.Document) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Document{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getDocument(db, id, true)
+}
+
+// GetDocumentVersionDbHandler - Returns the document and one of its versions (0 for the current version);
+func GetDocumentVersionDbHandler(ctx context.Context, id, versionNumber int) (error, models.Document, models.DocumentVersion) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Document{}, models.DocumentVersion{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, document := getDocument(db, id, false)
+	if err != nil {
+		return err, models.Document{}, models.DocumentVersion{}
+	}
+	if versionNumber == 0 {
+		versionNumber = document.CurrentVersion
+	}
+
+	var version models.DocumentVersion
+	err = scanDocumentVersion(db.QueryRow("SELECT "+documentVersionColumns+" FROM document_versions v WHERE v.document_id = ? AND v.version = ?", id, versionNumber), &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No such document version!"), models.Document{}, models.DocumentVersion{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Document{}, models.DocumentVersion{}
+	}
+	return nil, document, version
+}
+
+// DeleteDocumentDbHandler - Deletes a document and its versions; returns the storage keys so the files can be removed;
+func DeleteDocumentDbHandler(ctx context.Context, id int) (error, []string) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, document := getDocument(db, id, true)
+	if err != nil {
+		return err, nil
+	}
+
+	// Versions are removed by the foreign key cascade;
+	_, err = db.Exec("DELETE FROM documents WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete document!"), nil
+	}
+
+	keys := []string{}
+	for _, version := range document.Versions {
+		keys = append(keys, version.StorageKey)
+	}
+	return nil, keys
+}
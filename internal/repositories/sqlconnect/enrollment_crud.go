@@ -0,0 +1,187 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// openEnrollment - Starts an active enrollment of a student in a class;
+func openEnrollment(db dbExecutor, studentId int, class, academicYear string, promotionId int) error {
+	var promotion interface{}
+	if promotionId > 0 {
+		promotion = promotionId
+	}
+
+	_, err := db.Exec("INSERT INTO enrollments (student_id, class, academic_year, start_date, status, promotion_id) VALUES (?, ?, ?, ?, 'active', ?)",
+		studentId, class, academicYear, time.Now().Format(time.DateOnly), promotion)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot record enrollment!")
+	}
+	return nil
+}
+
+// closeEnrollment - Ends the active enrollment of a student with the given status (promoted, completed, withdrawn...);
+// For students created before enrollments were tracked, a closed record of their previous class is written instead;
+func closeEnrollment(db dbExecutor, studentId int, previousClass, closeStatus string) error {
+	today := time.Now().Format(time.DateOnly)
+	res, err := db.Exec("UPDATE enrollments SET end_date = ?, status = ? WHERE student_id = ? AND status = 'active'", today, closeStatus, studentId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update enrollment!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update enrollment!")
+	}
+
+	if rowsAffected == 0 && previousClass != "" {
+		_, err = db.Exec("INSERT INTO enrollments (student_id, class, academic_year, end_date, status) VALUES (?, ?, ?, ?, ?)",
+			studentId, previousClass, utils.AcademicYear(time.Now()), today, closeStatus)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot record enrollment!")
+		}
+	}
+	return nil
+}
+
+// recordClassChange - Keeps the enrollment history in sync when a student's class changes;
+func recordClassChange(db dbExecutor, studentId int, previousClass, newClass string) error {
+	if previousClass == newClass {
+		return nil
+	}
+
+	err := closeEnrollment(db, studentId, previousClass, "completed")
+	if err != nil {
+		return err
+	}
+
+	if newClass == "" {
+		return nil
+	}
+	return openEnrollment(db, studentId, newClass, utils.AcademicYear(time.Now()), 0)
+}
+
+// recordPromotion - Closes the current enrollment as promoted and opens the one for the next class;
+func recordPromotion(db dbExecutor, move models.PromotionMove, academicYear string, promotionId int) error {
+	err := closeEnrollment(db, move.StudentId, move.FromClass, "promoted")
+	if err != nil {
+		return err
+	}
+	return openEnrollment(db, move.StudentId, move.ToClass, academicYear, promotionId)
+}
+
+// revertPromotion - Removes the enrollment created by a promotion and re-opens the previous one;
+func revertPromotion(db dbExecutor, move models.PromotionMove, promotionId int) error {
+	_, err := db.Exec("DELETE FROM enrollments WHERE promotion_id = ? AND student_id = ?", promotionId, move.StudentId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update enrollment!")
+	}
+
+	_, err = db.Exec("UPDATE enrollments SET end_date = NULL, status = 'active' WHERE student_id = ? AND class = ? AND status = 'promoted' ORDER BY id DESC LIMIT 1", move.StudentId, move.FromClass)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update enrollment!")
+	}
+	return nil
+}
+
+// scanEnrollments - Reads enrollment rows (id, student, names, class, year, start, end, status, promotion);
+func scanEnrollments(rows *sql.Rows) (error, []models.Enrollment) {
+	enrollments := []models.Enrollment{}
+	for rows.Next() {
+		var enrollment models.Enrollment
+		var startDate, endDate sql.NullString
+		var promotionId sql.NullInt64
+		err := rows.Scan(&enrollment.Id, &enrollment.StudentId, &enrollment.FirstName, &enrollment.LastName, &enrollment.Class, &enrollment.AcademicYear, &startDate, &endDate, &enrollment.Status, &promotionId)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		enrollment.StartDate = startDate.String
+		enrollment.EndDate = endDate.String
+		enrollment.PromotionId = int(promotionId.Int64)
+		enrollments = append(enrollments, enrollment)
+	}
+	return nil, enrollments
+}
+
+const enrollmentSelect = "SELECT e.id, e.student_id, s.first_name, s.last_name, e.class, e.academic_year, e.start_date, e.end_date, e.status, e.promotion_id FROM enrollments e JOIN students s ON s.id = e.student_id"
+
+// GetStudentEnrollmentsDbHandler - Fetches the class history of a student, oldest first;
+func GetStudentEnrollmentsDbHandler(ctx context.Context, studentId int) (error, []models.Enrollment) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	scope, scopeArgs := studentScopeOn(ctx, "s.class")
+	rows, err := db.Query(enrollmentSelect+" WHERE e.student_id = ?"+scope+" ORDER BY e.academic_year, e.id", append([]interface{}{studentId}, scopeArgs...)...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	return scanEnrollments(rows)
+}
+
+// GetEnrollmentsDbHandler - Searches enrollments, e.g. ?class=6B&academic_year=2024 for "who was in 6B in 2024";
+func GetEnrollmentsDbHandler(r *http.Request) (error, []models.Enrollment) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := enrollmentSelect + " WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"student_id":    "e.student_id",
+		"class":         "e.class",
+		"academic_year": "e.academic_year",
+		"status":        "e.status",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	// Enrollments that were running on a given date;
+	if date := r.URL.Query().Get("date"); date != "" {
+		query += " AND (e.start_date IS NULL OR e.start_date <= ?) AND (e.end_date IS NULL OR e.end_date >= ?)"
+		args = append(args, date, date)
+	}
+
+	// Teachers only see the history of the students they currently teach;
+	scope, scopeArgs := studentScopeOn(r.Context(), "s.class")
+	query += scope
+	args = append(args, scopeArgs...)
+
+	query += " ORDER BY e.academic_year, e.class, s.last_name, s.first_name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	return scanEnrollments(rows)
+}
@@ -1,6 +1,7 @@
 package sqlconnect
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
@@ -13,12 +14,11 @@ import (
 	"reflect"
 	"schoolManagement/internal/models"
 	"schoolManagement/pkg/utils"
-	"strconv"
 	"time"
 )
 
 func GetExecsDbHandler(r *http.Request) (error, []models.Exec) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), nil
 	}
@@ -63,7 +63,7 @@ func GetExecsDbHandler(r *http.Request) (error, []models.Exec) {
 }
 
 func AddExecsDbHandler(r *http.Request) (error, []models.Exec) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), nil
 	}
@@ -163,14 +163,18 @@ func AddExecsDbHandler(r *http.Request) (error, []models.Exec) {
 		}
 
 		exec.Id = int(lastId)
+		err = linkExecToTeacher(db, exec.Id, exec.Email)
+		if err != nil {
+			return err, nil
+		}
 	}
 
 	// Returns the final execs array;
 	return nil, execs
 }
 
-func PatchExecsDbHandler(execs []map[string]interface{}) error {
-	db, err := ConnectDb()
+func PatchExecsDbHandler(ctx context.Context, execs []map[string]interface{}) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -253,8 +257,8 @@ func PatchExecsDbHandler(execs []map[string]interface{}) error {
 	return nil
 }
 
-func GetExecsByIdHandler(id int) (error, models.Exec) {
-	db, err := ConnectDb()
+func GetExecsByIdHandler(ctx context.Context, id int) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
 	}
@@ -277,8 +281,8 @@ func GetExecsByIdHandler(id int) (error, models.Exec) {
 	return nil, exec
 }
 
-func PatchExecByIdDbHandler(id int, updatedStudent map[string]interface{}) (error, models.Exec) {
-	db, err := ConnectDb()
+func PatchExecByIdDbHandler(ctx context.Context, id int, updatedStudent map[string]interface{}) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
 	}
@@ -324,8 +328,8 @@ func PatchExecByIdDbHandler(id int, updatedStudent map[string]interface{}) (erro
 	return nil, exec
 }
 
-func DeleteExecByIdDbHandler(id int) error {
-	db, err := ConnectDb()
+func DeleteExecByIdDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -360,47 +364,54 @@ func DeleteExecByIdDbHandler(id int) error {
 	return err
 }
 
-func LoginDbHandler(username string, exec *models.Exec) error {
-	db, err := ConnectDb()
+// LoginDbHandler - Fetches the exec logging in by username; the flag is false when no exec has that username;
+func LoginDbHandler(ctx context.Context, username string, exec *models.Exec) (error, bool) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
-		return utils.HandleError(err, "Err: Internal server error!")
+		return utils.HandleError(err, "Err: Internal server error!"), false
 	}
 
 	defer db.Close()
 
 	err = db.QueryRow("SELECT id, first_name, last_name, email, username, password, inactive_status, role FROM execs WHERE username = ?", username).Scan(&exec.Id, &exec.FirstName, &exec.LastName, &exec.Email, &exec.Username, &exec.Password, &exec.Inactive, &exec.Role)
 	if err != nil {
+		// An unknown username is not an error here, the caller answers it like a wrong password;
 		if errors.Is(err, sql.ErrNoRows) {
-			return utils.HandleError(err, "Err: No records found!!")
+			return nil, false
 		}
-		return utils.HandleError(err, "Err: Cannot get records from db!")
+		return utils.HandleError(err, "Err: Cannot get records from db!"), false
 	}
-	return nil
+	return nil, true
 }
 
-func UpdatePasswordDbHandler(userId int, request models.UpdatePasswordRequest) (error, string) {
-	db, err := ConnectDb()
+// UpdatePasswordDbHandler - Changes the password of an exec; every token and session issued before stops working;
+// The current password is checked unless verifyCurrent is false, for an administrator resetting someone else's;
+// Returns the exec (id, username and role) so that the caller can be logged in again;
+func UpdatePasswordDbHandler(ctx context.Context, userId int, request models.UpdatePasswordRequest, verifyCurrent bool) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
-		return utils.HandleError(err, "Err: Internal server error!"), ""
+		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
 	}
 
 	var username, password, role string
 	err = db.QueryRow("select username, password, role from execs where id = ?", userId).Scan(&username, &password, &role)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return utils.HandleError(err, "Err: No records found!!"), ""
+			return utils.HandleError(err, "Err: No records found!!"), models.Exec{}
 		}
-		return utils.HandleError(err, "Err: Cannot get records from db!"), ""
+		return utils.HandleError(err, "Err: Cannot get records from db!"), models.Exec{}
 	}
 
-	err = utils.PasswordValidate(password, request.CurrentPassword)
-	if err != nil {
-		return utils.HandleError(err, "Err: Current password incorrect!"), ""
+	if verifyCurrent {
+		err = utils.PasswordValidate(password, request.CurrentPassword)
+		if err != nil {
+			return utils.HandleError(err, "Err: Current password incorrect!"), models.Exec{}
+		}
 	}
 
 	hashedPass, err := utils.HashPassword(request.NewPassword)
 	if err != nil {
-		return utils.HandleError(err, "Err: Cannot hash password!"), ""
+		return utils.HandleError(err, "Err: Cannot hash password!"), models.Exec{}
 	}
 
 	currentTime := time.Now().Format(time.RFC3339)
@@ -408,22 +419,22 @@ func UpdatePasswordDbHandler(userId int, request models.UpdatePasswordRequest) (
 	_, err = db.Exec("update execs set password = ?, password_changed_at = ? where id = ?", hashedPass, currentTime, userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return utils.HandleError(err, "Err: No records found!!"), ""
+			return utils.HandleError(err, "Err: No records found!!"), models.Exec{}
 		}
-		return utils.HandleError(err, "Err: Cannot update records from db!"), ""
+		return utils.HandleError(err, "Err: Cannot update records from db!"), models.Exec{}
 	}
 
-	idStr := strconv.Itoa(userId)
-	token, err := utils.SignToken(idStr, username, role)
+	err = revokeExecSessions(db, userId, time.Now().Format(time.DateTime))
 	if err != nil {
-		return utils.HandleError(err, "Err: Cannot sign token!"), ""
+		return err, models.Exec{}
 	}
+	forgetTokenCutoff(ctx, userId)
 
-	return nil, token
+	return nil, models.Exec{Id: userId, Username: username, Role: role}
 }
 
-func ForgotPasswordDbHandler(email string, exec *models.Exec) error {
-	db, err := ConnectDb()
+func ForgotPasswordDbHandler(ctx context.Context, email string, exec *models.Exec) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -446,8 +457,8 @@ func ForgotPasswordDbHandler(email string, exec *models.Exec) error {
 }
 
 // ForgotPasswordUpdateDbHandler - Handler to handle the queries to update the password directly;
-func ForgotPasswordUpdateDbHandler(exec models.Exec, hashedToken string, expiry string) error {
-	db, err := ConnectDb()
+func ForgotPasswordUpdateDbHandler(ctx context.Context, exec models.Exec, hashedToken string, expiry string) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -473,8 +484,8 @@ func ForgotPasswordUpdateDbHandler(exec models.Exec, hashedToken string, expiry
 }
 
 // ResetPasswordDbHandler - Handler to select the user for password reset based on reset link;
-func ResetPasswordDbHandler(token string, exec *models.Exec) error {
-	db, err := ConnectDb()
+func ResetPasswordDbHandler(ctx context.Context, token string, exec *models.Exec) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -509,8 +520,8 @@ func ResetPasswordDbHandler(token string, exec *models.Exec) error {
 }
 
 // ForgetPasswordResetDbHandler - Handles the update query for reset password based on password reset link;
-func ForgetPasswordResetDbHandler(exec *models.Exec, hashedPassword string) error {
-	db, err := ConnectDb()
+func ForgetPasswordResetDbHandler(ctx context.Context, exec *models.Exec, hashedPassword string) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -531,5 +542,42 @@ func ForgetPasswordResetDbHandler(exec *models.Exec, hashedPassword string) erro
 		}
 		return utils.HandleError(err, "Err: Password update failed!")
 	}
+
+	err = revokeExecSessions(db, exec.Id, time.Now().Format(time.DateTime))
+	if err != nil {
+		return err
+	}
+	forgetTokenCutoff(ctx, exec.Id)
+
+	// A password reset also lifts a lockout from failed logins;
+	_, err = db.Exec("DELETE FROM login_attempts WHERE username = (SELECT username FROM execs WHERE id = ?)", exec.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot unlock account!")
+	}
 	return nil
 }
+
+// GetExecContactDbHandler - Fetches the name, email and role of a user, e.g. to notify them;
+func GetExecContactDbHandler(ctx context.Context, id int) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var exec models.Exec
+	err = db.QueryRow("SELECT id, first_name, last_name, email, role FROM execs WHERE id = ?", id).Scan(&exec.Id, &exec.FirstName, &exec.LastName, &exec.Email, &exec.Role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No records found!"), models.Exec{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Exec{}
+	}
+	return nil, exec
+}
@@ -0,0 +1,126 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+)
+
+// GetStudentGuardiansDbHandler - Lists the guardian accounts linked to a student;
+func GetStudentGuardiansDbHandler(ctx context.Context, studentId int) (error, []models.StudentGuardian) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	rows, err := db.Query("SELECT g.student_id, g.exec_id, g.relationship, e.first_name, e.last_name, e.email FROM student_guardians g JOIN execs e ON e.id = g.exec_id WHERE g.student_id = ? ORDER BY e.last_name, e.first_name", studentId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	guardians := []models.StudentGuardian{}
+	for rows.Next() {
+		var guardian models.StudentGuardian
+		err = rows.Scan(&guardian.StudentId, &guardian.ExecId, &guardian.Relationship, &guardian.FirstName, &guardian.LastName, &guardian.Email)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		guardians = append(guardians, guardian)
+	}
+	return nil, guardians
+}
+
+// AddStudentGuardianDbHandler - Links a guardian account to a student; the account must have the guardian role;
+func AddStudentGuardianDbHandler(ctx context.Context, guardian models.StudentGuardian) (error, models.StudentGuardian) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.StudentGuardian{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var role string
+	err = db.QueryRow("SELECT role, first_name, last_name, email FROM execs WHERE id = ?", guardian.ExecId).Scan(&role, &guardian.FirstName, &guardian.LastName, &guardian.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No user found!"), models.StudentGuardian{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.StudentGuardian{}
+	}
+	if role != "guardian" {
+		return utils.HandleError(errors.New("not a guardian"), "Err: The user is not a guardian account!"), models.StudentGuardian{}
+	}
+
+	_, err = db.Exec("INSERT INTO student_guardians (student_id, exec_id, relationship) VALUES (?, ?, ?)", guardian.StudentId, guardian.ExecId, guardian.Relationship)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot link guardian, the student may not exist or is already linked!"), models.StudentGuardian{}
+	}
+
+	return nil, guardian
+}
+
+// DeleteStudentGuardianDbHandler - Unlinks a guardian account from a student;
+func DeleteStudentGuardianDbHandler(ctx context.Context, studentId, execId int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("DELETE FROM student_guardians WHERE student_id = ? AND exec_id = ?", studentId, execId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot unlink guardian!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot unlink guardian!")
+	}
+	if rowsAffected == 0 {
+		return utils.HandleError(errors.New("not found"), "Err: Guardian is not linked to this student!")
+	}
+	return nil
+}
+
+// IsStudentGuardianDbHandler - Whether a guardian account is linked to a student;
+func IsStudentGuardianDbHandler(ctx context.Context, studentId, execId int) (error, bool) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var linked int
+	err = db.QueryRow("SELECT COUNT(*) FROM student_guardians WHERE student_id = ? AND exec_id = ?", studentId, execId).Scan(&linked)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), false
+	}
+	return nil, linked > 0
+}
@@ -0,0 +1,209 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strings"
+	"time"
+)
+
+// maxIdAttempts - Sequence values tried before giving up when generated ids collide with existing ones;
+const maxIdAttempts = 5
+
+// mysqlDuplicateEntry - MySQL error number for a unique key violation;
+const mysqlDuplicateEntry = 1062
+
+// nextSequenceValue - Increments and returns the sequence of a scope in one statement, so it also runs inside a caller's transaction;
+// LAST_INSERT_ID(expr) hands the new value back on this connection only, concurrent callers each get their own;
+func nextSequenceValue(db dbExecutor, scope string) (int, error) {
+	res, err := db.Exec("INSERT INTO id_sequences (scope, last_value) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE last_value = LAST_INSERT_ID(last_value + 1)", scope)
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot generate id!")
+	}
+
+	value, err := res.LastInsertId()
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot generate id!")
+	}
+	return int(value), nil
+}
+
+// assignGeneratedId - Gives a row its printable id unless it already has one and returns the id in use;
+// table and column are fixed by the callers (students.roll_number, teachers.employee_id), never user input;
+func assignGeneratedId(db dbExecutor, table, column string, scheme utils.IdScheme, id int, class string) (string, error) {
+	year := utils.AcademicYear(time.Now())
+	scope := table + ":" + scheme.Scope(year, class)
+
+	for attempt := 0; attempt < maxIdAttempts; attempt++ {
+		seq, err := nextSequenceValue(db, scope)
+		if err != nil {
+			return "", err
+		}
+
+		generated := scheme.Format(year, class, seq)
+		res, err := db.Exec("UPDATE "+table+" SET "+column+" = ? WHERE id = ? AND "+column+" IS NULL", generated, id)
+		if err != nil {
+			// Taken already (e.g. set by hand or by an older scheme), try the next value;
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+				continue
+			}
+			return "", utils.HandleError(err, "Err: Cannot assign id!")
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "", utils.HandleError(err, "Err: Cannot assign id!")
+		}
+		if affected == 1 {
+			return generated, nil
+		}
+
+		// The row already had an id (or does not exist);
+		var existing sql.NullString
+		err = db.QueryRow("SELECT "+column+" FROM "+table+" WHERE id = ?", id).Scan(&existing)
+		if err != nil {
+			return "", utils.HandleError(err, "Err: Cannot assign id!")
+		}
+		return existing.String, nil
+	}
+	return "", utils.HandleError(errors.New("id space exhausted for "+scope), "Err: Cannot generate a unique id!")
+}
+
+// assignRollNumber - Generates the roll number of a student with STUDENT_ID_FORMAT;
+func assignRollNumber(db dbExecutor, studentId int, class string) (string, error) {
+	scheme := utils.IdSchemeFromEnv("STUDENT_ID_FORMAT", utils.DefaultStudentIdFormat)
+	return assignGeneratedId(db, "students", "roll_number", scheme, studentId, class)
+}
+
+// assignEmployeeId - Generates the employee id of a teacher with STAFF_ID_FORMAT;
+func assignEmployeeId(db dbExecutor, teacherId int, class string) (string, error) {
+	scheme := utils.IdSchemeFromEnv("STAFF_ID_FORMAT", utils.DefaultStaffIdFormat)
+	return assignGeneratedId(db, "teachers", "employee_id", scheme, teacherId, class)
+}
+
+// GetIdCardsDbHandler - Collects the card details of one student, one teacher or every active student of a class;
+// Read only: people without a printable id yet are refused, AssignMissingIdsDbHandler gives them one;
+func GetIdCardsDbHandler(ctx context.Context, kind string, id int, class string) (error, []models.IdCard) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var query string
+	var args []interface{}
+	if kind == "teacher" {
+		query = "SELECT t.id, t.employee_id, t.first_name, t.last_name, t.class, t.subject, p.storage_prefix FROM teachers t LEFT JOIN profile_photos p ON p.owner_type = 'teacher' AND p.owner_id = t.id WHERE t.id = ?"
+		args = append(args, id)
+	} else {
+		query = "SELECT s.id, s.roll_number, s.first_name, s.last_name, s.class, '', p.storage_prefix FROM students s LEFT JOIN profile_photos p ON p.owner_type = 'student' AND p.owner_id = s.id WHERE "
+		if id != 0 {
+			query += "s.id = ?"
+			args = append(args, id)
+		} else {
+			query += "s.class = ? AND s.inactive_status = 0 ORDER BY s.last_name, s.first_name"
+			args = append(args, class)
+		}
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	cards := []models.IdCard{}
+	for rows.Next() {
+		card := models.IdCard{Kind: kind}
+		var number, photoPrefix sql.NullString
+		err = rows.Scan(&card.Id, &number, &card.FirstName, &card.LastName, &card.Class, &card.Subject, &photoPrefix)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		card.Number = number.String
+		card.PhotoPrefix = photoPrefix.String
+		cards = append(cards, card)
+	}
+	rows.Close()
+
+	if len(cards) == 0 {
+		return utils.HandleError(sql.ErrNoRows, "Err: No records found!"), nil
+	}
+
+	missing := 0
+	for _, card := range cards {
+		if strings.TrimSpace(card.Number) == "" {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return utils.HandleError(errors.New("missing printable ids"), fmt.Sprintf("Err: %d of the cards have no printable id yet, assign the missing ids first!", missing)), nil
+	}
+	return nil, cards
+}
+
+// AssignMissingIdsDbHandler - Gives every student (roll number) or teacher (employee id) created without one its printable id;
+// Returns how many were assigned;
+func AssignMissingIdsDbHandler(ctx context.Context, kind string) (error, int) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), 0
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT id, COALESCE(class, '') FROM students WHERE roll_number IS NULL ORDER BY id"
+	if kind == "teacher" {
+		query = "SELECT id, COALESCE(class, '') FROM teachers WHERE employee_id IS NULL ORDER BY id"
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), 0
+	}
+
+	type pending struct {
+		id    int
+		class string
+	}
+	var people []pending
+	for rows.Next() {
+		var person pending
+		err = rows.Scan(&person.id, &person.class)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), 0
+		}
+		people = append(people, person)
+	}
+	rows.Close()
+
+	for i, person := range people {
+		if kind == "teacher" {
+			_, err = assignEmployeeId(db, person.id, person.class)
+		} else {
+			_, err = assignRollNumber(db, person.id, person.class)
+		}
+		if err != nil {
+			return err, i
+		}
+	}
+	return nil, len(people)
+}
@@ -0,0 +1,483 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+const incidentColumns = "i.id, i.incident_date, i.reporting_teacher_id, i.reported_by, i.category, i.severity, i.description, i.actions_taken, i.follow_up_status, i.created_at"
+
+// scanIncident - Scans a single incident row selected with incidentColumns;
+func scanIncident(scanner interface{ Scan(...interface{}) error }, incident *models.Incident) error {
+	return scanner.Scan(&incident.Id, &incident.IncidentDate, &incident.ReportingTeacherId, &incident.ReportedBy, &incident.Category, &incident.Severity, &incident.Description, &incident.ActionsTaken, &incident.FollowUpStatus, &incident.CreatedAt)
+}
+
+// getIncidentStudentIds - Returns the ids of the students involved in an incident;
+func getIncidentStudentIds(db *sql.DB, incidentId int) (error, []int) {
+	rows, err := db.Query("SELECT student_id FROM incident_students WHERE incident_id = ? ORDER BY student_id", incidentId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		ids = append(ids, id)
+	}
+	return nil, ids
+}
+
+// ValidateIncident - Checks the mandatory fields and the allowed values of an incident;
+func ValidateIncident(incident models.Incident) error {
+	if incident.Category == "" || incident.Description == "" || len(incident.StudentIds) == 0 {
+		return utils.HandleError(errors.New("missing fields"), "Err: Category, description and students are required!")
+	}
+
+	_, err := time.Parse(time.DateOnly, incident.IncidentDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Incident date must be in YYYY-MM-DD format!")
+	}
+
+	if !isAllowedValue(incident.Severity, models.IncidentSeverities) {
+		return utils.HandleError(errors.New("invalid severity"), "Err: Invalid severity!")
+	}
+
+	if !isAllowedValue(incident.FollowUpStatus, models.IncidentFollowUpStatuses) {
+		return utils.HandleError(errors.New("invalid follow up status"), "Err: Invalid follow-up status!")
+	}
+	return nil
+}
+
+// isAllowedValue - Checks whether a value is part of a list of allowed values;
+func isAllowedValue(value string, allowed []string) bool {
+	for _, v := range allowed {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIncidentsDbHandler - Fetches incidents; reportedBy > 0 restricts the list to incidents filed by that user;
+func GetIncidentsDbHandler(r *http.Request, reportedBy int) (error, []models.Incident) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + incidentColumns + " FROM incidents i WHERE 1=1"
+	var args []interface{}
+
+	if reportedBy > 0 {
+		query += " AND i.reported_by = ?"
+		args = append(args, reportedBy)
+	}
+
+	params := map[string]string{
+		"category":         "i.category",
+		"severity":         "i.severity",
+		"follow_up_status": "i.follow_up_status",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		query += " AND i.incident_date >= ?"
+		args = append(args, from)
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		query += " AND i.incident_date <= ?"
+		args = append(args, to)
+	}
+	if studentId := r.URL.Query().Get("student_id"); studentId != "" {
+		query += " AND i.id IN (SELECT incident_id FROM incident_students WHERE student_id = ?)"
+		args = append(args, studentId)
+	}
+	if class := r.URL.Query().Get("class"); class != "" {
+		query += " AND i.id IN (SELECT s.incident_id FROM incident_students s JOIN students st ON st.id = s.student_id WHERE st.class = ?)"
+		args = append(args, class)
+	}
+
+	query += " ORDER BY i.incident_date DESC, i.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var incidents []models.Incident
+	for rows.Next() {
+		var incident models.Incident
+		err = scanIncident(rows, &incident)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		incidents = append(incidents, incident)
+	}
+
+	for i := range incidents {
+		err, incidents[i].StudentIds = getIncidentStudentIds(db, incidents[i].Id)
+		if err != nil {
+			return err, nil
+		}
+	}
+
+	return nil, incidents
+}
+
+// GetIncidentDbHandler - Fetches a single incident; reportedBy > 0 hides incidents filed by other users;
+func GetIncidentDbHandler(ctx context.Context, id, reportedBy int) (error, models.Incident) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Incident{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + incidentColumns + " FROM incidents i WHERE i.id = ?"
+	args := []interface{}{id}
+	if reportedBy > 0 {
+		query += " AND i.reported_by = ?"
+		args = append(args, reportedBy)
+	}
+
+	var incident models.Incident
+	err = scanIncident(db.QueryRow(query, args...), &incident)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No records found!"), models.Incident{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Incident{}
+	}
+
+	err, incident.StudentIds = getIncidentStudentIds(db, incident.Id)
+	if err != nil {
+		return err, models.Incident{}
+	}
+	return nil, incident
+}
+
+// AddIncidentDbHandler - Stores a new incident together with the students involved;
+func AddIncidentDbHandler(ctx context.Context, incident models.Incident) (error, models.Incident) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Incident{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Incident{}
+	}
+
+	res, err := tx.Exec("INSERT INTO incidents (incident_date, reporting_teacher_id, reported_by, category, severity, description, actions_taken, follow_up_status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		incident.IncidentDate, incident.ReportingTeacherId, incident.ReportedBy, incident.Category, incident.Severity, incident.Description, incident.ActionsTaken, incident.FollowUpStatus)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add incident to database!"), models.Incident{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add incident to database!"), models.Incident{}
+	}
+	incident.Id = int(lastId)
+
+	err = insertIncidentStudents(tx, incident.Id, incident.StudentIds)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Incident{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Incident{}
+	}
+
+	return nil, incident
+}
+
+// insertIncidentStudents - Links the given students to an incident inside a transaction;
+func insertIncidentStudents(tx *sql.Tx, incidentId int, studentIds []int) error {
+	statement, err := tx.Prepare("INSERT INTO incident_students (incident_id, student_id) VALUES (?, ?)")
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot prepare statement!")
+	}
+	defer statement.Close()
+
+	seen := make(map[int]bool)
+	for _, studentId := range studentIds {
+		if seen[studentId] {
+			continue
+		}
+		seen[studentId] = true
+
+		_, err = statement.Exec(incidentId, studentId)
+		if err != nil {
+			return utils.HandleError(err, fmt.Sprintf("Err: Cannot link student %d to incident!", studentId))
+		}
+	}
+	return nil
+}
+
+// PatchIncidentDbHandler - Partially updates an incident; reportedBy > 0 only allows updating own incidents;
+func PatchIncidentDbHandler(ctx context.Context, id, reportedBy int, updates map[string]interface{}) (error, models.Incident) {
+	err, incident := GetIncidentDbHandler(ctx, id, reportedBy)
+	if err != nil {
+		return err, models.Incident{}
+	}
+
+	incidentVal := reflect.ValueOf(&incident).Elem()
+	incidentType := incidentVal.Type()
+
+	for k, v := range updates {
+		switch k {
+		case "id", "reported_by", "created_at":
+			continue // These fields are managed by the server;
+		case "student_ids":
+			rawIds, ok := v.([]interface{})
+			if !ok {
+				return utils.HandleError(errors.New("invalid student ids"), "Err: Invalid student ids!"), models.Incident{}
+			}
+			incident.StudentIds = []int{}
+			for _, rawId := range rawIds {
+				studentId, ok := rawId.(float64)
+				if !ok {
+					return utils.HandleError(errors.New("invalid student id"), "Err: Invalid student ids!"), models.Incident{}
+				}
+				incident.StudentIds = append(incident.StudentIds, int(studentId))
+			}
+			continue
+		}
+
+		for i := 0; i < incidentVal.NumField(); i++ {
+			field := incidentType.Field(i)
+			if field.Tag.Get("json") == k+",omitempty" {
+				fieldVal := incidentVal.Field(i)
+				val := reflect.ValueOf(v)
+				if !fieldVal.CanSet() || !val.IsValid() || !val.Type().ConvertibleTo(fieldVal.Type()) {
+					return utils.HandleError(errors.New("invalid field value"), "Err: Invalid value for "+k+"!"), models.Incident{}
+				}
+				fieldVal.Set(val.Convert(fieldVal.Type()))
+			}
+		}
+	}
+
+	err = ValidateIncident(incident)
+	if err != nil {
+		return err, models.Incident{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Incident{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Incident{}
+	}
+
+	_, err = tx.Exec("UPDATE incidents SET incident_date = ?, reporting_teacher_id = ?, category = ?, severity = ?, description = ?, actions_taken = ?, follow_up_status = ? WHERE id = ?",
+		incident.IncidentDate, incident.ReportingTeacherId, incident.Category, incident.Severity, incident.Description, incident.ActionsTaken, incident.FollowUpStatus, incident.Id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update incident in db!"), models.Incident{}
+	}
+
+	if _, ok := updates["student_ids"]; ok {
+		_, err = tx.Exec("DELETE FROM incident_students WHERE incident_id = ?", incident.Id)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update incident in db!"), models.Incident{}
+		}
+
+		err = insertIncidentStudents(tx, incident.Id, incident.StudentIds)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Incident{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Incident{}
+	}
+
+	return nil, incident
+}
+
+// DeleteIncidentDbHandler - Deletes an incident and its student links;
+func DeleteIncidentDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!")
+	}
+
+	_, err = tx.Exec("DELETE FROM incident_students WHERE incident_id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot delete incident from db!")
+	}
+
+	res, err := tx.Exec("DELETE FROM incidents WHERE id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot delete incident from db!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: No incident found!")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
+
+// GetStudentIncidentSummaryDbHandler - Aggregates the incidents a student was involved in;
+func GetStudentIncidentSummaryDbHandler(ctx context.Context, studentId, reportedBy int) (error, models.IncidentSummary) {
+	query := "SELECT DISTINCT i.id, i.category, i.severity, i.follow_up_status, i.incident_date FROM incidents i JOIN incident_students s ON s.incident_id = i.id JOIN students st ON st.id = s.student_id WHERE s.student_id = ?"
+	args := []interface{}{studentId}
+	scope, scopeArgs := studentScopeOn(ctx, "st.class")
+	query += scope
+	args = append(args, scopeArgs...)
+	if reportedBy > 0 {
+		query += " AND i.reported_by = ?"
+		args = append(args, reportedBy)
+	}
+
+	err, summary := getIncidentSummary(ctx, query, args)
+	summary.StudentId = studentId
+	return err, summary
+}
+
+// GetClassIncidentSummaryDbHandler - Aggregates the incidents involving students of a class;
+func GetClassIncidentSummaryDbHandler(ctx context.Context, class string, reportedBy int) (error, models.IncidentSummary) {
+	query := "SELECT DISTINCT i.id, i.category, i.severity, i.follow_up_status, i.incident_date FROM incidents i JOIN incident_students s ON s.incident_id = i.id JOIN students st ON st.id = s.student_id WHERE st.class = ?"
+	args := []interface{}{class}
+	scope, scopeArgs := studentScopeOn(ctx, "st.class")
+	query += scope
+	args = append(args, scopeArgs...)
+	if reportedBy > 0 {
+		query += " AND i.reported_by = ?"
+		args = append(args, reportedBy)
+	}
+
+	err, summary := getIncidentSummary(ctx, query, args)
+	summary.Class = class
+	return err, summary
+}
+
+// getIncidentSummary - Runs a summary query (id, category, severity, status, date) and counts the rows;
+func getIncidentSummary(ctx context.Context, query string, args []interface{}) (error, models.IncidentSummary) {
+	summary := models.IncidentSummary{
+		ByCategory: make(map[string]int),
+		BySeverity: make(map[string]int),
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), summary
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), summary
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var category, severity, status, date string
+		err = rows.Scan(&id, &category, &severity, &status, &date)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), summary
+		}
+
+		summary.Total++
+		summary.ByCategory[category]++
+		summary.BySeverity[severity]++
+		if status != "resolved" && status != "closed" {
+			summary.Open++
+		}
+		if date > summary.LastIncident {
+			summary.LastIncident = date
+		}
+	}
+
+	return nil, summary
+}
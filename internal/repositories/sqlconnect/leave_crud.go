@@ -0,0 +1,305 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// maxLeaveDays - Longest leave that can be requested in one go;
+const maxLeaveDays = 90
+
+const leaveColumns = "l.id, l.teacher_id, l.start_date, l.end_date, l.reason, l.status, l.requested_by, l.decided_by, l.decided_at, l.decision_note, l.created_at"
+
+// scanLeaveRequest - Scans a single leave row selected with leaveColumns;
+func scanLeaveRequest(scanner interface{ Scan(...interface{}) error }, leave *models.LeaveRequest) error {
+	var decidedBy sql.NullInt64
+	var decidedAt sql.NullString
+	err := scanner.Scan(&leave.Id, &leave.TeacherId, &leave.StartDate, &leave.EndDate, &leave.Reason, &leave.Status, &leave.RequestedBy, &decidedBy, &decidedAt, &leave.DecisionNote, &leave.CreatedAt)
+	if err != nil {
+		return err
+	}
+	leave.DecidedBy = int(decidedBy.Int64)
+	leave.DecidedAt = decidedAt.String
+	return nil
+}
+
+// ValidateLeaveRequest - Checks the dates and the mandatory fields of a leave request;
+func ValidateLeaveRequest(leave models.LeaveRequest) error {
+	if leave.TeacherId == 0 || leave.Reason == "" {
+		return utils.HandleError(errors.New("missing fields"), "Err: Teacher and reason are required!")
+	}
+
+	start, err := time.Parse(time.DateOnly, leave.StartDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Start date must be in YYYY-MM-DD format!")
+	}
+	end, err := time.Parse(time.DateOnly, leave.EndDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: End date must be in YYYY-MM-DD format!")
+	}
+
+	if end.Before(start) {
+		return utils.HandleError(errors.New("invalid range"), "Err: End date cannot be before the start date!")
+	}
+	if end.Sub(start) >= maxLeaveDays*24*time.Hour {
+		return utils.HandleError(errors.New("leave too long"), "Err: A leave request cannot span more than 90 days!")
+	}
+	return nil
+}
+
+// AddLeaveRequestDbHandler - Files a pending leave request; overlapping pending/approved leaves are rejected;
+func AddLeaveRequestDbHandler(ctx context.Context, leave models.LeaveRequest) (error, models.LeaveRequest) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LeaveRequest{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var overlapping int
+	err = db.QueryRow("SELECT COUNT(*) FROM leave_requests WHERE teacher_id = ? AND status IN ('pending', 'approved') AND start_date <= ? AND end_date >= ?",
+		leave.TeacherId, leave.EndDate, leave.StartDate).Scan(&overlapping)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.LeaveRequest{}
+	}
+	if overlapping > 0 {
+		return utils.HandleError(errors.New("overlapping leave"), "Err: The teacher already has a leave request for these dates!"), models.LeaveRequest{}
+	}
+
+	leave.Status = "pending"
+	leave.CreatedAt = time.Now().Format(time.DateTime)
+
+	res, err := db.Exec("INSERT INTO leave_requests (teacher_id, start_date, end_date, reason, status, requested_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		leave.TeacherId, leave.StartDate, leave.EndDate, leave.Reason, leave.Status, leave.RequestedBy, leave.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add leave request!"), models.LeaveRequest{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add leave request!"), models.LeaveRequest{}
+	}
+	leave.Id = int(lastId)
+
+	return nil, leave
+}
+
+// GetLeaveRequestsDbHandler - Fetches leave requests; teacherId > 0 restricts the list to that teacher;
+func GetLeaveRequestsDbHandler(r *http.Request, teacherId int) (error, []models.LeaveRequest) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + leaveColumns + " FROM leave_requests l WHERE 1=1"
+	var args []interface{}
+
+	if teacherId > 0 {
+		query += " AND l.teacher_id = ?"
+		args = append(args, teacherId)
+	} else if value := r.URL.Query().Get("teacher_id"); value != "" {
+		query += " AND l.teacher_id = ?"
+		args = append(args, value)
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " AND l.status = ?"
+		args = append(args, status)
+	}
+
+	// Leaves that cover a given date;
+	if date := r.URL.Query().Get("date"); date != "" {
+		query += " AND l.start_date <= ? AND l.end_date >= ?"
+		args = append(args, date, date)
+	}
+
+	query += " ORDER BY l.start_date DESC, l.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	leaves := []models.LeaveRequest{}
+	for rows.Next() {
+		var leave models.LeaveRequest
+		err = scanLeaveRequest(rows, &leave)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		leaves = append(leaves, leave)
+	}
+	return nil, leaves
+}
+
+// getLeaveRequest - Fetches a single leave request, optionally locking it;
+func getLeaveRequest(db dbExecutor, id int, forUpdate bool) (error, models.LeaveRequest) {
+	query := "SELECT " + leaveColumns + " FROM leave_requests l WHERE l.id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var leave models.LeaveRequest
+	err := scanLeaveRequest(db.QueryRow(query, id), &leave)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No leave request found!"), models.LeaveRequest{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LeaveRequest{}
+	}
+	return nil, leave
+}
+
+// GetLeaveRequestDbHandler - Fetches a single leave request; teacherId > 0 hides other teachers' requests;
+func GetLeaveRequestDbHandler(ctx context.Context, id, teacherId int) (error, models.LeaveRequest) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LeaveRequest{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, leave := getLeaveRequest(db, id, false)
+	if err != nil {
+		return err, models.LeaveRequest{}
+	}
+	if teacherId > 0 && leave.TeacherId != teacherId {
+		return utils.HandleError(errors.New("not owner"), "Err: No leave request found!"), models.LeaveRequest{}
+	}
+	return nil, leave
+}
+
+// DecideLeaveRequestDbHandler - Approves or rejects a pending leave request;
+func DecideLeaveRequestDbHandler(ctx context.Context, id int, status, note string, decidedBy int) (error, models.LeaveRequest) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LeaveRequest{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.LeaveRequest{}
+	}
+
+	err, leave := getLeaveRequest(tx, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err, models.LeaveRequest{}
+	}
+
+	if leave.Status != "pending" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("already decided"), "Err: Only pending leave requests can be approved or rejected!"), models.LeaveRequest{}
+	}
+
+	leave.Status = status
+	leave.DecidedBy = decidedBy
+	leave.DecidedAt = time.Now().Format(time.DateTime)
+	leave.DecisionNote = note
+
+	_, err = tx.Exec("UPDATE leave_requests SET status = ?, decided_by = ?, decided_at = ?, decision_note = ? WHERE id = ?",
+		leave.Status, leave.DecidedBy, leave.DecidedAt, leave.DecisionNote, leave.Id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update leave request!"), models.LeaveRequest{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.LeaveRequest{}
+	}
+
+	return nil, leave
+}
+
+// GetLeaveCoverDbHandler - Lists every timetable period affected by a leave with its substitute or suggestions;
+func GetLeaveCoverDbHandler(ctx context.Context, id, teacherId int) (error, []models.CoverPeriod) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, leave := getLeaveRequest(db, id, false)
+	if err != nil {
+		return err, nil
+	}
+	if teacherId > 0 && leave.TeacherId != teacherId {
+		return utils.HandleError(errors.New("not owner"), "Err: No leave request found!"), nil
+	}
+
+	rows, err := db.Query("SELECT "+timetableSlotColumns+" FROM timetable_slots ts WHERE ts.teacher_id = ? ORDER BY ts.period", leave.TeacherId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	slotsByDay := map[int][]models.TimetableSlot{}
+	for rows.Next() {
+		var slot models.TimetableSlot
+		err = scanTimetableSlot(rows, &slot)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		slotsByDay[slot.DayOfWeek] = append(slotsByDay[slot.DayOfWeek], slot)
+	}
+	rows.Close()
+
+	start, err := time.Parse(time.DateOnly, leave.StartDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Invalid leave dates!"), nil
+	}
+	end, err := time.Parse(time.DateOnly, leave.EndDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Invalid leave dates!"), nil
+	}
+
+	periods := []models.CoverPeriod{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		for _, slot := range slotsByDay[isoWeekday(day)] {
+			err, period := coverPeriod(db, leave.Id, slot, date)
+			if err != nil {
+				return err, nil
+			}
+			periods = append(periods, period)
+		}
+	}
+	return nil, periods
+}
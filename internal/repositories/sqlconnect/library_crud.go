@@ -0,0 +1,1212 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envInt - Reads a non-negative integer setting, falling back when unset or invalid;
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// libraryLoanDays - Loan period of a borrower type (LIBRARY_LOAN_DAYS_STUDENT / _TEACHER);
+func libraryLoanDays(borrowerType string) int {
+	if borrowerType == "teacher" {
+		return max(1, envInt("LIBRARY_LOAN_DAYS_TEACHER", 28))
+	}
+	return max(1, envInt("LIBRARY_LOAN_DAYS_STUDENT", 14))
+}
+
+// libraryMaxLoans - Copies a borrower may have out at once (LIBRARY_MAX_LOANS_STUDENT / _TEACHER);
+func libraryMaxLoans(borrowerType string) int {
+	if borrowerType == "teacher" {
+		return envInt("LIBRARY_MAX_LOANS_TEACHER", 10)
+	}
+	return envInt("LIBRARY_MAX_LOANS_STUDENT", 3)
+}
+
+// libraryFine - Days overdue and the fine (LIBRARY_FINE_PER_DAY cents, capped at LIBRARY_MAX_FINE) of a loan returned on the given day;
+func libraryFine(dueDate string, returned time.Time) (int, int) {
+	due, err := time.Parse(time.DateOnly, dueDate)
+	if err != nil {
+		return 0, 0
+	}
+
+	// Whole calendar days, independent of the time of day and DST;
+	day := time.Date(returned.Year(), returned.Month(), returned.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(day.Sub(due).Hours() / 24)
+	if days <= 0 {
+		return 0, 0
+	}
+	return days, min(days*envInt("LIBRARY_FINE_PER_DAY", 10), envInt("LIBRARY_MAX_FINE", 1000))
+}
+
+const bookColumns = "b.id, b.isbn, b.title, b.author, b.publisher, b.published_year, b.subject, b.created_at, " +
+	"(SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status <> 'withdrawn'), " +
+	"(SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available'), " +
+	"(SELECT COUNT(*) FROM book_holds h WHERE h.book_id = b.id AND h.status = 'waiting')"
+
+func scanBook(scanner interface{ Scan(...interface{}) error }, book *models.Book) error {
+	var year sql.NullInt64
+	err := scanner.Scan(&book.Id, &book.Isbn, &book.Title, &book.Author, &book.Publisher, &year, &book.Subject, &book.CreatedAt,
+		&book.TotalCopies, &book.AvailableCopies, &book.HoldsWaiting)
+	if err != nil {
+		return err
+	}
+	book.PublishedYear = int(year.Int64)
+	return nil
+}
+
+const loanColumns = "l.id, l.copy_id, c.barcode, b.id, b.title, b.isbn, l.borrower_type, l.borrower_id, " +
+	"COALESCE(s.first_name, t.first_name, ''), COALESCE(s.last_name, t.last_name, ''), COALESCE(s.class, ''), " +
+	"l.checked_out_at, l.checked_out_by, l.due_date, l.renewals, l.returned_at, l.fine_cents"
+
+const loanTables = " FROM book_loans l JOIN book_copies c ON c.id = l.copy_id JOIN books b ON b.id = c.book_id " +
+	"LEFT JOIN students s ON l.borrower_type = 'student' AND s.id = l.borrower_id " +
+	"LEFT JOIN teachers t ON l.borrower_type = 'teacher' AND t.id = l.borrower_id"
+
+// scanLoan - Scans a loan selected with loanColumns; open loans get the fine due if returned today;
+func scanLoan(scanner interface{ Scan(...interface{}) error }, loan *models.LibraryLoan) error {
+	var returnedAt sql.NullString
+	err := scanner.Scan(&loan.Id, &loan.CopyId, &loan.Barcode, &loan.BookId, &loan.Title, &loan.Isbn, &loan.BorrowerType, &loan.BorrowerId,
+		&loan.FirstName, &loan.LastName, &loan.Class, &loan.CheckedOutAt, &loan.CheckedOutBy, &loan.DueDate, &loan.Renewals, &returnedAt, &loan.FineCents)
+	if err != nil {
+		return err
+	}
+
+	loan.ReturnedAt = returnedAt.String
+	if returnedAt.Valid {
+		returned, err := time.ParseInLocation(time.DateTime, loan.ReturnedAt, time.Local)
+		if err == nil {
+			loan.DaysOverdue, _ = libraryFine(loan.DueDate, returned)
+		}
+	} else {
+		loan.DaysOverdue, loan.FineCents = libraryFine(loan.DueDate, time.Now())
+	}
+	return nil
+}
+
+const holdColumns = "h.id, h.book_id, b.title, h.borrower_type, h.borrower_id, " +
+	"COALESCE(s.first_name, t.first_name, ''), COALESCE(s.last_name, t.last_name, ''), h.status, h.copy_id, h.created_at, h.ready_at, h.expires_at, " +
+	"IF(h.status = 'waiting', (SELECT COUNT(*) FROM book_holds q WHERE q.book_id = h.book_id AND q.status = 'waiting' AND (q.created_at < h.created_at OR (q.created_at = h.created_at AND q.id <= h.id))), 0)"
+
+const holdTables = " FROM book_holds h JOIN books b ON b.id = h.book_id " +
+	"LEFT JOIN students s ON h.borrower_type = 'student' AND s.id = h.borrower_id " +
+	"LEFT JOIN teachers t ON h.borrower_type = 'teacher' AND t.id = h.borrower_id"
+
+func scanHold(scanner interface{ Scan(...interface{}) error }, hold *models.BookHold) error {
+	var copyId sql.NullInt64
+	var readyAt, expiresAt sql.NullString
+	err := scanner.Scan(&hold.Id, &hold.BookId, &hold.Title, &hold.BorrowerType, &hold.BorrowerId, &hold.FirstName, &hold.LastName,
+		&hold.Status, &copyId, &hold.CreatedAt, &readyAt, &expiresAt, &hold.Position)
+	if err != nil {
+		return err
+	}
+	hold.CopyId = int(copyId.Int64)
+	hold.ReadyAt = readyAt.String
+	hold.ExpiresAt = expiresAt.String
+	return nil
+}
+
+// ValidateBook - Checks the ISBN, title and author of a catalogue entry;
+func ValidateBook(book models.Book) error {
+	_, err := utils.NormalizeISBN(book.Isbn)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(book.Title) == "" || len(book.Title) > 255 {
+		return utils.HandleError(errors.New("invalid title"), "Err: Title must be between 1 and 255 characters!")
+	}
+	if strings.TrimSpace(book.Author) == "" || len(book.Author) > 255 {
+		return utils.HandleError(errors.New("invalid author"), "Err: Author must be between 1 and 255 characters!")
+	}
+	if book.PublishedYear < 0 || book.PublishedYear > time.Now().Year()+1 {
+		return utils.HandleError(errors.New("invalid year"), "Err: Invalid publication year!")
+	}
+	return nil
+}
+
+// ValidateBorrower - Checks the borrower type of a checkout or hold;
+func ValidateBorrower(borrower models.Borrower) error {
+	if !isAllowedValue(borrower.BorrowerType, models.BorrowerTypes) || borrower.BorrowerId <= 0 {
+		return utils.HandleError(errors.New("invalid borrower"), "Err: Borrower must be a student or a teacher with an id!")
+	}
+	return nil
+}
+
+// checkBorrower - The borrower must exist, and students must still be enrolled;
+func checkBorrower(db dbExecutor, borrower models.Borrower) error {
+	err := documentOwnerExists(db, borrower.BorrowerType, borrower.BorrowerId)
+	if err != nil {
+		return err
+	}
+
+	if borrower.BorrowerType == "student" {
+		var inactive bool
+		err = db.QueryRow("SELECT inactive_status FROM students WHERE id = ?", borrower.BorrowerId).Scan(&inactive)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		if inactive {
+			return utils.HandleError(errors.New("inactive student"), "Err: The student is no longer enrolled!")
+		}
+	}
+	return nil
+}
+
+func getBook(db dbExecutor, id int) (error, models.Book) {
+	var book models.Book
+	err := scanBook(db.QueryRow("SELECT "+bookColumns+" FROM books b WHERE b.id = ?", id), &book)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No book found!"), models.Book{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Book{}
+	}
+
+	rows, err := db.Query("SELECT id, book_id, barcode, location, status, acquired_at FROM book_copies WHERE book_id = ? ORDER BY barcode", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Book{}
+	}
+	defer rows.Close()
+
+	book.Copies = []models.BookCopy{}
+	for rows.Next() {
+		var copy models.BookCopy
+		err = rows.Scan(&copy.Id, &copy.BookId, &copy.Barcode, &copy.Location, &copy.Status, &copy.AcquiredAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.Book{}
+		}
+		book.Copies = append(book.Copies, copy)
+	}
+	return nil, book
+}
+
+// AddBookDbHandler - Adds a catalogue entry; the ISBN is stored as ISBN-13;
+func AddBookDbHandler(ctx context.Context, book models.Book) (error, models.Book) {
+	isbn, err := utils.NormalizeISBN(book.Isbn)
+	if err != nil {
+		return err, models.Book{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Book{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("INSERT INTO books (isbn, title, author, publisher, published_year, subject, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		isbn, book.Title, book.Author, book.Publisher, sql.NullInt64{Int64: int64(book.PublishedYear), Valid: book.PublishedYear != 0}, book.Subject, time.Now().Format(time.DateTime))
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add book, the ISBN may already be catalogued!"), models.Book{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add book!"), models.Book{}
+	}
+	return getBook(db, int(lastId))
+}
+
+// GetBooksDbHandler - Searches the catalogue by ?q= (title or author), isbn, author or subject;
+func GetBooksDbHandler(r *http.Request) (error, []models.Book) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + bookColumns + " FROM books b WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"author":  "b.author",
+		"subject": "b.subject",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	if isbn := r.URL.Query().Get("isbn"); isbn != "" {
+		normalized, err := utils.NormalizeISBN(isbn)
+		if err != nil {
+			return err, nil
+		}
+		query += " AND b.isbn = ?"
+		args = append(args, normalized)
+	}
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		pattern := "%" + strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(q) + "%"
+		query += " AND (b.title LIKE ? OR b.author LIKE ?)"
+		args = append(args, pattern, pattern)
+	}
+	query += " ORDER BY b.title, b.author"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	books := []models.Book{}
+	for rows.Next() {
+		var book models.Book
+		err = scanBook(rows, &book)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		books = append(books, book)
+	}
+	return nil, books
+}
+
+// GetBookDbHandler - Fetches a catalogue entry with its copies;
+func GetBookDbHandler(ctx context.Context, id int) (error, models.Book) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Book{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getBook(db, id)
+}
+
+// PatchBookDbHandler - Updates isbn, title, author, publisher, published_year or subject of a book;
+func PatchBookDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Book) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Book{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, book := getBook(db, id)
+	if err != nil {
+		return err, models.Book{}
+	}
+
+	for k, v := range updates {
+		if k == "published_year" {
+			value, ok := v.(float64)
+			if !ok || value != math.Trunc(value) {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for published_year!"), models.Book{}
+			}
+			book.PublishedYear = int(value)
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Book{}
+		}
+		switch k {
+		case "isbn":
+			book.Isbn = value
+		case "title":
+			book.Title = value
+		case "author":
+			book.Author = value
+		case "publisher":
+			book.Publisher = value
+		case "subject":
+			book.Subject = value
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Book{}
+		}
+	}
+
+	err = ValidateBook(book)
+	if err != nil {
+		return err, models.Book{}
+	}
+	book.Isbn, _ = utils.NormalizeISBN(book.Isbn)
+
+	_, err = db.Exec("UPDATE books SET isbn = ?, title = ?, author = ?, publisher = ?, published_year = ?, subject = ? WHERE id = ?",
+		book.Isbn, book.Title, book.Author, book.Publisher, sql.NullInt64{Int64: int64(book.PublishedYear), Valid: book.PublishedYear != 0}, book.Subject, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update book, the ISBN may already be catalogued!"), models.Book{}
+	}
+	return getBook(db, id)
+}
+
+// DeleteBookDbHandler - Removes a book with its copies and history; refused while copies are out on loan;
+func DeleteBookDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var onLoan int
+	err = db.QueryRow("SELECT COUNT(*) FROM book_copies WHERE book_id = ? AND status = 'on_loan'", id).Scan(&onLoan)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if onLoan > 0 {
+		return utils.HandleError(errors.New("copies on loan"), "Err: Copies of this book are still on loan!")
+	}
+
+	res, err := db.Exec("DELETE FROM books WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete book!")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete book!")
+	}
+	if affected == 0 {
+		return utils.HandleError(sql.ErrNoRows, "Err: No book found!")
+	}
+	return nil
+}
+
+// AddBookCopyDbHandler - Adds a copy of a book with its barcode and shelf location; it goes to the holds queue first;
+func AddBookCopyDbHandler(ctx context.Context, copy models.BookCopy) (error, models.BookCopy) {
+	if strings.TrimSpace(copy.Barcode) == "" || len(copy.Barcode) > 64 {
+		return utils.HandleError(errors.New("invalid barcode"), "Err: Barcode must be between 1 and 64 characters!"), models.BookCopy{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.BookCopy{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.BookCopy{}
+	}
+
+	copy.Barcode = strings.TrimSpace(copy.Barcode)
+	copy.Status = "available"
+	copy.AcquiredAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO book_copies (book_id, barcode, location, status, acquired_at) VALUES (?, ?, ?, ?, ?)",
+		copy.BookId, copy.Barcode, copy.Location, copy.Status, copy.AcquiredAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add copy, the book may not exist or the barcode is taken!"), models.BookCopy{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add copy!"), models.BookCopy{}
+	}
+	copy.Id = int(lastId)
+
+	copy.Status, err = releaseCopy(tx, copy.Id, copy.BookId)
+	if err != nil {
+		tx.Rollback()
+		return err, models.BookCopy{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.BookCopy{}
+	}
+	return nil, copy
+}
+
+// PatchBookCopyDbHandler - Moves a copy (location) or marks it available, lost or withdrawn; copies out on loan or on hold cannot change status;
+func PatchBookCopyDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.BookCopy) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.BookCopy{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.BookCopy{}
+	}
+
+	var copy models.BookCopy
+	err = tx.QueryRow("SELECT id, book_id, barcode, location, status, acquired_at FROM book_copies WHERE id = ? FOR UPDATE", id).
+		Scan(&copy.Id, &copy.BookId, &copy.Barcode, &copy.Location, &copy.Status, &copy.AcquiredAt)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No copy found!"), models.BookCopy{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.BookCopy{}
+	}
+
+	status := copy.Status
+	for k, v := range updates {
+		value, ok := v.(string)
+		if !ok {
+			tx.Rollback()
+			return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.BookCopy{}
+		}
+		switch k {
+		case "location":
+			copy.Location = value
+		case "status":
+			status = value
+		default:
+			tx.Rollback()
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.BookCopy{}
+		}
+	}
+
+	if status != copy.Status {
+		if copy.Status == "on_loan" || copy.Status == "on_hold" {
+			tx.Rollback()
+			return utils.HandleError(errors.New("copy in use"), "Err: The copy is "+strings.ReplaceAll(copy.Status, "_", " ")+", return or release it first!"), models.BookCopy{}
+		}
+		if status != "available" && status != "lost" && status != "withdrawn" {
+			tx.Rollback()
+			return utils.HandleError(errors.New("invalid status"), "Err: Status must be available, lost or withdrawn!"), models.BookCopy{}
+		}
+	}
+
+	_, err = tx.Exec("UPDATE book_copies SET location = ?, status = ? WHERE id = ?", copy.Location, status, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update copy!"), models.BookCopy{}
+	}
+	copy.Status = status
+
+	// A copy back in circulation serves the holds queue first;
+	if status == "available" {
+		copy.Status, err = releaseCopy(tx, copy.Id, copy.BookId)
+		if err != nil {
+			tx.Rollback()
+			return err, models.BookCopy{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.BookCopy{}
+	}
+	return nil, copy
+}
+
+// releaseCopy - Sets a copy aside for the oldest waiting hold on its book (LIBRARY_HOLD_DAYS to collect), or makes it available;
+// Returns the new status of the copy;
+func releaseCopy(tx *sql.Tx, copyId, bookId int) (string, error) {
+	var holdId int
+	err := tx.QueryRow("SELECT id FROM book_holds WHERE book_id = ? AND status = 'waiting' ORDER BY created_at, id LIMIT 1 FOR UPDATE", bookId).Scan(&holdId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+
+	status := "available"
+	if holdId != 0 {
+		status = "on_hold"
+		now := time.Now()
+		_, err = tx.Exec("UPDATE book_holds SET status = 'ready', copy_id = ?, ready_at = ?, expires_at = ? WHERE id = ?",
+			copyId, now.Format(time.DateTime), now.AddDate(0, 0, max(1, envInt("LIBRARY_HOLD_DAYS", 3))).Format(time.DateTime), holdId)
+		if err != nil {
+			return "", utils.HandleError(err, "Err: Cannot update hold!")
+		}
+	}
+
+	_, err = tx.Exec("UPDATE book_copies SET status = ? WHERE id = ?", status, copyId)
+	if err != nil {
+		return "", utils.HandleError(err, "Err: Cannot update copy!")
+	}
+	return status, nil
+}
+
+// expireHolds - Ready holds not collected in time expire and their copies move on to the next in line;
+func expireHolds(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, book_id, copy_id FROM book_holds WHERE status = 'ready' AND expires_at < ? FOR UPDATE", time.Now().Format(time.DateTime))
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+
+	type expiredHold struct {
+		id, bookId int
+		copyId     sql.NullInt64
+	}
+	var expired []expiredHold
+	for rows.Next() {
+		var hold expiredHold
+		err = rows.Scan(&hold.id, &hold.bookId, &hold.copyId)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		expired = append(expired, hold)
+	}
+	rows.Close()
+
+	for _, hold := range expired {
+		_, err = tx.Exec("UPDATE book_holds SET status = 'expired' WHERE id = ?", hold.id)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot update hold!")
+		}
+		if hold.copyId.Valid {
+			_, err = releaseCopy(tx, int(hold.copyId.Int64), hold.bookId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getLoan(db dbExecutor, id int) (error, models.LibraryLoan) {
+	var loan models.LibraryLoan
+	err := scanLoan(db.QueryRow("SELECT "+loanColumns+loanTables+" WHERE l.id = ?", id), &loan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No loan found!"), models.LibraryLoan{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+	return nil, loan
+}
+
+// CheckoutDbHandler - Lends a copy (by barcode) to a student or teacher;
+// Borrowers with overdue loans or at their loan limit are refused, and copies set aside only go to the hold they were kept for;
+func CheckoutDbHandler(ctx context.Context, request models.CheckoutRequest, checkedOutBy int) (error, models.LibraryLoan) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LibraryLoan{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.LibraryLoan{}
+	}
+
+	err = expireHolds(tx)
+	if err != nil {
+		tx.Rollback()
+		return err, models.LibraryLoan{}
+	}
+
+	var copyId, bookId int
+	var status string
+	err = tx.QueryRow("SELECT id, book_id, status FROM book_copies WHERE barcode = ? FOR UPDATE", strings.TrimSpace(request.Barcode)).Scan(&copyId, &bookId, &status)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No copy found with this barcode!"), models.LibraryLoan{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+
+	err = checkBorrower(tx, request.Borrower)
+	if err != nil {
+		tx.Rollback()
+		return err, models.LibraryLoan{}
+	}
+
+	// A waiting or ready hold of this borrower on the book is fulfilled by the checkout;
+	var holdId int
+	var holdCopy sql.NullInt64
+	err = tx.QueryRow("SELECT id, copy_id FROM book_holds WHERE book_id = ? AND borrower_type = ? AND borrower_id = ? AND status IN ('waiting', 'ready') FOR UPDATE",
+		bookId, request.BorrowerType, request.BorrowerId).Scan(&holdId, &holdCopy)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+
+	switch {
+	case status == "available":
+	case status == "on_hold" && holdCopy.Valid && int(holdCopy.Int64) == copyId:
+	case status == "on_hold":
+		tx.Rollback()
+		return utils.HandleError(errors.New("copy on hold"), "Err: This copy is set aside for another borrower!"), models.LibraryLoan{}
+	default:
+		tx.Rollback()
+		return utils.HandleError(errors.New("copy unavailable"), "Err: This copy is "+strings.ReplaceAll(status, "_", " ")+"!"), models.LibraryLoan{}
+	}
+
+	var openLoans, overdueLoans int
+	err = tx.QueryRow("SELECT COUNT(*), COALESCE(SUM(due_date < CURDATE()), 0) FROM book_loans WHERE borrower_type = ? AND borrower_id = ? AND returned_at IS NULL",
+		request.BorrowerType, request.BorrowerId).Scan(&openLoans, &overdueLoans)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Query execution failed!"), models.LibraryLoan{}
+	}
+	if overdueLoans > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("overdue loans"), "Err: The borrower has overdue books to return first!"), models.LibraryLoan{}
+	}
+	if openLoans >= libraryMaxLoans(request.BorrowerType) {
+		tx.Rollback()
+		return utils.HandleError(errors.New("loan limit"), "Err: The borrower already has the maximum number of books on loan!"), models.LibraryLoan{}
+	}
+
+	if holdId != 0 {
+		// The copy a ready hold kept aside is freed when the borrower takes another copy;
+		if holdCopy.Valid && int(holdCopy.Int64) != copyId {
+			_, err = releaseCopy(tx, int(holdCopy.Int64), bookId)
+			if err != nil {
+				tx.Rollback()
+				return err, models.LibraryLoan{}
+			}
+		}
+		_, err = tx.Exec("UPDATE book_holds SET status = 'fulfilled' WHERE id = ?", holdId)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update hold!"), models.LibraryLoan{}
+		}
+	}
+
+	now := time.Now()
+	res, err := tx.Exec("INSERT INTO book_loans (copy_id, borrower_type, borrower_id, checked_out_at, checked_out_by, due_date) VALUES (?, ?, ?, ?, ?, ?)",
+		copyId, request.BorrowerType, request.BorrowerId, now.Format(time.DateTime), checkedOutBy, now.AddDate(0, 0, libraryLoanDays(request.BorrowerType)).Format(time.DateOnly))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot check out copy!"), models.LibraryLoan{}
+	}
+
+	loanId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot check out copy!"), models.LibraryLoan{}
+	}
+
+	_, err = tx.Exec("UPDATE book_copies SET status = 'on_loan' WHERE id = ?", copyId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot check out copy!"), models.LibraryLoan{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.LibraryLoan{}
+	}
+	return getLoan(db, int(loanId))
+}
+
+// ReturnDbHandler - Checks a copy (by barcode) back in, fixes the fine of the loan and passes the copy to the holds queue;
+func ReturnDbHandler(ctx context.Context, barcode string) (error, models.LibraryLoan) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LibraryLoan{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.LibraryLoan{}
+	}
+
+	err = expireHolds(tx)
+	if err != nil {
+		tx.Rollback()
+		return err, models.LibraryLoan{}
+	}
+
+	var loanId, copyId, bookId int
+	var dueDate string
+	err = tx.QueryRow(`SELECT l.id, l.copy_id, c.book_id, l.due_date FROM book_loans l JOIN book_copies c ON c.id = l.copy_id
+		WHERE c.barcode = ? AND l.returned_at IS NULL FOR UPDATE`, strings.TrimSpace(barcode)).Scan(&loanId, &copyId, &bookId, &dueDate)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: This copy is not on loan!"), models.LibraryLoan{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+
+	now := time.Now()
+	_, fine := libraryFine(dueDate, now)
+	_, err = tx.Exec("UPDATE book_loans SET returned_at = ?, fine_cents = ? WHERE id = ?", now.Format(time.DateTime), fine, loanId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot return copy!"), models.LibraryLoan{}
+	}
+
+	_, err = releaseCopy(tx, copyId, bookId)
+	if err != nil {
+		tx.Rollback()
+		return err, models.LibraryLoan{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.LibraryLoan{}
+	}
+	return getLoan(db, loanId)
+}
+
+// RenewLoanDbHandler - Extends an open loan by another loan period, up to LIBRARY_MAX_RENEWALS times;
+// Overdue loans and books others are waiting for cannot be renewed;
+func RenewLoanDbHandler(ctx context.Context, id int) (error, models.LibraryLoan) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.LibraryLoan{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.LibraryLoan{}
+	}
+
+	var loan models.LibraryLoan
+	err = scanLoan(tx.QueryRow("SELECT "+loanColumns+loanTables+" WHERE l.id = ? FOR UPDATE", id), &loan)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No loan found!"), models.LibraryLoan{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+
+	if loan.ReturnedAt != "" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("returned"), "Err: The copy has already been returned!"), models.LibraryLoan{}
+	}
+	if loan.DaysOverdue > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("overdue"), "Err: Overdue loans cannot be renewed!"), models.LibraryLoan{}
+	}
+	if loan.Renewals >= envInt("LIBRARY_MAX_RENEWALS", 2) {
+		tx.Rollback()
+		return utils.HandleError(errors.New("renewal limit"), "Err: The loan has been renewed the maximum number of times!"), models.LibraryLoan{}
+	}
+
+	var waiting int
+	err = tx.QueryRow("SELECT COUNT(*) FROM book_holds WHERE book_id = ? AND status = 'waiting'", loan.BookId).Scan(&waiting)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Query execution failed!"), models.LibraryLoan{}
+	}
+	if waiting > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("holds waiting"), "Err: Other borrowers are waiting for this book!"), models.LibraryLoan{}
+	}
+
+	due, err := time.Parse(time.DateOnly, loan.DueDate)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.LibraryLoan{}
+	}
+	_, err = tx.Exec("UPDATE book_loans SET due_date = ?, renewals = renewals + 1 WHERE id = ?",
+		due.AddDate(0, 0, libraryLoanDays(loan.BorrowerType)).Format(time.DateOnly), id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot renew loan!"), models.LibraryLoan{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.LibraryLoan{}
+	}
+	return getLoan(db, id)
+}
+
+// GetLoansDbHandler - Lists loans by ?status=open|overdue|returned and ?book_id=; a borrower narrows it to their loans;
+func GetLoansDbHandler(r *http.Request, borrower models.Borrower) (error, []models.LibraryLoan) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + loanColumns + loanTables + " WHERE 1=1"
+	var args []interface{}
+
+	switch r.URL.Query().Get("status") {
+	case "":
+	case "open":
+		query += " AND l.returned_at IS NULL"
+	case "overdue":
+		query += " AND l.returned_at IS NULL AND l.due_date < CURDATE()"
+	case "returned":
+		query += " AND l.returned_at IS NOT NULL"
+	default:
+		return utils.HandleError(errors.New("invalid status"), "Err: Status must be open, overdue or returned!"), nil
+	}
+	if bookId := r.URL.Query().Get("book_id"); bookId != "" {
+		query += " AND b.id = ?"
+		args = append(args, bookId)
+	}
+	if borrower.BorrowerId != 0 {
+		query += " AND l.borrower_type = ? AND l.borrower_id = ?"
+		args = append(args, borrower.BorrowerType, borrower.BorrowerId)
+	}
+	query += " ORDER BY l.returned_at IS NULL DESC, l.due_date, l.id"
+
+	return getLoans(db, query, args...)
+}
+
+func getLoans(db dbExecutor, query string, args ...interface{}) (error, []models.LibraryLoan) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	loans := []models.LibraryLoan{}
+	for rows.Next() {
+		var loan models.LibraryLoan
+		err = scanLoan(rows, &loan)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		loans = append(loans, loan)
+	}
+	return nil, loans
+}
+
+// GetOverdueReportDbHandler - Open overdue loans grouped by class, optionally for a single class; teachers are listed under "staff";
+func GetOverdueReportDbHandler(ctx context.Context, class string) (error, []models.OverdueClassReport) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + loanColumns + loanTables + " WHERE l.returned_at IS NULL AND l.due_date < CURDATE()"
+	var args []interface{}
+	if class != "" {
+		query += " AND l.borrower_type = 'student' AND s.class = ?"
+		args = append(args, class)
+	}
+	query += " ORDER BY l.due_date, COALESCE(s.last_name, t.last_name)"
+
+	err, loans := getLoans(db, query, args...)
+	if err != nil {
+		return err, nil
+	}
+
+	reports := []models.OverdueClassReport{}
+	index := map[string]int{}
+	for _, loan := range loans {
+		group := loan.Class
+		if loan.BorrowerType == "teacher" {
+			group = "staff"
+		}
+
+		i, ok := index[group]
+		if !ok {
+			i = len(reports)
+			index[group] = i
+			reports = append(reports, models.OverdueClassReport{Class: group, Loans: []models.LibraryLoan{}})
+		}
+		reports[i].Loans = append(reports[i].Loans, loan)
+		reports[i].Count++
+		reports[i].FineCents += loan.FineCents
+	}
+
+	sort.Slice(reports, func(a, b int) bool {
+		return reports[a].Class < reports[b].Class
+	})
+	return nil, reports
+}
+
+func getHold(db dbExecutor, id int) (error, models.BookHold) {
+	var hold models.BookHold
+	err := scanHold(db.QueryRow("SELECT "+holdColumns+holdTables+" WHERE h.id = ?", id), &hold)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No hold found!"), models.BookHold{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.BookHold{}
+	}
+	return nil, hold
+}
+
+// PlaceHoldDbHandler - Queues a borrower for a book that has no copy on the shelf;
+func PlaceHoldDbHandler(ctx context.Context, bookId int, borrower models.Borrower) (error, models.BookHold) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.BookHold{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.BookHold{}
+	}
+
+	err = expireHolds(tx)
+	if err != nil {
+		tx.Rollback()
+		return err, models.BookHold{}
+	}
+
+	// Locking the book serialises holds and checkouts of its copies;
+	var id int
+	err = tx.QueryRow("SELECT id FROM books WHERE id = ? FOR UPDATE", bookId).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No book found!"), models.BookHold{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.BookHold{}
+	}
+
+	err = checkBorrower(tx, borrower)
+	if err != nil {
+		tx.Rollback()
+		return err, models.BookHold{}
+	}
+
+	var available, circulating, existing int
+	err = tx.QueryRow(`SELECT COALESCE(SUM(status = 'available'), 0), COALESCE(SUM(status IN ('available', 'on_loan', 'on_hold')), 0),
+		(SELECT COUNT(*) FROM book_holds WHERE book_id = ? AND borrower_type = ? AND borrower_id = ? AND status IN ('waiting', 'ready'))
+		+ (SELECT COUNT(*) FROM book_loans l JOIN book_copies c ON c.id = l.copy_id WHERE c.book_id = ? AND l.borrower_type = ? AND l.borrower_id = ? AND l.returned_at IS NULL)
+		FROM book_copies WHERE book_id = ?`,
+		bookId, borrower.BorrowerType, borrower.BorrowerId, bookId, borrower.BorrowerType, borrower.BorrowerId, bookId).Scan(&available, &circulating, &existing)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Query execution failed!"), models.BookHold{}
+	}
+	if existing > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("duplicate hold"), "Err: The borrower already has this book on hold or on loan!"), models.BookHold{}
+	}
+	if circulating == 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("no copies"), "Err: The library has no copies of this book in circulation!"), models.BookHold{}
+	}
+	if available > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("copy available"), "Err: A copy is available, check it out instead!"), models.BookHold{}
+	}
+
+	res, err := tx.Exec("INSERT INTO book_holds (book_id, borrower_type, borrower_id, status, created_at) VALUES (?, ?, ?, 'waiting', ?)",
+		bookId, borrower.BorrowerType, borrower.BorrowerId, time.Now().Format(time.DateTime))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot place hold!"), models.BookHold{}
+	}
+
+	holdId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot place hold!"), models.BookHold{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.BookHold{}
+	}
+	return getHold(db, int(holdId))
+}
+
+// GetHoldDbHandler - Fetches a single hold;
+func GetHoldDbHandler(ctx context.Context, id int) (error, models.BookHold) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.BookHold{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getHold(db, id)
+}
+
+// GetHoldsDbHandler - Lists holds by book_id, status (default waiting and ready) and borrower, in queue order;
+func GetHoldsDbHandler(r *http.Request, borrower models.Borrower) (error, []models.BookHold) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + holdColumns + holdTables + " WHERE 1=1"
+	var args []interface{}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		query += " AND h.status IN ('waiting', 'ready')"
+	} else if isAllowedValue(status, models.BookHoldStatuses) {
+		query += " AND h.status = ?"
+		args = append(args, status)
+	} else {
+		return utils.HandleError(errors.New("invalid status"), "Err: Invalid hold status!"), nil
+	}
+	if bookId := r.URL.Query().Get("book_id"); bookId != "" {
+		query += " AND h.book_id = ?"
+		args = append(args, bookId)
+	}
+	if borrower.BorrowerId != 0 {
+		query += " AND h.borrower_type = ? AND h.borrower_id = ?"
+		args = append(args, borrower.BorrowerType, borrower.BorrowerId)
+	}
+	query += " ORDER BY b.title, h.created_at, h.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	holds := []models.BookHold{}
+	for rows.Next() {
+		var hold models.BookHold
+		err = scanHold(rows, &hold)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		holds = append(holds, hold)
+	}
+	return nil, holds
+}
+
+// CancelHoldDbHandler - Cancels a waiting or ready hold; a copy set aside for it goes to the next in line;
+func CancelHoldDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	var bookId int
+	var status string
+	var copyId sql.NullInt64
+	err = tx.QueryRow("SELECT book_id, status, copy_id FROM book_holds WHERE id = ? FOR UPDATE", id).Scan(&bookId, &status, &copyId)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No hold found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	if status != "waiting" && status != "ready" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("hold closed"), "Err: Only waiting or ready holds can be cancelled!")
+	}
+
+	_, err = tx.Exec("UPDATE book_holds SET status = 'cancelled' WHERE id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot cancel hold!")
+	}
+
+	if status == "ready" && copyId.Valid {
+		_, err = releaseCopy(tx, int(copyId.Int64), bookId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// LoginWaitDbHandler - How long the next login attempt for a username has to wait (0 when it may try now),
+// and whether that is because the username is locked out rather than backing off;
+func LoginWaitDbHandler(ctx context.Context, username string) (error, time.Duration, bool) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), 0, false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var failures int
+	var lastFailedAt string
+	var lockedUntil sql.NullString
+	err = db.QueryRow("SELECT failures, last_failed_at, locked_until FROM login_attempts WHERE username = ?", username).Scan(&failures, &lastFailedAt, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, false
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), 0, false
+	}
+
+	now := time.Now()
+	if lockedUntil.Valid {
+		until, err := parseStoredTime(lockedUntil.String)
+		if err != nil {
+			return utils.HandleError(err, "Err: Invalid timestamp!"), 0, false
+		}
+		if now.Before(until) {
+			return nil, until.Sub(now), true
+		}
+	}
+
+	last, err := parseStoredTime(lastFailedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Invalid timestamp!"), 0, false
+	}
+	wait := last.Add(utils.LoginBackoff(failures)).Sub(now)
+	if wait < 0 {
+		return nil, 0, false
+	}
+	return nil, wait, false
+}
+
+// RecordLoginFailureDbHandler - Counts a failed login for a username, locking it out once it reaches LoginMaxFailures;
+// Returns the end of the lockout when this failure caused one (zero otherwise);
+func RecordLoginFailureDbHandler(ctx context.Context, username string) (error, time.Time) {
+	lockout, err := utils.LoginLockoutDuration()
+	if err != nil {
+		return err, time.Time{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), time.Time{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), time.Time{}
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`INSERT INTO login_attempts (username, failures, last_failed_at) VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE failures = failures + 1, last_failed_at = VALUES(last_failed_at)`, username, now.Format(time.DateTime))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record failed login!"), time.Time{}
+	}
+
+	var failures int
+	err = tx.QueryRow("SELECT failures FROM login_attempts WHERE username = ?", username).Scan(&failures)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!"), time.Time{}
+	}
+
+	// The counter starts over once the lockout is set, so the next round gets the same number of tries;
+	var lockedUntil time.Time
+	if failures >= utils.LoginMaxFailures() {
+		lockedUntil = now.Add(lockout)
+		_, err = tx.Exec("UPDATE login_attempts SET failures = 0, locked_until = ? WHERE username = ?", lockedUntil.Format(time.DateTime), username)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot lock account!"), time.Time{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), time.Time{}
+	}
+	return nil, lockedUntil
+}
+
+// ClearLoginFailuresDbHandler - Forgets the failed logins of a username after a successful login;
+func ClearLoginFailuresDbHandler(ctx context.Context, username string) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	_, err = db.Exec("DELETE FROM login_attempts WHERE username = ?", username)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot clear failed logins!")
+	}
+	return nil
+}
+
+// UnlockExecDbHandler - Lifts the lockout of an exec and forgets their failed logins;
+func UnlockExecDbHandler(ctx context.Context, execId int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var username string
+	err = db.QueryRow("SELECT username FROM execs WHERE id = ?", execId).Scan(&username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No user found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+
+	_, err = db.Exec("DELETE FROM login_attempts WHERE username = ?", username)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot unlock account!")
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strings"
+	"time"
+)
+
+// medicalKeyEnv - Environment variable holding the base64 AES-256 key for medical data;
+const medicalKeyEnv = "MEDICAL_ENCRYPTION_KEY"
+
+// recordMedicalAccess - Writes an audit entry; callers must not return medical data when this fails;
+func recordMedicalAccess(db dbExecutor, access models.MedicalAccess) error {
+	var studentId interface{}
+	if access.StudentId > 0 {
+		studentId = access.StudentId
+	}
+
+	access.AccessedAt = time.Now().Format(time.DateTime)
+	_, err := db.Exec("INSERT INTO medical_access_log (user_id, role, student_id, class, action, ip_address, accessed_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		access.UserId, access.Role, studentId, access.Class, access.Action, access.IpAddress, access.AccessedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot write access audit!")
+	}
+	return nil
+}
+
+// ValidateMedicalProfile - Checks the allergy severities, mandatory names and vaccination dates of a profile;
+func ValidateMedicalProfile(profile models.MedicalProfile) error {
+	for _, allergy := range profile.Allergies {
+		if strings.TrimSpace(allergy.Name) == "" || !isAllowedValue(allergy.Severity, models.AllergySeverities) {
+			return utils.HandleError(errors.New("invalid allergy"), "Err: Every allergy needs a name and a severity (mild, moderate or severe)!")
+		}
+	}
+
+	for _, condition := range profile.Conditions {
+		if strings.TrimSpace(condition.Name) == "" {
+			return utils.HandleError(errors.New("invalid condition"), "Err: Every condition needs a name!")
+		}
+	}
+
+	for _, medication := range profile.Medications {
+		if strings.TrimSpace(medication.Name) == "" {
+			return utils.HandleError(errors.New("invalid medication"), "Err: Every medication needs a name!")
+		}
+	}
+
+	for _, vaccination := range profile.Vaccinations {
+		_, err := time.Parse(time.DateOnly, vaccination.Date)
+		if strings.TrimSpace(vaccination.Vaccine) == "" || err != nil {
+			return utils.HandleError(errors.New("invalid vaccination"), "Err: Every vaccination needs a vaccine and a date in YYYY-MM-DD format!")
+		}
+	}
+	return nil
+}
+
+// decryptMedicalProfile - Decrypts and unmarshals a stored profile;
+func decryptMedicalProfile(key []byte, data string, profile *models.MedicalProfile) error {
+	plaintext, err := utils.DecryptString(key, data)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal([]byte(plaintext), profile)
+	if err != nil {
+		return utils.HandleError(err, "Err: Corrupt medical profile!")
+	}
+	return nil
+}
+
+// GetMedicalProfileDbHandler - Fetches and decrypts the medical profile of a student; the read is audited first;
+func GetMedicalProfileDbHandler(ctx context.Context, studentId int, access models.MedicalAccess) (error, models.MedicalProfile) {
+	key, err := utils.EncryptionKey(medicalKeyEnv)
+	if err != nil {
+		return err, models.MedicalProfile{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MedicalProfile{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = recordMedicalAccess(db, access)
+	if err != nil {
+		return err, models.MedicalProfile{}
+	}
+
+	var data string
+	var profile models.MedicalProfile
+	var updatedBy sql.NullInt64
+	err = db.QueryRow("SELECT profile_data, updated_by, updated_at FROM student_medical_profiles WHERE student_id = ?", studentId).Scan(&data, &updatedBy, &profile.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No medical profile recorded for this student!"), models.MedicalProfile{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.MedicalProfile{}
+	}
+
+	updatedAt := profile.UpdatedAt
+	err = decryptMedicalProfile(key, data, &profile)
+	if err != nil {
+		return err, models.MedicalProfile{}
+	}
+	profile.StudentId = studentId
+	profile.UpdatedBy = int(updatedBy.Int64)
+	profile.UpdatedAt = updatedAt
+
+	return nil, profile
+}
+
+// SaveMedicalProfileDbHandler - Creates or replaces the encrypted medical profile of a student;
+func SaveMedicalProfileDbHandler(ctx context.Context, profile models.MedicalProfile, access models.MedicalAccess) (error, models.MedicalProfile) {
+	key, err := utils.EncryptionKey(medicalKeyEnv)
+	if err != nil {
+		return err, models.MedicalProfile{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MedicalProfile{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	profile.UpdatedBy = access.UserId
+	profile.UpdatedAt = time.Now().Format(time.DateTime)
+
+	// Ids and timestamps live in their own columns, the rest is one encrypted document;
+	sensitive := profile
+	sensitive.StudentId, sensitive.UpdatedBy, sensitive.UpdatedAt = 0, 0, ""
+	plaintext, err := json.Marshal(sensitive)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot encode medical profile!"), models.MedicalProfile{}
+	}
+
+	data, err := utils.EncryptString(key, string(plaintext))
+	if err != nil {
+		return err, models.MedicalProfile{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.MedicalProfile{}
+	}
+
+	err = recordMedicalAccess(tx, access)
+	if err != nil {
+		tx.Rollback()
+		return err, models.MedicalProfile{}
+	}
+
+	_, err = tx.Exec(`INSERT INTO student_medical_profiles (student_id, profile_data, updated_by, updated_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE profile_data = VALUES(profile_data), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)`,
+		profile.StudentId, data, profile.UpdatedBy, profile.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot save medical profile, the student may not exist!"), models.MedicalProfile{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.MedicalProfile{}
+	}
+
+	return nil, profile
+}
+
+// GetNurseVisitsDbHandler - Fetches and decrypts the nurse-visit log of a student, newest first; the read is audited first;
+func GetNurseVisitsDbHandler(ctx context.Context, studentId int, access models.MedicalAccess) (error, []models.NurseVisit) {
+	key, err := utils.EncryptionKey(medicalKeyEnv)
+	if err != nil {
+		return err, nil
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = recordMedicalAccess(db, access)
+	if err != nil {
+		return err, nil
+	}
+
+	rows, err := db.Query("SELECT id, student_id, visited_at, complaint, treatment, outcome, notes, recorded_by, created_at FROM nurse_visits WHERE student_id = ? ORDER BY visited_at DESC, id DESC", studentId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	visits := []models.NurseVisit{}
+	for rows.Next() {
+		var visit models.NurseVisit
+		err = rows.Scan(&visit.Id, &visit.StudentId, &visit.VisitedAt, &visit.Complaint, &visit.Treatment, &visit.Outcome, &visit.Notes, &visit.RecordedBy, &visit.CreatedAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+
+		for _, field := range []*string{&visit.Complaint, &visit.Treatment, &visit.Notes} {
+			*field, err = utils.DecryptString(key, *field)
+			if err != nil {
+				return err, nil
+			}
+		}
+		visits = append(visits, visit)
+	}
+	return nil, visits
+}
+
+// AddNurseVisitDbHandler - Logs a nurse visit with its free text fields encrypted;
+func AddNurseVisitDbHandler(ctx context.Context, visit models.NurseVisit, access models.MedicalAccess) (error, models.NurseVisit) {
+	key, err := utils.EncryptionKey(medicalKeyEnv)
+	if err != nil {
+		return err, models.NurseVisit{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.NurseVisit{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var encrypted [3]string
+	for i, value := range []string{visit.Complaint, visit.Treatment, visit.Notes} {
+		encrypted[i], err = utils.EncryptString(key, value)
+		if err != nil {
+			return err, models.NurseVisit{}
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.NurseVisit{}
+	}
+
+	err = recordMedicalAccess(tx, access)
+	if err != nil {
+		tx.Rollback()
+		return err, models.NurseVisit{}
+	}
+
+	visit.RecordedBy = access.UserId
+	visit.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO nurse_visits (student_id, visited_at, complaint, treatment, outcome, notes, recorded_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		visit.StudentId, visit.VisitedAt, encrypted[0], encrypted[1], visit.Outcome, encrypted[2], visit.RecordedBy, visit.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot log nurse visit, the student may not exist!"), models.NurseVisit{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot log nurse visit!"), models.NurseVisit{}
+	}
+	visit.Id = int(lastId)
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.NurseVisit{}
+	}
+
+	return nil, visit
+}
+
+// GetMedicalAlertsDbHandler - Minimal alerts (severe allergies and flagged conditions) of the active students of a class,
+// or of a single student when studentId > 0 (restricted to the class when one is given); the read is audited first;
+func GetMedicalAlertsDbHandler(ctx context.Context, class string, studentId int, access models.MedicalAccess) (error, []models.MedicalAlert) {
+	key, err := utils.EncryptionKey(medicalKeyEnv)
+	if err != nil {
+		return err, nil
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = recordMedicalAccess(db, access)
+	if err != nil {
+		return err, nil
+	}
+
+	query := "SELECT s.id, s.first_name, s.last_name, p.profile_data FROM students s JOIN student_medical_profiles p ON p.student_id = s.id WHERE s.inactive_status = 0"
+	var args []interface{}
+	if class != "" {
+		query += " AND s.class = ?"
+		args = append(args, class)
+	}
+	if studentId > 0 {
+		query += " AND s.id = ?"
+		args = append(args, studentId)
+	}
+	query += " ORDER BY s.last_name, s.first_name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	alerts := []models.MedicalAlert{}
+	for rows.Next() {
+		var student models.Student
+		var data string
+		err = rows.Scan(&student.Id, &student.FirstName, &student.LastName, &data)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+
+		var profile models.MedicalProfile
+		err = decryptMedicalProfile(key, data, &profile)
+		if err != nil {
+			return err, nil
+		}
+
+		alert := models.MedicalAlert{StudentId: student.Id, FirstName: student.FirstName, LastName: student.LastName}
+		for _, allergy := range profile.Allergies {
+			if allergy.Severity == "severe" {
+				alert.Type, alert.Name = "allergy", allergy.Name
+				alerts = append(alerts, alert)
+			}
+		}
+		for _, condition := range profile.Conditions {
+			if condition.Alert {
+				alert.Type, alert.Name = "condition", condition.Name
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	return nil, alerts
+}
+
+// GetMedicalAuditDbHandler - Lists the medical access audit, filtered by ?student_id=, ?user_id= or ?action=;
+func GetMedicalAuditDbHandler(r *http.Request) (error, []models.MedicalAccess) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT id, user_id, role, student_id, class, action, ip_address, accessed_at FROM medical_access_log WHERE 1=1"
+	var args []interface{}
+
+	for _, param := range []string{"student_id", "user_id", "action"} {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + param + " = ?"
+			args = append(args, value)
+		}
+	}
+	query += " ORDER BY id DESC LIMIT 1000"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	entries := []models.MedicalAccess{}
+	for rows.Next() {
+		var entry models.MedicalAccess
+		var studentId sql.NullInt64
+		err = rows.Scan(&entry.Id, &entry.UserId, &entry.Role, &studentId, &entry.Class, &entry.Action, &entry.IpAddress, &entry.AccessedAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		entry.StudentId = int(studentId.Int64)
+		entries = append(entries, entry)
+	}
+	return nil, entries
+}
@@ -0,0 +1,425 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+const threadColumns = "mt.id, mt.student_id, mt.subject, mt.staff_id, mt.guardian_id, mt.created_by, mt.locked, mt.created_at, mt.last_message_at"
+
+// threadUnreadColumn - Number of messages in a thread (alias mt) the user has not read yet; arguments: user id, user id;
+const threadUnreadColumn = `(SELECT COUNT(*) FROM messages m LEFT JOIN message_thread_reads tr ON tr.thread_id = m.thread_id AND tr.user_id = ?
+	WHERE m.thread_id = mt.id AND m.sender_id <> ? AND m.hidden = 0 AND m.id > COALESCE(tr.last_read_message_id, 0))`
+
+// scanThread - Scans a single thread row selected with threadColumns and threadUnreadColumn;
+func scanThread(scanner interface{ Scan(...interface{}) error }, thread *models.MessageThread) error {
+	return scanner.Scan(&thread.Id, &thread.StudentId, &thread.Subject, &thread.StaffId, &thread.GuardianId, &thread.CreatedBy, &thread.Locked, &thread.CreatedAt, &thread.LastMessageAt, &thread.Unread)
+}
+
+// getThread - Fetches a thread with the unread count of userId; viewerId > 0 hides threads the viewer is not part of;
+func getThread(db dbExecutor, id, viewerId, userId int) (error, models.MessageThread) {
+	query := "SELECT " + threadColumns + ", " + threadUnreadColumn + " FROM message_threads mt WHERE mt.id = ?"
+	args := []interface{}{userId, userId, id}
+	if viewerId > 0 {
+		query += " AND (mt.staff_id = ? OR mt.guardian_id = ?)"
+		args = append(args, viewerId, viewerId)
+	}
+
+	var thread models.MessageThread
+	err := scanThread(db.QueryRow(query, args...), &thread)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No thread found!"), models.MessageThread{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.MessageThread{}
+	}
+	return nil, thread
+}
+
+// insertMessage - Stores a message with its attachments and bumps the thread's last message time;
+func insertMessage(tx *sql.Tx, message models.Message) (error, models.Message) {
+	message.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO messages (thread_id, sender_id, sender_role, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		message.ThreadId, message.SenderId, message.SenderRole, message.Body, message.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot store message!"), models.Message{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot store message!"), models.Message{}
+	}
+	message.Id = int(lastId)
+
+	for i := range message.Attachments {
+		attachment := &message.Attachments[i]
+		attachment.MessageId = message.Id
+		res, err = tx.Exec("INSERT INTO message_attachments (message_id, file_name, content_type, size, data) VALUES (?, ?, ?, ?, ?)",
+			attachment.MessageId, attachment.FileName, attachment.ContentType, attachment.Size, attachment.Data)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot store attachment!"), models.Message{}
+		}
+
+		lastId, err = res.LastInsertId()
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot store attachment!"), models.Message{}
+		}
+		attachment.Id = int(lastId)
+		attachment.Content = ""
+	}
+
+	_, err = tx.Exec("UPDATE message_threads SET last_message_at = ? WHERE id = ?", message.CreatedAt, message.ThreadId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update thread!"), models.Message{}
+	}
+
+	// The sender has obviously read everything up to their own message;
+	err = markThreadRead(tx, message.ThreadId, message.SenderId, message.Id)
+	if err != nil {
+		return err, models.Message{}
+	}
+	return nil, message
+}
+
+// markThreadRead - Moves the read marker of a user in a thread forward;
+func markThreadRead(db dbExecutor, threadId, userId, messageId int) error {
+	_, err := db.Exec(`INSERT INTO message_thread_reads (thread_id, user_id, last_read_message_id) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE last_read_message_id = GREATEST(last_read_message_id, VALUES(last_read_message_id))`, threadId, userId, messageId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update read status!")
+	}
+	return nil
+}
+
+// AddThreadDbHandler - Starts a thread about a student with its first message;
+// The guardian must be linked to the student and the staff member must not be a guardian account;
+func AddThreadDbHandler(ctx context.Context, thread models.MessageThread, message models.Message) (error, models.MessageThread, models.Message) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MessageThread{}, models.Message{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var linked int
+	err = db.QueryRow("SELECT COUNT(*) FROM student_guardians WHERE student_id = ? AND exec_id = ?", thread.StudentId, thread.GuardianId).Scan(&linked)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.MessageThread{}, models.Message{}
+	}
+	if linked == 0 {
+		return utils.HandleError(errors.New("not linked"), "Err: The guardian is not linked to this student!"), models.MessageThread{}, models.Message{}
+	}
+
+	var staffRole string
+	err = db.QueryRow("SELECT role FROM execs WHERE id = ? AND inactive_status = 0", thread.StaffId).Scan(&staffRole)
+	if err != nil || staffRole == "guardian" {
+		return utils.HandleError(errors.New("invalid staff"), "Err: The staff member does not exist!"), models.MessageThread{}, models.Message{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.MessageThread{}, models.Message{}
+	}
+
+	thread.CreatedAt = time.Now().Format(time.DateTime)
+	thread.LastMessageAt = thread.CreatedAt
+	res, err := tx.Exec("INSERT INTO message_threads (student_id, subject, staff_id, guardian_id, created_by, created_at, last_message_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		thread.StudentId, thread.Subject, thread.StaffId, thread.GuardianId, thread.CreatedBy, thread.CreatedAt, thread.LastMessageAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot create thread!"), models.MessageThread{}, models.Message{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot create thread!"), models.MessageThread{}, models.Message{}
+	}
+	thread.Id = int(lastId)
+
+	message.ThreadId = thread.Id
+	err, message = insertMessage(tx, message)
+	if err != nil {
+		tx.Rollback()
+		return err, models.MessageThread{}, models.Message{}
+	}
+	thread.LastMessageAt = message.CreatedAt
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.MessageThread{}, models.Message{}
+	}
+
+	return nil, thread, message
+}
+
+// GetThreadsDbHandler - Lists threads, newest activity first, with the unread count of userId;
+// viewerId > 0 restricts the list to the threads the viewer takes part in;
+func GetThreadsDbHandler(r *http.Request, viewerId, userId int) (error, []models.MessageThread) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + threadColumns + ", " + threadUnreadColumn + " FROM message_threads mt WHERE 1=1"
+	args := []interface{}{userId, userId}
+
+	if viewerId > 0 {
+		query += " AND (mt.staff_id = ? OR mt.guardian_id = ?)"
+		args = append(args, viewerId, viewerId)
+	}
+
+	if studentId := r.URL.Query().Get("student_id"); studentId != "" {
+		query += " AND mt.student_id = ?"
+		args = append(args, studentId)
+	}
+
+	query += " ORDER BY mt.last_message_at DESC, mt.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	threads := []models.MessageThread{}
+	for rows.Next() {
+		var thread models.MessageThread
+		err = scanThread(rows, &thread)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		threads = append(threads, thread)
+	}
+	return nil, threads
+}
+
+// GetThreadMessagesDbHandler - Fetches a thread with its messages (oldest first) and marks them as read for userId;
+// Hidden (moderated) messages are only returned when includeHidden is set;
+func GetThreadMessagesDbHandler(ctx context.Context, id, viewerId, userId int, includeHidden bool) (error, models.MessageThread, []models.Message) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MessageThread{}, nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, thread := getThread(db, id, viewerId, userId)
+	if err != nil {
+		return err, models.MessageThread{}, nil
+	}
+
+	query := "SELECT id, thread_id, sender_id, sender_role, body, hidden, created_at FROM messages WHERE thread_id = ?"
+	if !includeHidden {
+		query += " AND hidden = 0"
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Query(query, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.MessageThread{}, nil
+	}
+
+	messages := []models.Message{}
+	index := map[int]int{}
+	for rows.Next() {
+		var message models.Message
+		err = rows.Scan(&message.Id, &message.ThreadId, &message.SenderId, &message.SenderRole, &message.Body, &message.Hidden, &message.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.MessageThread{}, nil
+		}
+		index[message.Id] = len(messages)
+		messages = append(messages, message)
+	}
+	rows.Close()
+
+	// Attachment metadata only, the content is downloaded separately;
+	rows, err = db.Query("SELECT a.id, a.message_id, a.file_name, a.content_type, a.size FROM message_attachments a JOIN messages m ON m.id = a.message_id WHERE m.thread_id = ? ORDER BY a.id", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.MessageThread{}, nil
+	}
+	for rows.Next() {
+		var attachment models.MessageAttachment
+		err = rows.Scan(&attachment.Id, &attachment.MessageId, &attachment.FileName, &attachment.ContentType, &attachment.Size)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.MessageThread{}, nil
+		}
+		if i, ok := index[attachment.MessageId]; ok {
+			messages[i].Attachments = append(messages[i].Attachments, attachment)
+		}
+	}
+	rows.Close()
+
+	if len(messages) > 0 && (thread.StaffId == userId || thread.GuardianId == userId) {
+		err = markThreadRead(db, id, userId, messages[len(messages)-1].Id)
+		if err != nil {
+			return err, models.MessageThread{}, nil
+		}
+		thread.Unread = 0
+	}
+
+	return nil, thread, messages
+}
+
+// AddMessageDbHandler - Replies in a thread the sender takes part in; locked threads cannot be replied to;
+func AddMessageDbHandler(ctx context.Context, threadId int, message models.Message) (error, models.MessageThread, models.Message) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MessageThread{}, models.Message{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, thread := getThread(db, threadId, message.SenderId, message.SenderId)
+	if err != nil {
+		return err, models.MessageThread{}, models.Message{}
+	}
+
+	if thread.Locked {
+		return utils.HandleError(errors.New("thread locked"), "Err: This thread has been locked by a moderator!"), models.MessageThread{}, models.Message{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.MessageThread{}, models.Message{}
+	}
+
+	message.ThreadId = thread.Id
+	err, message = insertMessage(tx, message)
+	if err != nil {
+		tx.Rollback()
+		return err, models.MessageThread{}, models.Message{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.MessageThread{}, models.Message{}
+	}
+
+	thread.LastMessageAt = message.CreatedAt
+	return nil, thread, message
+}
+
+// GetMessageAttachmentDbHandler - Fetches an attachment with its content; attachments of hidden messages need includeHidden;
+func GetMessageAttachmentDbHandler(ctx context.Context, threadId, attachmentId, viewerId int, includeHidden bool) (error, models.MessageAttachment) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MessageAttachment{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, _ = getThread(db, threadId, viewerId, viewerId)
+	if err != nil {
+		return err, models.MessageAttachment{}
+	}
+
+	query := "SELECT a.id, a.message_id, a.file_name, a.content_type, a.size, a.data FROM message_attachments a JOIN messages m ON m.id = a.message_id WHERE a.id = ? AND m.thread_id = ?"
+	if !includeHidden {
+		query += " AND m.hidden = 0"
+	}
+
+	var attachment models.MessageAttachment
+	err = db.QueryRow(query, attachmentId, threadId).Scan(&attachment.Id, &attachment.MessageId, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.Data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No attachment found!"), models.MessageAttachment{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.MessageAttachment{}
+	}
+	return nil, attachment
+}
+
+// SetThreadLockedDbHandler - Locks or unlocks a thread (moderation);
+func SetThreadLockedDbHandler(ctx context.Context, id int, locked bool) (error, models.MessageThread) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.MessageThread{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	_, err = db.Exec("UPDATE message_threads SET locked = ? WHERE id = ?", locked, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update thread!"), models.MessageThread{}
+	}
+
+	return getThread(db, id, 0, 0)
+}
+
+// SetMessageHiddenDbHandler - Hides or restores a message in a thread (moderation);
+func SetMessageHiddenDbHandler(ctx context.Context, threadId, messageId int, hidden bool, moderatorId int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var hiddenBy interface{}
+	if hidden {
+		hiddenBy = moderatorId
+	}
+
+	res, err := db.Exec("UPDATE messages SET hidden = ?, hidden_by = ? WHERE id = ? AND thread_id = ?", hidden, hiddenBy, messageId, threadId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update message!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update message!")
+	}
+	if rowsAffected == 0 {
+		var exists int
+		err = db.QueryRow("SELECT COUNT(*) FROM messages WHERE id = ? AND thread_id = ?", messageId, threadId).Scan(&exists)
+		if err != nil || exists == 0 {
+			return utils.HandleError(errors.New("not found"), "Err: No message found!")
+		}
+	}
+	return nil
+}
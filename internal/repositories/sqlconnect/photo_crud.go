@@ -0,0 +1,124 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// hasPhotoColumn - Select expression telling whether a student / teacher row has a profile photo;
+func hasPhotoColumn(ownerType, table string) string {
+	return "EXISTS (SELECT 1 FROM profile_photos p WHERE p.owner_type = '" + ownerType + "' AND p.owner_id = " + table + ".id)"
+}
+
+func getProfilePhoto(db dbExecutor, ownerType string, ownerId int, forUpdate bool) (error, models.ProfilePhoto) {
+	query := "SELECT owner_type, owner_id, version, width, height, storage_prefix, updated_by, updated_at FROM profile_photos WHERE owner_type = ? AND owner_id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var photo models.ProfilePhoto
+	err := db.QueryRow(query, ownerType, ownerId).Scan(&photo.OwnerType, &photo.OwnerId, &photo.Version, &photo.Width, &photo.Height,
+		&photo.StoragePrefix, &photo.UpdatedBy, &photo.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No photo found!"), models.ProfilePhoto{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.ProfilePhoto{}
+	}
+	return nil, photo
+}
+
+// GetProfilePhotoDbHandler - Returns the photo record of a student or teacher;
+func GetProfilePhotoDbHandler(ctx context.Context, ownerType string, ownerId int) (error, models.ProfilePhoto) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.ProfilePhoto{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getProfilePhoto(db, ownerType, ownerId, false)
+}
+
+// SaveProfilePhotoDbHandler - Records a new photo (the files must already be stored) and returns the storage prefix of the replaced one, if any;
+func SaveProfilePhotoDbHandler(ctx context.Context, photo models.ProfilePhoto) (error, models.ProfilePhoto, string) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.ProfilePhoto{}, ""
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = documentOwnerExists(db, photo.OwnerType, photo.OwnerId)
+	if err != nil {
+		return err, models.ProfilePhoto{}, ""
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.ProfilePhoto{}, ""
+	}
+
+	previousPrefix := ""
+	photo.Version = 1
+	err, previous := getProfilePhoto(tx, photo.OwnerType, photo.OwnerId, true)
+	if err == nil {
+		previousPrefix = previous.StoragePrefix
+		photo.Version = previous.Version + 1
+	}
+
+	photo.UpdatedAt = time.Now().Format(time.DateTime)
+	_, err = tx.Exec(`INSERT INTO profile_photos (owner_type, owner_id, version, width, height, storage_prefix, updated_by, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE version = VALUES(version), width = VALUES(width), height = VALUES(height), storage_prefix = VALUES(storage_prefix), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)`,
+		photo.OwnerType, photo.OwnerId, photo.Version, photo.Width, photo.Height, photo.StoragePrefix, photo.UpdatedBy, photo.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot save photo!"), models.ProfilePhoto{}, ""
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.ProfilePhoto{}, ""
+	}
+	return nil, photo, previousPrefix
+}
+
+// DeleteProfilePhotoDbHandler - Removes the photo record and returns it so the files can be deleted;
+func DeleteProfilePhotoDbHandler(ctx context.Context, ownerType string, ownerId int) (error, models.ProfilePhoto) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.ProfilePhoto{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, photo := getProfilePhoto(db, ownerType, ownerId, false)
+	if err != nil {
+		return err, models.ProfilePhoto{}
+	}
+
+	_, err = db.Exec("DELETE FROM profile_photos WHERE owner_type = ? AND owner_id = ?", ownerType, ownerId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete photo!"), models.ProfilePhoto{}
+	}
+	return nil, photo
+}
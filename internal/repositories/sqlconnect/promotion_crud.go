@@ -0,0 +1,335 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// planPromotion - Works out the class moves for every student based on the progression map;
+// Students listed in excludeIds are held back and students whose class is not in the map are reported as unmapped;
+func planPromotion(db dbExecutor, progression map[string]string, excludeIds []int, forUpdate bool) (error, models.PromotionPreview) {
+	preview := models.PromotionPreview{
+		Moves:    []models.PromotionMove{},
+		HeldBack: []models.PromotionMove{},
+		Unmapped: []models.PromotionMove{},
+	}
+
+	excluded := make(map[int]bool)
+	for _, id := range excludeIds {
+		excluded[id] = true
+	}
+
+	query := "SELECT id, first_name, last_name, class FROM students WHERE inactive_status = 0 ORDER BY class, last_name, first_name"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), preview
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var move models.PromotionMove
+		err = rows.Scan(&move.StudentId, &move.FirstName, &move.LastName, &move.FromClass)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), preview
+		}
+
+		nextClass, ok := progression[move.FromClass]
+		switch {
+		case excluded[move.StudentId]:
+			preview.HeldBack = append(preview.HeldBack, move)
+		case !ok || nextClass == "":
+			preview.Unmapped = append(preview.Unmapped, move)
+		default:
+			move.ToClass = nextClass
+			preview.Moves = append(preview.Moves, move)
+		}
+	}
+
+	return rows.Err(), preview
+}
+
+// PreviewPromotionDbHandler - Returns the proposed moves without changing any student;
+func PreviewPromotionDbHandler(ctx context.Context, request models.PromotionRequest) (error, models.PromotionPreview) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.PromotionPreview{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return planPromotion(db, request.Progression, request.ExcludeIds, false)
+}
+
+// CommitPromotionDbHandler - Moves every mapped student to the next class in a single transaction and records the batch;
+func CommitPromotionDbHandler(ctx context.Context, request models.PromotionRequest, promotedBy int, undoWindow time.Duration) (error, models.Promotion) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Promotion{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Promotion{}
+	}
+
+	err, preview := planPromotion(tx, request.Progression, request.ExcludeIds, true)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Promotion{}
+	}
+
+	if len(preview.Moves) == 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("nothing to promote"), "Err: No students to promote!"), models.Promotion{}
+	}
+
+	now := time.Now()
+	promotion := models.Promotion{
+		AcademicYear: request.AcademicYear,
+		PromotedBy:   promotedBy,
+		Status:       "committed",
+		CreatedAt:    now.Format(time.DateTime),
+		UndoUntil:    now.Add(undoWindow).Format(time.DateTime),
+		Moves:        preview.Moves,
+	}
+
+	res, err := tx.Exec("INSERT INTO promotions (academic_year, promoted_by, status, undo_until, created_at) VALUES (?, ?, ?, ?, ?)",
+		promotion.AcademicYear, promotion.PromotedBy, promotion.Status, promotion.UndoUntil, promotion.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record promotion!"), models.Promotion{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record promotion!"), models.Promotion{}
+	}
+	promotion.Id = int(lastId)
+
+	moveStatement, err := tx.Prepare("INSERT INTO promotion_moves (promotion_id, student_id, from_class, to_class) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot prepare statement!"), models.Promotion{}
+	}
+	defer moveStatement.Close()
+
+	updateStatement, err := tx.Prepare("UPDATE students SET class = ? WHERE id = ?")
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot prepare statement!"), models.Promotion{}
+	}
+	defer updateStatement.Close()
+
+	for _, move := range promotion.Moves {
+		_, err = updateStatement.Exec(move.ToClass, move.StudentId)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update student in db!"), models.Promotion{}
+		}
+
+		_, err = moveStatement.Exec(promotion.Id, move.StudentId, move.FromClass, move.ToClass)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot record promotion!"), models.Promotion{}
+		}
+
+		err = recordPromotion(tx, move, promotion.AcademicYear, promotion.Id)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Promotion{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Promotion{}
+	}
+
+	log.Printf("Promotion %d committed, %d students moved\n", promotion.Id, len(promotion.Moves))
+	return nil, promotion
+}
+
+// UndoPromotionDbHandler - Reverts a committed promotion and its enrollments while the undo window is open;
+// Students whose class was changed again after the promotion are left untouched;
+func UndoPromotionDbHandler(ctx context.Context, id int) (error, models.Promotion) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Promotion{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Promotion{}
+	}
+
+	err, promotion := getPromotion(tx, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Promotion{}
+	}
+
+	now := time.Now().Format(time.DateTime)
+	if promotion.Status != "committed" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("promotion not committed"), "Err: Promotion has already been undone!"), models.Promotion{}
+	}
+	if promotion.UndoUntil < now {
+		tx.Rollback()
+		return utils.HandleError(errors.New("undo window closed"), "Err: Undo window for this promotion has expired!"), models.Promotion{}
+	}
+
+	for _, move := range promotion.Moves {
+		res, err := tx.Exec("UPDATE students SET class = ? WHERE id = ? AND class = ?", move.FromClass, move.StudentId, move.ToClass)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update student in db!"), models.Promotion{}
+		}
+
+		reverted, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update student in db!"), models.Promotion{}
+		}
+
+		if reverted > 0 {
+			err = revertPromotion(tx, move, promotion.Id)
+			if err != nil {
+				tx.Rollback()
+				return err, models.Promotion{}
+			}
+		}
+	}
+
+	_, err = tx.Exec("UPDATE promotions SET status = 'undone', undone_at = ? WHERE id = ?", now, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update promotion!"), models.Promotion{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Promotion{}
+	}
+
+	promotion.Status = "undone"
+	promotion.UndoneAt = now
+	return nil, promotion
+}
+
+// getPromotion - Loads a promotion batch with its moves;
+func getPromotion(db dbExecutor, id int, forUpdate bool) (error, models.Promotion) {
+	var promotion models.Promotion
+	var undoneAt sql.NullString
+
+	query := "SELECT id, academic_year, promoted_by, status, undo_until, created_at, undone_at FROM promotions WHERE id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	err := db.QueryRow(query, id).Scan(&promotion.Id, &promotion.AcademicYear, &promotion.PromotedBy, &promotion.Status, &promotion.UndoUntil, &promotion.CreatedAt, &undoneAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No promotion found!"), models.Promotion{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Promotion{}
+	}
+	promotion.UndoneAt = undoneAt.String
+
+	rows, err := db.Query("SELECT student_id, from_class, to_class FROM promotion_moves WHERE promotion_id = ? ORDER BY student_id", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Promotion{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var move models.PromotionMove
+		err = rows.Scan(&move.StudentId, &move.FromClass, &move.ToClass)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.Promotion{}
+		}
+		promotion.Moves = append(promotion.Moves, move)
+	}
+
+	return nil, promotion
+}
+
+// GetPromotionDbHandler - Fetches a promotion batch with its moves;
+func GetPromotionDbHandler(ctx context.Context, id int) (error, models.Promotion) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Promotion{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getPromotion(db, id, false)
+}
+
+// GetPromotionsDbHandler - Lists promotion batches, newest first (moves are not included);
+func GetPromotionsDbHandler(ctx context.Context) (error, []models.Promotion) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	rows, err := db.Query("SELECT id, academic_year, promoted_by, status, undo_until, created_at, undone_at FROM promotions ORDER BY id DESC")
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	var promotions []models.Promotion
+	for rows.Next() {
+		var promotion models.Promotion
+		var undoneAt sql.NullString
+		err = rows.Scan(&promotion.Id, &promotion.AcademicYear, &promotion.PromotedBy, &promotion.Status, &promotion.UndoUntil, &promotion.CreatedAt, &undoneAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		promotion.UndoneAt = undoneAt.String
+		promotions = append(promotions, promotion)
+	}
+
+	return nil, promotions
+}
@@ -0,0 +1,919 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBookingOccurrences - Upper bound for the occurrences of one recurring booking;
+const maxBookingOccurrences = 100
+
+const roomColumns = "r.id, r.name, r.type, r.building, r.capacity, r.restricted, r.active, r.created_at"
+
+func scanRoom(scanner interface{ Scan(...interface{}) error }, room *models.Room) error {
+	return scanner.Scan(&room.Id, &room.Name, &room.Type, &room.Building, &room.Capacity, &room.Restricted, &room.Active, &room.CreatedAt)
+}
+
+const roomBookingColumns = "b.id, b.room_id, r.name, b.title, b.start_at, b.end_at, b.status, b.series_id, b.booked_by, b.decided_by, b.decided_at, b.decision_note, b.created_at"
+
+func scanRoomBooking(scanner interface{ Scan(...interface{}) error }, booking *models.RoomBooking) error {
+	var seriesId, decidedBy sql.NullInt64
+	var decidedAt sql.NullString
+	err := scanner.Scan(&booking.Id, &booking.RoomId, &booking.RoomName, &booking.Title, &booking.StartAt, &booking.EndAt, &booking.Status,
+		&seriesId, &booking.BookedBy, &decidedBy, &decidedAt, &booking.DecisionNote, &booking.CreatedAt)
+	if err != nil {
+		return err
+	}
+	booking.SeriesId = int(seriesId.Int64)
+	booking.DecidedBy = int(decidedBy.Int64)
+	booking.DecidedAt = decidedAt.String
+	return nil
+}
+
+// ValidateRoom - Checks the name, type, capacity and equipment list of a room;
+func ValidateRoom(room models.Room) error {
+	if strings.TrimSpace(room.Name) == "" || len(room.Name) > 255 {
+		return utils.HandleError(errors.New("invalid name"), "Err: Room name must be between 1 and 255 characters!")
+	}
+	if !isAllowedValue(room.Type, models.RoomTypes) {
+		return utils.HandleError(errors.New("invalid type"), "Err: Room type must be classroom, lab, auditorium, sports_ground or other!")
+	}
+	if room.Capacity < 1 {
+		return utils.HandleError(errors.New("invalid capacity"), "Err: Capacity must be at least 1!")
+	}
+
+	seen := map[string]bool{}
+	for _, equipment := range room.Equipment {
+		item := strings.ToLower(strings.TrimSpace(equipment.Item))
+		if item == "" || len(equipment.Item) > 255 || equipment.Quantity < 1 {
+			return utils.HandleError(errors.New("invalid equipment"), "Err: Equipment needs an item name and a quantity of at least 1!")
+		}
+		if seen[item] {
+			return utils.HandleError(errors.New("duplicate equipment"), "Err: Equipment item "+equipment.Item+" is listed twice!")
+		}
+		seen[item] = true
+	}
+	return nil
+}
+
+// replaceRoomEquipment - Rewrites the equipment list of a room;
+func replaceRoomEquipment(tx *sql.Tx, room models.Room) error {
+	_, err := tx.Exec("DELETE FROM room_equipment WHERE room_id = ?", room.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update equipment!")
+	}
+	for _, equipment := range room.Equipment {
+		_, err = tx.Exec("INSERT INTO room_equipment (room_id, item, quantity) VALUES (?, ?, ?)", room.Id, strings.TrimSpace(equipment.Item), equipment.Quantity)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot update equipment!")
+		}
+	}
+	return nil
+}
+
+// getRoomEquipment - Loads the equipment list of a room;
+func getRoomEquipment(db dbExecutor, room *models.Room) error {
+	rows, err := db.Query("SELECT item, quantity FROM room_equipment WHERE room_id = ? ORDER BY item", room.Id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	defer rows.Close()
+
+	room.Equipment = []models.RoomEquipment{}
+	for rows.Next() {
+		var equipment models.RoomEquipment
+		err = rows.Scan(&equipment.Item, &equipment.Quantity)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		room.Equipment = append(room.Equipment, equipment)
+	}
+	return nil
+}
+
+// getRooms - Runs a room query and loads the equipment of every room found;
+func getRooms(db dbExecutor, query string, args ...interface{}) (error, []models.Room) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	rooms := []models.Room{}
+	for rows.Next() {
+		var room models.Room
+		err = scanRoom(rows, &room)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		rooms = append(rooms, room)
+	}
+	rows.Close()
+
+	for i := range rooms {
+		err = getRoomEquipment(db, &rooms[i])
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, rooms
+}
+
+func getRoom(db dbExecutor, id int) (error, models.Room) {
+	var room models.Room
+	err := scanRoom(db.QueryRow("SELECT "+roomColumns+" FROM rooms r WHERE r.id = ?", id), &room)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No room found!"), models.Room{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Room{}
+	}
+
+	err = getRoomEquipment(db, &room)
+	if err != nil {
+		return err, models.Room{}
+	}
+	return nil, room
+}
+
+// AddRoomDbHandler - Adds a room with its equipment;
+func AddRoomDbHandler(ctx context.Context, room models.Room) (error, models.Room) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Room{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Room{}
+	}
+
+	room.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO rooms (name, type, building, capacity, restricted, active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		room.Name, room.Type, room.Building, room.Capacity, room.Restricted, room.Active, room.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add room, the name may already be taken!"), models.Room{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add room!"), models.Room{}
+	}
+	room.Id = int(lastId)
+
+	err = replaceRoomEquipment(tx, room)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Room{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Room{}
+	}
+	return getRoom(db, room.Id)
+}
+
+// GetRoomsDbHandler - Lists rooms filtered by type, building, ?min_capacity= and ?active=true|false;
+func GetRoomsDbHandler(r *http.Request) (error, []models.Room) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + roomColumns + " FROM rooms r WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"type":     "r.type",
+		"building": "r.building",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	if minCapacity := r.URL.Query().Get("min_capacity"); minCapacity != "" {
+		query += " AND r.capacity >= ?"
+		args = append(args, minCapacity)
+	}
+	switch r.URL.Query().Get("active") {
+	case "true":
+		query += " AND r.active = 1"
+	case "false":
+		query += " AND r.active = 0"
+	}
+	query += " ORDER BY r.name"
+
+	return getRooms(db, query, args...)
+}
+
+// GetRoomDbHandler - Fetches a room with its equipment;
+func GetRoomDbHandler(ctx context.Context, id int) (error, models.Room) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Room{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getRoom(db, id)
+}
+
+// PatchRoomDbHandler - Updates name, type, building, capacity, restricted, active or equipment of a room;
+func PatchRoomDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Room) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Room{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, room := getRoom(db, id)
+	if err != nil {
+		return err, models.Room{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "name", "type", "building":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Room{}
+			}
+			switch k {
+			case "name":
+				room.Name = value
+			case "type":
+				room.Type = value
+			default:
+				room.Building = value
+			}
+		case "capacity":
+			value, ok := v.(float64)
+			if !ok || value != math.Trunc(value) {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for capacity!"), models.Room{}
+			}
+			room.Capacity = int(value)
+		case "restricted", "active":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Room{}
+			}
+			if k == "restricted" {
+				room.Restricted = value
+			} else {
+				room.Active = value
+			}
+		case "equipment":
+			// The list is decoded again into its typed form;
+			raw, err := json.Marshal(v)
+			if err == nil {
+				room.Equipment = nil
+				err = json.Unmarshal(raw, &room.Equipment)
+			}
+			if err != nil {
+				return utils.HandleError(err, "Err: Invalid value for equipment!"), models.Room{}
+			}
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Room{}
+		}
+	}
+
+	err = ValidateRoom(room)
+	if err != nil {
+		return err, models.Room{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Room{}
+	}
+
+	_, err = tx.Exec("UPDATE rooms SET name = ?, type = ?, building = ?, capacity = ?, restricted = ?, active = ? WHERE id = ?",
+		room.Name, room.Type, room.Building, room.Capacity, room.Restricted, room.Active, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update room, the name may already be taken!"), models.Room{}
+	}
+
+	if _, ok := updates["equipment"]; ok {
+		err = replaceRoomEquipment(tx, room)
+		if err != nil {
+			tx.Rollback()
+			return err, models.Room{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Room{}
+	}
+	return getRoom(db, id)
+}
+
+// DeleteRoomDbHandler - Deletes a room without upcoming bookings; timetable periods held there lose their room;
+func DeleteRoomDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var upcoming int
+	err = db.QueryRow("SELECT COUNT(*) FROM room_bookings WHERE room_id = ? AND status IN ('pending', 'approved') AND end_at > ?",
+		id, time.Now().Format(time.DateTime)).Scan(&upcoming)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if upcoming > 0 {
+		return utils.HandleError(errors.New("room in use"), "Err: The room has upcoming bookings, cancel them or deactivate the room instead!")
+	}
+
+	res, err := db.Exec("DELETE FROM rooms WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete room!")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete room!")
+	}
+	if affected == 0 {
+		return utils.HandleError(sql.ErrNoRows, "Err: No room found!")
+	}
+	return nil
+}
+
+// ParseBookingRange - Parses a booking's start and end (YYYY-MM-DD HH:MM:SS); both must fall on the same day;
+func ParseBookingRange(startAt, endAt string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(time.DateTime, startAt, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, utils.HandleError(err, "Err: Start must be in YYYY-MM-DD HH:MM:SS format!")
+	}
+	end, err := time.ParseInLocation(time.DateTime, endAt, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, utils.HandleError(err, "Err: End must be in YYYY-MM-DD HH:MM:SS format!")
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, utils.HandleError(errors.New("invalid range"), "Err: End must be after the start!")
+	}
+	if start.Format(time.DateOnly) != end.Format(time.DateOnly) {
+		return time.Time{}, time.Time{}, utils.HandleError(errors.New("invalid range"), "Err: A booking must start and end on the same day!")
+	}
+	return start, end, nil
+}
+
+// bookingOccurrences - Expands a booking request into the time ranges to reserve;
+func bookingOccurrences(request models.RoomBookingRequest) ([][2]time.Time, error) {
+	if strings.TrimSpace(request.Title) == "" || len(request.Title) > 255 {
+		return nil, utils.HandleError(errors.New("invalid title"), "Err: Title must be between 1 and 255 characters!")
+	}
+
+	start, end, err := ParseBookingRange(request.StartAt, request.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	if start.Before(time.Now()) {
+		return nil, utils.HandleError(errors.New("past booking"), "Err: Bookings cannot start in the past!")
+	}
+
+	occurrences := [][2]time.Time{{start, end}}
+	if request.Recurrence == nil {
+		return occurrences, nil
+	}
+
+	step := 0
+	switch request.Recurrence.Frequency {
+	case "daily":
+		step = 1
+	case "weekly":
+		step = 7
+	default:
+		return nil, utils.HandleError(errors.New("invalid frequency"), "Err: Recurrence frequency must be daily or weekly!")
+	}
+
+	until, err := time.ParseInLocation(time.DateOnly, request.Recurrence.Until, time.Local)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Recurrence end must be in YYYY-MM-DD format!")
+	}
+	// Until is inclusive;
+	until = until.AddDate(0, 0, 1)
+
+	for next := 1; ; next++ {
+		nextStart := start.AddDate(0, 0, next*step)
+		if !nextStart.Before(until) {
+			break
+		}
+		if len(occurrences) == maxBookingOccurrences {
+			return nil, utils.HandleError(errors.New("too many occurrences"), "Err: A recurring booking cannot have more than "+strconv.Itoa(maxBookingOccurrences)+" occurrences!")
+		}
+		occurrences = append(occurrences, [2]time.Time{nextStart, end.AddDate(0, 0, next*step)})
+	}
+	return occurrences, nil
+}
+
+// findRoomConflicts - Pending or approved bookings and weekly timetable periods of a room overlapping a time range;
+func findRoomConflicts(db dbExecutor, roomId int, start, end time.Time) ([]models.RoomConflict, error) {
+	conflicts := []models.RoomConflict{}
+
+	rows, err := db.Query("SELECT id, title, start_at, end_at FROM room_bookings WHERE room_id = ? AND status IN ('pending', 'approved') AND start_at < ? AND end_at > ?",
+		roomId, end.Format(time.DateTime), start.Format(time.DateTime))
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Query execution failed!")
+	}
+	for rows.Next() {
+		conflict := models.RoomConflict{Source: "booking"}
+		err = rows.Scan(&conflict.Id, &conflict.Title, &conflict.StartAt, &conflict.EndAt)
+		if err != nil {
+			rows.Close()
+			return nil, utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT id, class, subject, start_time, end_time FROM timetable_slots WHERE room_id = ? AND day_of_week = ? AND start_time < ? AND end_time > ?",
+		roomId, isoWeekday(start), end.Format(time.TimeOnly), start.Format(time.TimeOnly))
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Query execution failed!")
+	}
+	defer rows.Close()
+
+	day := start.Format(time.DateOnly)
+	for rows.Next() {
+		conflict := models.RoomConflict{Source: "timetable"}
+		var class, subject, startTime, endTime string
+		err = rows.Scan(&conflict.Id, &class, &subject, &startTime, &endTime)
+		if err != nil {
+			return nil, utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		conflict.Title = class + " " + subject
+		conflict.StartAt = day + " " + startTime
+		conflict.EndAt = day + " " + endTime
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+// checkTimetableRoom - A timetable period can only use an active room that is free on that weekday, now and in future weeks;
+func checkTimetableRoom(db dbExecutor, slot models.TimetableSlot) error {
+	err, room := getRoom(db, slot.RoomId)
+	if err != nil {
+		return err
+	}
+	if !room.Active {
+		return utils.HandleError(errors.New("inactive room"), "Err: The room is not in use!")
+	}
+
+	var clashes int
+	err = db.QueryRow("SELECT COUNT(*) FROM timetable_slots WHERE room_id = ? AND day_of_week = ? AND start_time < ? AND end_time > ?",
+		slot.RoomId, slot.DayOfWeek, slot.EndTime, slot.StartTime).Scan(&clashes)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if clashes > 0 {
+		return utils.HandleError(errors.New("room clash"), "Err: The room is already timetabled at this time!")
+	}
+
+	// WEEKDAY() counts from 0 (Monday);
+	err = db.QueryRow(`SELECT COUNT(*) FROM room_bookings WHERE room_id = ? AND status IN ('pending', 'approved') AND end_at > ?
+		AND WEEKDAY(start_at) + 1 = ? AND TIME(start_at) < ? AND TIME(end_at) > ?`,
+		slot.RoomId, time.Now().Format(time.DateTime), slot.DayOfWeek, slot.EndTime, slot.StartTime).Scan(&clashes)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if clashes > 0 {
+		return utils.HandleError(errors.New("room clash"), "Err: The room has upcoming bookings at this time!")
+	}
+	return nil
+}
+
+// AddRoomBookingDbHandler - Books a room for one or more occurrences; nothing is booked if any occurrence conflicts;
+// Restricted rooms stay pending until approved unless autoApprove is set;
+func AddRoomBookingDbHandler(ctx context.Context, roomId int, request models.RoomBookingRequest, bookedBy int, autoApprove bool) (error, []models.RoomBooking, []models.RoomConflict) {
+	occurrences, err := bookingOccurrences(request)
+	if err != nil {
+		return err, nil, nil
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil, nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), nil, nil
+	}
+
+	// Locking the room serialises bookings so two requests cannot take the same time;
+	var restricted, active bool
+	err = tx.QueryRow("SELECT restricted, active FROM rooms WHERE id = ? FOR UPDATE", roomId).Scan(&restricted, &active)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No room found!"), nil, nil
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), nil, nil
+	}
+	if !active {
+		tx.Rollback()
+		return utils.HandleError(errors.New("inactive room"), "Err: The room is not in use!"), nil, nil
+	}
+
+	conflicts := []models.RoomConflict{}
+	for _, occurrence := range occurrences {
+		found, err := findRoomConflicts(tx, roomId, occurrence[0], occurrence[1])
+		if err != nil {
+			tx.Rollback()
+			return err, nil, nil
+		}
+		conflicts = append(conflicts, found...)
+	}
+	if len(conflicts) > 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("room conflict"), "Err: The room is not free at the requested time!"), nil, conflicts
+	}
+
+	status := "approved"
+	if restricted && !autoApprove {
+		status = "pending"
+	}
+	createdAt := time.Now().Format(time.DateTime)
+
+	var bookings []models.RoomBooking
+	var seriesId sql.NullInt64
+	for _, occurrence := range occurrences {
+		booking := models.RoomBooking{
+			RoomId:    roomId,
+			Title:     strings.TrimSpace(request.Title),
+			StartAt:   occurrence[0].Format(time.DateTime),
+			EndAt:     occurrence[1].Format(time.DateTime),
+			Status:    status,
+			BookedBy:  bookedBy,
+			CreatedAt: createdAt,
+		}
+		if status == "approved" && restricted {
+			booking.DecidedBy = bookedBy
+			booking.DecidedAt = createdAt
+		}
+
+		res, err := tx.Exec("INSERT INTO room_bookings (room_id, title, start_at, end_at, status, series_id, booked_by, decided_by, decided_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			booking.RoomId, booking.Title, booking.StartAt, booking.EndAt, booking.Status, seriesId, booking.BookedBy,
+			sql.NullInt64{Int64: int64(booking.DecidedBy), Valid: booking.DecidedBy != 0}, nullableString(booking.DecidedAt), booking.CreatedAt)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot add booking!"), nil, nil
+		}
+
+		lastId, err := res.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot add booking!"), nil, nil
+		}
+		booking.Id = int(lastId)
+
+		// Occurrences of a recurring booking point at the first one;
+		if len(occurrences) > 1 {
+			if !seriesId.Valid {
+				seriesId = sql.NullInt64{Int64: lastId, Valid: true}
+				_, err = tx.Exec("UPDATE room_bookings SET series_id = id WHERE id = ?", lastId)
+				if err != nil {
+					tx.Rollback()
+					return utils.HandleError(err, "Err: Cannot add booking!"), nil, nil
+				}
+			}
+			booking.SeriesId = int(seriesId.Int64)
+		}
+		bookings = append(bookings, booking)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), nil, nil
+	}
+	return nil, bookings, nil
+}
+
+// GetRoomBookingsDbHandler - Lists bookings filtered by room_id, status or series_id and ?from= / ?to= (YYYY-MM-DD);
+// A non-zero bookedBy limits the list to the bookings of one user;
+func GetRoomBookingsDbHandler(r *http.Request, bookedBy int) (error, []models.RoomBooking) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + roomBookingColumns + " FROM room_bookings b JOIN rooms r ON r.id = b.room_id WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"room_id":   "b.room_id",
+		"status":    "b.status",
+		"series_id": "b.series_id",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		_, err = time.Parse(time.DateOnly, from)
+		if err != nil {
+			return utils.HandleError(err, "Err: From must be in YYYY-MM-DD format!"), nil
+		}
+		query += " AND b.end_at > ?"
+		args = append(args, from)
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		day, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return utils.HandleError(err, "Err: To must be in YYYY-MM-DD format!"), nil
+		}
+		query += " AND b.start_at < ?"
+		args = append(args, day.AddDate(0, 0, 1).Format(time.DateOnly))
+	}
+	if bookedBy != 0 {
+		query += " AND b.booked_by = ?"
+		args = append(args, bookedBy)
+	}
+	query += " ORDER BY b.start_at, r.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	bookings := []models.RoomBooking{}
+	for rows.Next() {
+		var booking models.RoomBooking
+		err = scanRoomBooking(rows, &booking)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		bookings = append(bookings, booking)
+	}
+	return nil, bookings
+}
+
+func getRoomBooking(db dbExecutor, id int, forUpdate bool) (error, models.RoomBooking) {
+	query := "SELECT " + roomBookingColumns + " FROM room_bookings b JOIN rooms r ON r.id = b.room_id WHERE b.id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var booking models.RoomBooking
+	err := scanRoomBooking(db.QueryRow(query, id), &booking)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No booking found!"), models.RoomBooking{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.RoomBooking{}
+	}
+	return nil, booking
+}
+
+// GetRoomBookingDbHandler - Fetches a single booking;
+func GetRoomBookingDbHandler(ctx context.Context, id int) (error, models.RoomBooking) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.RoomBooking{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getRoomBooking(db, id, false)
+}
+
+// DecideRoomBookingDbHandler - Approves or rejects a pending booking together with the pending occurrences of its series;
+// Returns the number of bookings decided;
+func DecideRoomBookingDbHandler(ctx context.Context, id int, status, note string, decidedBy int) (error, models.RoomBooking, int) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.RoomBooking{}, 0
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.RoomBooking{}, 0
+	}
+
+	err, booking := getRoomBooking(tx, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err, models.RoomBooking{}, 0
+	}
+	if booking.Status != "pending" {
+		tx.Rollback()
+		return utils.HandleError(errors.New("already decided"), "Err: Only pending bookings can be approved or rejected!"), models.RoomBooking{}, 0
+	}
+
+	booking.Status = status
+	booking.DecidedBy = decidedBy
+	booking.DecidedAt = time.Now().Format(time.DateTime)
+	booking.DecisionNote = note
+
+	query := "UPDATE room_bookings SET status = ?, decided_by = ?, decided_at = ?, decision_note = ? WHERE status = 'pending' AND "
+	args := []interface{}{booking.Status, booking.DecidedBy, booking.DecidedAt, booking.DecisionNote}
+	if booking.SeriesId != 0 {
+		query += "series_id = ?"
+		args = append(args, booking.SeriesId)
+	} else {
+		query += "id = ?"
+		args = append(args, booking.Id)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update booking!"), models.RoomBooking{}, 0
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update booking!"), models.RoomBooking{}, 0
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.RoomBooking{}, 0
+	}
+	return nil, booking, int(affected)
+}
+
+// CancelRoomBookingDbHandler - Cancels an upcoming booking, or with series this and every later occurrence of its series;
+// Only the person who booked can cancel unless manager is set; returns the number of bookings cancelled;
+func CancelRoomBookingDbHandler(ctx context.Context, id int, series bool, userId int, manager bool) (error, int) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), 0
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), 0
+	}
+
+	err, booking := getRoomBooking(tx, id, true)
+	if err != nil {
+		tx.Rollback()
+		return err, 0
+	}
+	if !manager && booking.BookedBy != userId {
+		tx.Rollback()
+		return utils.HandleError(errors.New("access denied"), "Err: Access denied!"), 0
+	}
+
+	now := time.Now().Format(time.DateTime)
+	if (booking.Status != "pending" && booking.Status != "approved") || booking.EndAt <= now {
+		tx.Rollback()
+		return utils.HandleError(errors.New("cannot cancel"), "Err: Only upcoming pending or approved bookings can be cancelled!"), 0
+	}
+
+	query := "UPDATE room_bookings SET status = 'cancelled' WHERE status IN ('pending', 'approved') AND "
+	var args []interface{}
+	if series && booking.SeriesId != 0 {
+		query += "series_id = ? AND start_at >= ?"
+		args = append(args, booking.SeriesId, booking.StartAt)
+	} else {
+		query += "id = ?"
+		args = append(args, booking.Id)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot cancel booking!"), 0
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot cancel booking!"), 0
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), 0
+	}
+	return nil, int(affected)
+}
+
+// GetRoomAvailabilityDbHandler - Active rooms free between ?start= and ?end=, optionally of a ?type= and ?min_capacity=;
+func GetRoomAvailabilityDbHandler(r *http.Request) (error, []models.Room) {
+	start, end, err := ParseBookingRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"))
+	if err != nil {
+		return err, nil
+	}
+
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + roomColumns + ` FROM rooms r WHERE r.active = 1
+		AND NOT EXISTS (SELECT 1 FROM room_bookings b WHERE b.room_id = r.id AND b.status IN ('pending', 'approved') AND b.start_at < ? AND b.end_at > ?)
+		AND NOT EXISTS (SELECT 1 FROM timetable_slots ts WHERE ts.room_id = r.id AND ts.day_of_week = ? AND ts.start_time < ? AND ts.end_time > ?)`
+	args := []interface{}{end.Format(time.DateTime), start.Format(time.DateTime), isoWeekday(start), end.Format(time.TimeOnly), start.Format(time.TimeOnly)}
+
+	if roomType := r.URL.Query().Get("type"); roomType != "" {
+		query += " AND r.type = ?"
+		args = append(args, roomType)
+	}
+	if minCapacity := r.URL.Query().Get("min_capacity"); minCapacity != "" {
+		query += " AND r.capacity >= ?"
+		args = append(args, minCapacity)
+	}
+	// Smallest suitable rooms first;
+	query += " ORDER BY r.capacity, r.name"
+
+	return getRooms(db, query, args...)
+}
@@ -0,0 +1,177 @@
+package sqlconnect
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+func insertSession(db dbExecutor, session models.Session, tokenHash string) (error, models.Session) {
+	session.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := db.Exec("INSERT INTO sessions (family_id, exec_id, token_hash, expires_at, user_agent, ip_address, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		session.FamilyId, session.ExecId, tokenHash, session.ExpiresAt, session.UserAgent, session.IpAddress, session.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot create session!"), models.Session{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot create session!"), models.Session{}
+	}
+	session.Id = int(lastId)
+	return nil, session
+}
+
+// CreateSessionDbHandler - Starts a new session family for a login; the exec's long expired sessions are pruned on the way;
+func CreateSessionDbHandler(ctx context.Context, session models.Session, tokenHash string) (error, models.Session) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Session{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	family := make([]byte, 16)
+	_, err = rand.Read(family)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot create session!"), models.Session{}
+	}
+	session.FamilyId = hex.EncodeToString(family)
+
+	// Expired rows are kept for a day, so that a late replay of a rotated token is still recognised;
+	_, err = db.Exec("DELETE FROM sessions WHERE exec_id = ? AND expires_at < ?", session.ExecId, time.Now().AddDate(0, 0, -1).Format(time.DateTime))
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot prune sessions!"), models.Session{}
+	}
+
+	return insertSession(db, session, tokenHash)
+}
+
+// RotateSessionDbHandler - Exchanges a refresh token for its successor (next, stored under newHash) in the same family;
+// A token that was already exchanged means it was copied: the whole family is revoked and the refresh refused;
+// Returns the exec the session belongs to, for signing the new access token;
+func RotateSessionDbHandler(ctx context.Context, tokenHash, newHash string, next models.Session) (error, models.Exec, models.Session) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}, models.Session{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Exec{}, models.Session{}
+	}
+
+	now := time.Now().Format(time.DateTime)
+	var session models.Session
+	var exec models.Exec
+	var usedAt, revokedAt sql.NullString
+	var expired bool
+	err = tx.QueryRow(`SELECT s.id, s.family_id, s.exec_id, s.used_at, s.revoked_at, s.expires_at <= ?, e.username, e.role, e.inactive_status
+		FROM sessions s JOIN execs e ON e.id = s.exec_id WHERE s.token_hash = ? FOR UPDATE`, now, tokenHash).
+		Scan(&session.Id, &session.FamilyId, &session.ExecId, &usedAt, &revokedAt, &expired, &exec.Username, &exec.Role, &exec.Inactive)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: Invalid refresh token!"), models.Exec{}, models.Session{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Exec{}, models.Session{}
+	}
+	exec.Id = session.ExecId
+
+	if revokedAt.Valid {
+		tx.Rollback()
+		return utils.HandleError(errors.New("revoked refresh token"), "Err: Session has been revoked, please log in again!"), models.Exec{}, models.Session{}
+	}
+
+	if usedAt.Valid {
+		_, err = tx.Exec("UPDATE sessions SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", now, session.FamilyId)
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot revoke session!"), models.Exec{}, models.Session{}
+		}
+		err = tx.Commit()
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Exec{}, models.Session{}
+		}
+		log.Printf("Refresh token reuse detected for exec %d, session family %s revoked", session.ExecId, session.FamilyId)
+		return utils.HandleError(errors.New("refresh token reuse"), "Err: Refresh token was already used, please log in again!"), models.Exec{}, models.Session{}
+	}
+
+	if expired {
+		tx.Rollback()
+		return utils.HandleError(errors.New("expired refresh token"), "Err: Session expired, please log in again!"), models.Exec{}, models.Session{}
+	}
+
+	if exec.Inactive {
+		tx.Rollback()
+		return utils.HandleError(errors.New("inactive user"), "Err: User is inactive!"), models.Exec{}, models.Session{}
+	}
+
+	_, err = tx.Exec("UPDATE sessions SET used_at = ? WHERE id = ?", now, session.Id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update session!"), models.Exec{}, models.Session{}
+	}
+
+	next.FamilyId = session.FamilyId
+	next.ExecId = session.ExecId
+	err, next = insertSession(tx, next, newHash)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Exec{}, models.Session{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Exec{}, models.Session{}
+	}
+	return nil, exec, next
+}
+
+// RevokeSessionDbHandler - Ends the session family the refresh token belongs to (logout); an unknown token is ignored;
+func RevokeSessionDbHandler(ctx context.Context, tokenHash string) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var familyId string
+	err = db.QueryRow("SELECT family_id FROM sessions WHERE token_hash = ?", tokenHash).Scan(&familyId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+
+	_, err = db.Exec("UPDATE sessions SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", time.Now().Format(time.DateTime), familyId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot revoke session!")
+	}
+	return nil
+}
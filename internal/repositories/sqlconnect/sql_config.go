@@ -1,17 +1,20 @@
 package sqlconnect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"os"
+	"schoolManagement/pkg/utils"
 )
 
-func ConnectDb() (*sql.DB, error) {
+// openDb - Opens a connection to one database of the configured server;
+func openDb(dbName string) (*sql.DB, error) {
 
 	usr := os.Getenv("DB_USER")
 	pwd := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
 	host := os.Getenv("DB_HOST")
 	port := os.Getenv("DB_PORT")
 
@@ -25,3 +28,34 @@ func ConnectDb() (*sql.DB, error) {
 	fmt.Println("Connected to database!")
 	return db, nil
 }
+
+// ConnectControlDb - Connects to the control database (DB_NAME) holding the school registry and super-admins;
+func ConnectControlDb() (*sql.DB, error) {
+	return openDb(os.Getenv("DB_NAME"))
+}
+
+// ConnectDb - Connects to the database of the school (tenant) the request was resolved to;
+// Every school has its own database, so a query can never reach another school's rows, whatever its filters;
+// Without a resolved school the connection is refused;
+func ConnectDb(ctx context.Context) (*sql.DB, error) {
+	slug := utils.TenantFromContext(ctx)
+	if slug == "" {
+		return nil, utils.HandleError(errors.New("no tenant in context"), "Err: No school selected!")
+	}
+
+	err, tenant := getCachedTenant(slug)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.Active {
+		return nil, utils.HandleError(errors.New("inactive tenant"), "Err: The school is not active!")
+	}
+	return openDb(tenant.DbName)
+}
+
+// dbExecutor - Query methods shared by *sql.DB and *sql.Tx, so that helpers can run inside or outside a transaction;
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
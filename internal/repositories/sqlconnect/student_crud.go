@@ -1,6 +1,7 @@
 package sqlconnect
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -11,13 +12,61 @@ import (
 	"reflect"
 	"schoolManagement/internal/models"
 	"schoolManagement/pkg/utils"
+	"time"
 )
 
+// teacherEditableStudentFields - Fields a teacher may change on the students of their classes;
+var teacherEditableStudentFields = map[string]bool{"notes": true}
+
+// studentScope - Row filter on students for the caller in the context (set by the JWT middleware);
+// Teachers only reach the students of the classes they teach: their own class and the classes of their timetable slots;
+func studentScope(ctx context.Context) (string, []interface{}) {
+	return studentScopeOn(ctx, "class")
+}
+
+// studentScopeOn - studentScope for queries joining students under an alias, classColumn being e.g. "s.class";
+func studentScopeOn(ctx context.Context, classColumn string) (string, []interface{}) {
+	if utils.RoleFromContext(ctx) != "teacher" {
+		return "", nil
+	}
+
+	execId := utils.UserIdFromContext(ctx)
+	clause := ` AND ` + classColumn + ` IN (
+		SELECT t.class FROM teachers t WHERE t.exec_id = ?
+		UNION SELECT ts.class FROM timetable_slots ts JOIN teachers t ON t.id = ts.teacher_id WHERE t.exec_id = ?)`
+	return clause, []interface{}{execId, execId}
+}
+
+// authorizeStudentChange - Checks that the caller may change the given fields of a student;
+// Teachers may only change the notes of the students in their scope;
+func authorizeStudentChange(ctx context.Context, db dbExecutor, id interface{}, fields []string) error {
+	if utils.RoleFromContext(ctx) != "teacher" {
+		return nil
+	}
+
+	for _, field := range fields {
+		if !teacherEditableStudentFields[field] {
+			return utils.HandleError(errors.New("field not editable by teachers"), "Err: Teachers can only change the notes of a student!")
+		}
+	}
+
+	scope, scopeArgs := studentScope(ctx)
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM students WHERE id = ?"+scope, append([]interface{}{id}, scopeArgs...)...).Scan(&count)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot get student from db!")
+	}
+	if count == 0 {
+		return utils.HandleError(errors.New("student out of scope"), "Err: No student found!")
+	}
+	return nil
+}
+
 // ******** DB Crud Handlers ********
 
 // GetStudentsDbHandler - Fetches students list from DB;
 func GetStudentsDbHandler(r *http.Request, limit, page int) (error, []models.Student, int) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), []models.Student{}, 0
 	}
@@ -30,11 +79,20 @@ func GetStudentsDbHandler(r *http.Request, limit, page int) (error, []models.Stu
 	}()
 
 	var students []models.Student
-	query := "SELECT id, first_name, last_name, email, class FROM students WHERE 1=1"
+	query := "SELECT id, first_name, last_name, email, class, inactive_status, " + hasPhotoColumn("student", "students") + ", roll_number, notes FROM students WHERE 1=1"
 	var args []interface{}
 
 	query, args = utils.GetFilters(r, query, args)
 
+	// Withdrawn and transferred students are left out unless asked for;
+	statusFilter := studentStatusFilter(r)
+	query += statusFilter
+
+	// Teachers only list the students of their classes;
+	scope, scopeArgs := studentScope(r.Context())
+	query += scope
+	args = append(args, scopeArgs...)
+
 	// Adding pagination;
 	offset := (page - 1) * limit
 	query += " LIMIT ? OFFSET ?"
@@ -57,16 +115,19 @@ func GetStudentsDbHandler(r *http.Request, limit, page int) (error, []models.Stu
 
 	for rows.Next() {
 		var student models.Student
-		err = rows.Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class)
+		var rollNumber, notes sql.NullString
+		err = rows.Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class, &student.Inactive, &student.HasPhoto, &rollNumber, &notes)
 		if err != nil {
 			return utils.HandleError(err, "Err: Data retrieval failed!"), []models.Student{}, 0
 		}
+		student.RollNumber = rollNumber.String
+		student.Notes = notes.String
 
 		students = append(students, student)
 	}
 
 	var totalStudents int
-	err = db.QueryRow("SELECT COUNT(*) FROM students").Scan(&totalStudents)
+	err = db.QueryRow("SELECT COUNT(*) FROM students WHERE 1=1"+statusFilter+scope, scopeArgs...).Scan(&totalStudents)
 	if err != nil {
 		utils.HandleError(err, "Err: Query execution failed!")
 		totalStudents = 0
@@ -75,8 +136,47 @@ func GetStudentsDbHandler(r *http.Request, limit, page int) (error, []models.Stu
 	return nil, students, totalStudents
 }
 
-func GetStudentHandler(id int) (error, models.Student) {
-	db, err := ConnectDb()
+// studentStatusFilter - Active students only by default; ?status=inactive lists withdrawn/transferred students and ?include_inactive=true lists everyone;
+func studentStatusFilter(r *http.Request) string {
+	if r.URL.Query().Get("include_inactive") == "true" {
+		return ""
+	}
+	if r.URL.Query().Get("status") == "inactive" {
+		return " AND inactive_status = 1"
+	}
+	return " AND inactive_status = 0"
+}
+
+// StudentVisibleDbHandler - Whether the student is within the caller's scope (studentScope), for the student sub-resources;
+// Only teachers are limited, so no query is made for the other roles;
+func StudentVisibleDbHandler(ctx context.Context, studentId int) (error, bool) {
+	scope, scopeArgs := studentScope(ctx)
+	if scope == "" {
+		return nil, true
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM students WHERE id = ?"+scope, append([]interface{}{studentId}, scopeArgs...)...).Scan(&count)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot get student from db!"), false
+	}
+	return nil, count > 0
+}
+
+func GetStudentHandler(ctx context.Context, id int) (error, models.Student) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), models.Student{}
 	}
@@ -88,20 +188,25 @@ func GetStudentHandler(id int) (error, models.Student) {
 		}
 	}()
 
+	// Students outside a teacher's classes are reported as not found;
+	scope, scopeArgs := studentScope(ctx)
 	var student models.Student
-	err = db.QueryRow("SELECT id, first_name, last_name, email, class FROM students WHERE id = ?", id).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class)
+	var rollNumber, notes sql.NullString
+	err = db.QueryRow("SELECT id, first_name, last_name, email, class, inactive_status, "+hasPhotoColumn("student", "students")+", roll_number, notes FROM students WHERE id = ?"+scope, append([]interface{}{id}, scopeArgs...)...).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class, &student.Inactive, &student.HasPhoto, &rollNumber, &notes)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return utils.HandleError(err, "Err: No records found!"), models.Student{}
 		}
 		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Student{}
 	}
+	student.RollNumber = rollNumber.String
+	student.Notes = notes.String
 	return nil, student
 }
 
 // AddStudentsDbHandler - Handles the crud operation to store new student details in table;
 func AddStudentsDbHandler(r *http.Request) (error, []models.Student) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), []models.Student{}
 	}
@@ -183,6 +288,10 @@ func AddStudentsDbHandler(r *http.Request) (error, []models.Student) {
 		values := reflect.ValueOf(student)
 		for i := 0; i < values.NumField(); i++ {
 			val := values.Field(i)
+			// Fields without a db tag are generated by the server (e.g. roll_number);
+			if values.Type().Field(i).Tag.Get("db") == "" {
+				continue
+			}
 			if val.Kind() == reflect.String && val.String() == "" {
 				return utils.HandleError(err, "Err: Cannot parse request body!"), nil
 			}
@@ -191,7 +300,7 @@ func AddStudentsDbHandler(r *http.Request) (error, []models.Student) {
 	}
 	log.Println("\nStatement execution begins")
 	// Loops through the incoming students arrays and executes the insert statement for store the values in DB;
-	for _, student := range students {
+	for i, student := range students {
 		values := utils.GetFieldValues(student)
 		log.Println("\nField values", values)
 
@@ -205,7 +314,18 @@ func AddStudentsDbHandler(r *http.Request) (error, []models.Student) {
 			return utils.HandleError(err, "Err: Cannot add student to database!"), nil
 		}
 
-		student.Id = int(lastId)
+		students[i].Id = int(lastId)
+
+		students[i].RollNumber, err = assignRollNumber(db, students[i].Id, student.Class)
+		if err != nil {
+			return err, nil
+		}
+
+		// Starts the enrollment history of the new student;
+		err = openEnrollment(db, students[i].Id, student.Class, utils.AcademicYear(time.Now()), 0)
+		if err != nil {
+			return err, nil
+		}
 	}
 
 	// Returns the final students array;
@@ -213,9 +333,9 @@ func AddStudentsDbHandler(r *http.Request) (error, []models.Student) {
 }
 
 // UpdateStudentsDbHandler - Handles the update operation of students;
-func UpdateStudentsDbHandler(id int, updatedStudent models.Student) (error, []models.Student) {
+func UpdateStudentsDbHandler(ctx context.Context, id int, updatedStudent models.Student) (error, []models.Student) {
 	// Connect to DB;
-	db, err := ConnectDb()
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), []models.Student{}
 	}
@@ -227,6 +347,12 @@ func UpdateStudentsDbHandler(id int, updatedStudent models.Student) (error, []mo
 		}
 	}()
 
+	// Teachers cannot replace a student's details;
+	err = authorizeStudentChange(ctx, db, id, []string{"first_name", "last_name", "email", "class"})
+	if err != nil {
+		return err, nil
+	}
+
 	var student models.Student
 	// Fetch student details based on ID;
 	err = db.QueryRow("SELECT id, first_name, last_name, email, class FROM students WHERE id = ?", id).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class)
@@ -237,17 +363,35 @@ func UpdateStudentsDbHandler(id int, updatedStudent models.Student) (error, []mo
 		return utils.HandleError(err, "Err: Cannot get student from db!"), nil
 	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), nil
+	}
+
 	// Execute the update query;
 	updatedStudent.Id = int(student.Id)
-	_, err = db.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ? WHERE id = ?", updatedStudent.FirstName, updatedStudent.LastName, updatedStudent.Email, updatedStudent.Class, student.Id)
+	_, err = tx.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ? WHERE id = ?", updatedStudent.FirstName, updatedStudent.LastName, updatedStudent.Email, updatedStudent.Class, student.Id)
 	if err != nil {
+		tx.Rollback()
 		return utils.HandleError(err, "Err: Cannot update student in db!"), nil
 	}
+
+	// Keeps the enrollment history when the class changes;
+	err = recordClassChange(tx, student.Id, student.Class, updatedStudent.Class)
+	if err != nil {
+		tx.Rollback()
+		return err, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), nil
+	}
 	return nil, []models.Student{updatedStudent}
 }
 
-func PatchStudentsDbHandler(students []map[string]interface{}) error {
-	db, err := ConnectDb()
+func PatchStudentsDbHandler(ctx context.Context, students []map[string]interface{}) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
@@ -270,8 +414,21 @@ func PatchStudentsDbHandler(students []map[string]interface{}) error {
 		id := fmt.Sprintf("%v", student["id"])
 		log.Println("\nStudent ID: ", id)
 
+		fields := []string{}
+		for k := range student {
+			if k != "id" {
+				fields = append(fields, k)
+			}
+		}
+		err = authorizeStudentChange(ctx, db, id, fields)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		var studentFromDb models.Student
-		err = db.QueryRow("SELECT id, first_name, last_name, email, class FROM students WHERE id = ?", id).Scan(&studentFromDb.Id, &studentFromDb.FirstName, &studentFromDb.LastName, &studentFromDb.Email, &studentFromDb.Class)
+		var notes sql.NullString
+		err = db.QueryRow("SELECT id, first_name, last_name, email, class, notes FROM students WHERE id = ?", id).Scan(&studentFromDb.Id, &studentFromDb.FirstName, &studentFromDb.LastName, &studentFromDb.Email, &studentFromDb.Class, &notes)
 		if err != nil {
 			tx.Rollback()
 			if errors.Is(err, sql.ErrNoRows) {
@@ -280,6 +437,8 @@ func PatchStudentsDbHandler(students []map[string]interface{}) error {
 			return utils.HandleError(err, "Err: Cannot get student from db!")
 		}
 
+		studentFromDb.Notes = notes.String
+		previousClass := studentFromDb.Class
 		studentVal := reflect.ValueOf(&studentFromDb).Elem()
 		studentType := studentVal.Type()
 
@@ -311,7 +470,7 @@ func PatchStudentsDbHandler(students []map[string]interface{}) error {
 				}
 			}
 
-			_, err = tx.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ? WHERE id = ?", studentFromDb.FirstName, studentFromDb.LastName, studentFromDb.Email, studentFromDb.Class, id)
+			_, err = tx.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ?, notes = ? WHERE id = ?", studentFromDb.FirstName, studentFromDb.LastName, studentFromDb.Email, studentFromDb.Class, studentFromDb.Notes, id)
 			if err != nil {
 				tx.Rollback()
 				if errors.Is(err, sql.ErrNoRows) {
@@ -321,6 +480,12 @@ func PatchStudentsDbHandler(students []map[string]interface{}) error {
 			}
 		}
 
+		// Keeps the enrollment history when the class changes;
+		err = recordClassChange(tx, studentFromDb.Id, previousClass, studentFromDb.Class)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 	err = tx.Commit()
 	if err != nil {
@@ -330,8 +495,8 @@ func PatchStudentsDbHandler(students []map[string]interface{}) error {
 	return nil
 }
 
-func PatchStudentDbHandler(id int, updatedStudent map[string]interface{}) (error, models.Student) {
-	db, err := ConnectDb()
+func PatchStudentDbHandler(ctx context.Context, id int, updatedStudent map[string]interface{}) (error, models.Student) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), models.Student{}
 	}
@@ -343,15 +508,27 @@ func PatchStudentDbHandler(id int, updatedStudent map[string]interface{}) (error
 		}
 	}()
 
+	fields := []string{}
+	for k := range updatedStudent {
+		fields = append(fields, k)
+	}
+	err = authorizeStudentChange(ctx, db, id, fields)
+	if err != nil {
+		return err, models.Student{}
+	}
+
 	var student models.Student
-	err = db.QueryRow("SELECT id, first_name, last_name, email, class FROM students WHERE id = ?", id).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class)
+	var notes sql.NullString
+	err = db.QueryRow("SELECT id, first_name, last_name, email, class, notes FROM students WHERE id = ?", id).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class, &notes)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return utils.HandleError(err, "Err: No student found!!"), models.Student{}
 		}
 		return utils.HandleError(err, "Err: Cannot get student from db!"), models.Student{}
 	}
+	student.Notes = notes.String
 
+	previousClass := student.Class
 	studentVal := reflect.ValueOf(&student).Elem()
 	studentType := studentVal.Type()
 
@@ -366,19 +543,37 @@ func PatchStudentDbHandler(id int, updatedStudent map[string]interface{}) (error
 		}
 	}
 
-	_, err = db.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ? WHERE id = ?", student.FirstName, student.LastName, student.Email, student.Class, id)
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Student{}
+	}
+
+	_, err = tx.Exec("UPDATE students SET first_name = ?, last_name = ?, email = ?, class = ?, notes = ? WHERE id = ?", student.FirstName, student.LastName, student.Email, student.Class, student.Notes, id)
 	if err != nil {
+		tx.Rollback()
 		if errors.Is(err, sql.ErrNoRows) {
 			return utils.HandleError(err, "Err: No student found!!"), models.Student{}
 		}
 		return utils.HandleError(err, "Err: Cannot update student in db!"), models.Student{}
 	}
 
+	// Keeps the enrollment history when the class changes;
+	err = recordClassChange(tx, student.Id, previousClass, student.Class)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Student{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Student{}
+	}
+
 	return nil, student
 }
 
-func DeleteStudentsDbHandler(ids []int) (error, []int) {
-	db, err := ConnectDb()
+func DeleteStudentsDbHandler(ctx context.Context, ids []int) (error, []int) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!"), nil
 	}
@@ -468,8 +663,8 @@ func DeleteStudentsDbHandler(ids []int) (error, []int) {
 	return err, deletedIds
 }
 
-func DeleteStudentDbHandler(id int) error {
-	db, err := ConnectDb()
+func DeleteStudentDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		return utils.HandleError(err, "Err: Internal server error!")
 	}
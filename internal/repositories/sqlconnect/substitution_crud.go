@@ -0,0 +1,307 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// maxSubstituteSuggestions - Number of free teachers suggested per uncovered period;
+const maxSubstituteSuggestions = 5
+
+const substitutionColumns = "s.id, s.leave_id, s.slot_id, s.date, s.substitute_teacher_id, s.assigned_by, s.created_at"
+
+// scanSubstitution - Scans a single substitution row selected with substitutionColumns;
+func scanSubstitution(scanner interface{ Scan(...interface{}) error }, substitution *models.Substitution) error {
+	return scanner.Scan(&substitution.Id, &substitution.LeaveId, &substitution.SlotId, &substitution.Date, &substitution.SubstituteTeacherId, &substitution.AssignedBy, &substitution.CreatedAt)
+}
+
+// teacherFreeCondition - Matches teachers (alias t) who have no class, no substitution and no approved leave in a period;
+// Arguments: day of week, period, date, period, date, date;
+const teacherFreeCondition = `NOT EXISTS (SELECT 1 FROM timetable_slots own WHERE own.teacher_id = t.id AND own.day_of_week = ? AND own.period = ?)
+	AND NOT EXISTS (SELECT 1 FROM substitutions sub JOIN timetable_slots subslot ON subslot.id = sub.slot_id WHERE sub.substitute_teacher_id = t.id AND sub.date = ? AND subslot.period = ?)
+	AND NOT EXISTS (SELECT 1 FROM leave_requests lr WHERE lr.teacher_id = t.id AND lr.status = 'approved' AND lr.start_date <= ? AND lr.end_date >= ?)`
+
+// suggestSubstitutes - Finds free teachers for a slot on a date;
+// Teachers of the same subject come first, then the ones with the fewest substitutions that day;
+func suggestSubstitutes(db dbExecutor, slot models.TimetableSlot, date string) (error, []models.SubstituteSuggestion) {
+	query := `SELECT t.id, t.first_name, t.last_name, t.subject, t.subject = ? AS qualified FROM teachers t
+	WHERE t.id <> ? AND ` + teacherFreeCondition + `
+	ORDER BY qualified DESC, (SELECT COUNT(*) FROM substitutions load_s WHERE load_s.substitute_teacher_id = t.id AND load_s.date = ?), t.id
+	LIMIT ?`
+
+	rows, err := db.Query(query, slot.Subject, slot.TeacherId, slot.DayOfWeek, slot.Period, date, slot.Period, date, date, date, maxSubstituteSuggestions)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	suggestions := []models.SubstituteSuggestion{}
+	for rows.Next() {
+		var suggestion models.SubstituteSuggestion
+		err = rows.Scan(&suggestion.TeacherId, &suggestion.FirstName, &suggestion.LastName, &suggestion.Subject, &suggestion.Qualified)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return nil, suggestions
+}
+
+// getSlotSubstitution - Returns the substitution of a slot on a date, or nil when the period is uncovered;
+func getSlotSubstitution(db dbExecutor, slotId int, date string) (error, *models.Substitution) {
+	var substitution models.Substitution
+	err := scanSubstitution(db.QueryRow("SELECT "+substitutionColumns+" FROM substitutions s WHERE s.slot_id = ? AND s.date = ?", slotId, date), &substitution)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+	}
+	return nil, &substitution
+}
+
+// coverPeriod - Builds the cover state of a slot on a date;
+func coverPeriod(db dbExecutor, leaveId int, slot models.TimetableSlot, date string) (error, models.CoverPeriod) {
+	period := models.CoverPeriod{Date: date, LeaveId: leaveId, Slot: slot}
+
+	err, substitution := getSlotSubstitution(db, slot.Id, date)
+	if err != nil {
+		return err, models.CoverPeriod{}
+	}
+	if substitution != nil {
+		period.Substitution = substitution
+		return nil, period
+	}
+
+	err, period.Suggestions = suggestSubstitutes(db, slot, date)
+	if err != nil {
+		return err, models.CoverPeriod{}
+	}
+	return nil, period
+}
+
+// GetUncoveredPeriodsDbHandler - Lists the periods of a day whose teacher is on approved leave and that have no substitute yet;
+func GetUncoveredPeriodsDbHandler(ctx context.Context, date string) (error, []models.CoverPeriod) {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return utils.HandleError(err, "Err: Date must be in YYYY-MM-DD format!"), nil
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	// Overlapping approved leaves of one teacher list the period once, against the earliest leave;
+	query := "SELECT " + timetableSlotColumns + `, MIN(l.id) FROM leave_requests l
+	JOIN timetable_slots ts ON ts.teacher_id = l.teacher_id
+	WHERE l.status = 'approved' AND l.start_date <= ? AND l.end_date >= ? AND ts.day_of_week = ?
+	AND NOT EXISTS (SELECT 1 FROM substitutions s WHERE s.slot_id = ts.id AND s.date = ?)
+	GROUP BY ts.id
+	ORDER BY ts.period, ts.class`
+
+	rows, err := db.Query(query, date, date, isoWeekday(day), date)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+
+	periods := []models.CoverPeriod{}
+	for rows.Next() {
+		period := models.CoverPeriod{Date: date}
+		slot := &period.Slot
+		var roomId sql.NullInt64
+		err = rows.Scan(&slot.Id, &slot.TeacherId, &slot.Class, &slot.Subject, &slot.DayOfWeek, &slot.Period, &slot.StartTime, &slot.EndTime, &roomId, &period.LeaveId)
+		if err != nil {
+			rows.Close()
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		slot.RoomId = int(roomId.Int64)
+		periods = append(periods, period)
+	}
+	rows.Close()
+
+	for i := range periods {
+		err, periods[i].Suggestions = suggestSubstitutes(db, periods[i].Slot, date)
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, periods
+}
+
+// AssignSubstituteDbHandler - Assigns a free teacher to a period whose teacher is on approved leave;
+func AssignSubstituteDbHandler(ctx context.Context, substitution models.Substitution) (error, models.Substitution) {
+	day, err := time.Parse(time.DateOnly, substitution.Date)
+	if err != nil {
+		return utils.HandleError(err, "Err: Date must be in YYYY-MM-DD format!"), models.Substitution{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Substitution{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Substitution{}
+	}
+
+	err, slot := getTimetableSlot(tx, substitution.SlotId)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Substitution{}
+	}
+
+	if slot.DayOfWeek != isoWeekday(day) {
+		tx.Rollback()
+		return utils.HandleError(errors.New("wrong day"), "Err: The timetable slot does not take place on this date!"), models.Substitution{}
+	}
+
+	if slot.TeacherId == substitution.SubstituteTeacherId {
+		tx.Rollback()
+		return utils.HandleError(errors.New("same teacher"), "Err: A teacher cannot substitute their own period!"), models.Substitution{}
+	}
+
+	// The slot's teacher must be on approved leave that day;
+	err = tx.QueryRow("SELECT id FROM leave_requests WHERE teacher_id = ? AND status = 'approved' AND start_date <= ? AND end_date >= ? LIMIT 1",
+		slot.TeacherId, substitution.Date, substitution.Date).Scan(&substitution.LeaveId)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: The teacher of this period is not on approved leave!"), models.Substitution{}
+		}
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Substitution{}
+	}
+
+	var free int
+	err = tx.QueryRow("SELECT COUNT(*) FROM teachers t WHERE t.id = ? AND "+teacherFreeCondition,
+		substitution.SubstituteTeacherId, slot.DayOfWeek, slot.Period, substitution.Date, slot.Period, substitution.Date, substitution.Date).Scan(&free)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Substitution{}
+	}
+	if free == 0 {
+		tx.Rollback()
+		return utils.HandleError(errors.New("teacher busy"), "Err: The substitute teacher does not exist or is not free in this period!"), models.Substitution{}
+	}
+
+	substitution.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := tx.Exec("INSERT INTO substitutions (leave_id, slot_id, date, substitute_teacher_id, assigned_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		substitution.LeaveId, substitution.SlotId, substitution.Date, substitution.SubstituteTeacherId, substitution.AssignedBy, substitution.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot assign substitute, the period may already be covered!"), models.Substitution{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot assign substitute!"), models.Substitution{}
+	}
+	substitution.Id = int(lastId)
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Substitution{}
+	}
+
+	return nil, substitution
+}
+
+// GetSubstitutionsDbHandler - Fetches substitutions filtered by date, leave_id or substitute_teacher_id;
+func GetSubstitutionsDbHandler(r *http.Request) (error, []models.Substitution) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + substitutionColumns + " FROM substitutions s WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"date":                  "s.date",
+		"leave_id":              "s.leave_id",
+		"substitute_teacher_id": "s.substitute_teacher_id",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	query += " ORDER BY s.date, s.slot_id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	substitutions := []models.Substitution{}
+	for rows.Next() {
+		var substitution models.Substitution
+		err = scanSubstitution(rows, &substitution)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		substitutions = append(substitutions, substitution)
+	}
+	return nil, substitutions
+}
+
+// DeleteSubstitutionDbHandler - Removes a substitute assignment, leaving the period uncovered again;
+func DeleteSubstitutionDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("DELETE FROM substitutions WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete substitution!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete substitution!")
+	}
+	if rowsAffected == 0 {
+		return utils.HandleError(errors.New("not found"), "Err: No substitution found!")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package sqlconnect
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -81,7 +82,7 @@ func addFilters(r *http.Request, query string, args []interface{}) (string, []in
 }
 
 func GetTeachersDbHandler(r *http.Request, teachersList []models.Teacher) (error, []models.Teacher) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -95,7 +96,7 @@ func GetTeachersDbHandler(r *http.Request, teachersList []models.Teacher) (error
 		}
 	}()
 
-	query := "SELECT id, first_name, last_name, class, subject, email  FROM teachers WHERE 1=1"
+	query := "SELECT id, first_name, last_name, class, subject, email, " + hasPhotoColumn("teacher", "teachers") + ", employee_id FROM teachers WHERE 1=1"
 	var args []interface{}
 
 	query, args = addFilters(r, query, args)
@@ -117,19 +118,21 @@ func GetTeachersDbHandler(r *http.Request, teachersList []models.Teacher) (error
 
 	for rows.Next() {
 		var teacher models.Teacher
-		err = rows.Scan(&teacher.Id, &teacher.FirstName, &teacher.LastName, &teacher.Class, &teacher.Subject, &teacher.Email)
+		var employeeId sql.NullString
+		err = rows.Scan(&teacher.Id, &teacher.FirstName, &teacher.LastName, &teacher.Class, &teacher.Subject, &teacher.Email, &teacher.HasPhoto, &employeeId)
 		if err != nil {
 			//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			fmt.Println("Error", err)
 			return utils.HandleError(err, "Err : Internal server error!"), nil
 		}
+		teacher.EmployeeId = employeeId.String
 		teachersList = append(teachersList, teacher)
 	}
 	return err, teachersList
 }
 
-func GetTeacherDbHandler(idStr string) (error, models.Teacher) {
-	db, err := ConnectDb()
+func GetTeacherDbHandler(ctx context.Context, idStr string) (error, models.Teacher) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -143,8 +146,10 @@ func GetTeacherDbHandler(idStr string) (error, models.Teacher) {
 		}
 	}()
 	var teacher models.Teacher
+	var employeeId sql.NullString
 	// Handling param based query;
-	err = db.QueryRow("SELECT id, first_name, last_name, class, subject, email  FROM teachers WHERE id=?", idStr).Scan(&teacher.Id, &teacher.FirstName, &teacher.LastName, &teacher.Class, &teacher.Subject, &teacher.Email)
+	err = db.QueryRow("SELECT id, first_name, last_name, class, subject, email, "+hasPhotoColumn("teacher", "teachers")+", employee_id FROM teachers WHERE id=?", idStr).Scan(&teacher.Id, &teacher.FirstName, &teacher.LastName, &teacher.Class, &teacher.Subject, &teacher.Email, &teacher.HasPhoto, &employeeId)
+	teacher.EmployeeId = employeeId.String
 	if errors.Is(err, sql.ErrNoRows) {
 		//http.Error(w, "Do records not found", http.StatusNotFound)
 		fmt.Println("Error", err)
@@ -158,7 +163,7 @@ func GetTeacherDbHandler(idStr string) (error, models.Teacher) {
 }
 
 func AddTeacherDbHandler(w http.ResponseWriter, r *http.Request, newTeachers []models.Teacher) (error, []models.Teacher) {
-	db, err := ConnectDb()
+	db, err := ConnectDb(r.Context())
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -245,6 +250,10 @@ func AddTeacherDbHandler(w http.ResponseWriter, r *http.Request, newTeachers []m
 		val := reflect.ValueOf(teacher)
 		for i := 0; i < val.NumField(); i++ {
 			field := val.Field(i)
+			// Fields without a db tag are generated by the server (e.g. employee_id);
+			if val.Type().Field(i).Tag.Get("db") == "" {
+				continue
+			}
 			if field.Kind() == reflect.String && field.String() == "" {
 				fmt.Println("field.Kind() : ", field.Kind())
 				fmt.Println("reflect.String : ", reflect.String)
@@ -274,6 +283,14 @@ func AddTeacherDbHandler(w http.ResponseWriter, r *http.Request, newTeachers []m
 		}
 
 		teacher.Id = int(lastId)
+		teacher.EmployeeId, err = assignEmployeeId(db, teacher.Id, teacher.Class)
+		if err != nil {
+			return err, nil
+		}
+		err = linkTeacherToExec(db, teacher.Id, teacher.Email)
+		if err != nil {
+			return err, nil
+		}
 		addedTeachers[i] = teacher
 
 	}
@@ -329,8 +346,8 @@ func getStructValues(model interface{}) []interface{} {
 	return values
 }
 
-func UpdateTeachersDbHandler(id int, updatedTeachers models.Teacher) error {
-	db, err := ConnectDb()
+func UpdateTeachersDbHandler(ctx context.Context, id int, updatedTeachers models.Teacher) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : Internal server error!", err)
@@ -367,8 +384,8 @@ func UpdateTeachersDbHandler(id int, updatedTeachers models.Teacher) error {
 	return utils.HandleError(err, "Internal server error!")
 }
 
-func PatchTeachersDbHandler(updates []map[string]interface{}) error {
-	db, err := ConnectDb()
+func PatchTeachersDbHandler(ctx context.Context, updates []map[string]interface{}) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -456,8 +473,8 @@ func PatchTeachersDbHandler(updates []map[string]interface{}) error {
 	return nil
 }
 
-func PatchTeacherDbHandler(id int, updates map[string]interface{}) (error, models.Teacher) {
-	db, err := ConnectDb()
+func PatchTeacherDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Teacher) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -512,8 +529,8 @@ func PatchTeacherDbHandler(id int, updates map[string]interface{}) (error, model
 	return err, existingTeacher
 }
 
-func DeleteTeacherDbHandler(id int) error {
-	db, err := ConnectDb()
+func DeleteTeacherDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -549,8 +566,8 @@ func DeleteTeacherDbHandler(id int) error {
 	return nil
 }
 
-func DeleteTeachersDbHandler(ids []int) (error, []int) {
-	db, err := ConnectDb()
+func DeleteTeachersDbHandler(ctx context.Context, ids []int) (error, []int) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		//http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		fmt.Println("Err : DB connection failed!", err)
@@ -626,8 +643,8 @@ func DeleteTeachersDbHandler(ids []int) (error, []int) {
 	return err, deletedIds
 }
 
-func GetStudentsByTeacherDbHandler(w http.ResponseWriter, teacherId string, students []models.Student) (error, []models.Student) {
-	db, err := ConnectDb()
+func GetStudentsByTeacherDbHandler(ctx context.Context, w http.ResponseWriter, teacherId string, students []models.Student) (error, []models.Student) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -636,8 +653,10 @@ func GetStudentsByTeacherDbHandler(w http.ResponseWriter, teacherId string, stud
 
 	defer db.Close()
 
-	query := "SELECT id, first_name, last_name, class, email FROM students WHERE class = (SELECT class FROM teachers WHERE id = ?)"
-	rows, err := db.Query(query, teacherId)
+	// A teacher asking for another teacher's class only gets the students they teach themselves;
+	scope, scopeArgs := studentScope(ctx)
+	query := "SELECT id, first_name, last_name, class, email FROM students WHERE class = (SELECT class FROM teachers WHERE id = ?) AND inactive_status = 0" + scope
+	rows, err := db.Query(query, append([]interface{}{teacherId}, scopeArgs...)...)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return nil, nil
@@ -663,8 +682,8 @@ func GetStudentsByTeacherDbHandler(w http.ResponseWriter, teacherId string, stud
 	return err, students
 }
 
-func GetStudentsCountByTeacherDbHandler(w http.ResponseWriter, teacherId string) (error, int) {
-	db, err := ConnectDb()
+func GetStudentsCountByTeacherDbHandler(ctx context.Context, w http.ResponseWriter, teacherId string) (error, int) {
+	db, err := ConnectDb(ctx)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -674,8 +693,9 @@ func GetStudentsCountByTeacherDbHandler(w http.ResponseWriter, teacherId string)
 	defer db.Close()
 
 	var count int
-	query := "SELECT COUNT(*) FROM students WHERE class = (SELECT class FROM teachers WHERE id = ?)"
-	err = db.QueryRow(query, teacherId).Scan(&count)
+	scope, scopeArgs := studentScope(ctx)
+	query := "SELECT COUNT(*) FROM students WHERE class = (SELECT class FROM teachers WHERE id = ?) AND inactive_status = 0" + scope
+	err = db.QueryRow(query, append([]interface{}{teacherId}, scopeArgs...)...).Scan(&count)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return nil, 0
@@ -683,3 +703,44 @@ func GetStudentsCountByTeacherDbHandler(w http.ResponseWriter, teacherId string)
 
 	return err, count
 }
+
+// GetTeacherIdByExecDbHandler - Resolves the teacher record linked to a logged-in exec user;
+func GetTeacherIdByExecDbHandler(ctx context.Context, execId int) (error, int) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), 0
+	}
+
+	defer db.Close()
+
+	var teacherId int
+	query := "SELECT id FROM teachers WHERE exec_id = ?"
+	err = db.QueryRow(query, execId).Scan(&teacherId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No teacher linked to this user!"), 0
+		}
+		return utils.HandleError(err, "Err: Cannot get teacher from db!"), 0
+	}
+
+	return nil, teacherId
+}
+
+// linkTeacherToExec - Links a new teacher to the exec account with the same email, unless another teacher holds it already;
+func linkTeacherToExec(db dbExecutor, teacherId int, email string) error {
+	_, err := db.Exec(`UPDATE teachers t JOIN execs e ON e.email = ? LEFT JOIN teachers linked ON linked.exec_id = e.id
+		SET t.exec_id = e.id WHERE t.id = ? AND t.exec_id IS NULL AND linked.id IS NULL`, email, teacherId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot link teacher to user!")
+	}
+	return nil
+}
+
+// linkExecToTeacher - Links a new exec account to the unlinked teacher with the same email, if there is one;
+func linkExecToTeacher(db dbExecutor, execId int, email string) error {
+	_, err := db.Exec("UPDATE teachers SET exec_id = ? WHERE email = ? AND exec_id IS NULL ORDER BY id LIMIT 1", execId, email)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot link user to teacher!")
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package sqlconnect
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tenantCacheTTL - How long the school registry is kept in memory before it is read again;
+const tenantCacheTTL = time.Minute
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+var tenantDbNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// tenantCache - The registry is read on almost every request, so it is cached by slug and by host;
+var tenantCache = struct {
+	sync.Mutex
+	bySlug   map[string]models.Tenant
+	byHost   map[string]string
+	loadedAt time.Time
+}{}
+
+const tenantColumns = "id, slug, name, db_name, host, active, created_at"
+
+func scanTenant(scanner interface{ Scan(...interface{}) error }, tenant *models.Tenant) error {
+	var host sql.NullString
+	err := scanner.Scan(&tenant.Id, &tenant.Slug, &tenant.Name, &tenant.DbName, &host, &tenant.Active, &tenant.CreatedAt)
+	if err != nil {
+		return err
+	}
+	tenant.Host = host.String
+	return nil
+}
+
+func getTenants(db dbExecutor) (error, []models.Tenant) {
+	rows, err := db.Query("SELECT " + tenantColumns + " FROM tenants ORDER BY name")
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+	for rows.Next() {
+		var tenant models.Tenant
+		err = scanTenant(rows, &tenant)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		tenants = append(tenants, tenant)
+	}
+	return nil, tenants
+}
+
+// loadTenantCache - Reloads the registry once it is older than tenantCacheTTL (or always when forced); the caller holds the lock;
+func loadTenantCache(force bool) error {
+	if !force && tenantCache.bySlug != nil && time.Since(tenantCache.loadedAt) < tenantCacheTTL {
+		return nil
+	}
+
+	db, err := ConnectControlDb()
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, tenants := getTenants(db)
+	if err != nil {
+		return err
+	}
+
+	tenantCache.bySlug = map[string]models.Tenant{}
+	tenantCache.byHost = map[string]string{}
+	for _, tenant := range tenants {
+		tenantCache.bySlug[tenant.Slug] = tenant
+		if tenant.Host != "" {
+			tenantCache.byHost[strings.ToLower(tenant.Host)] = tenant.Slug
+		}
+	}
+	tenantCache.loadedAt = time.Now()
+	return nil
+}
+
+// invalidateTenantCache - Forces the next lookup to read the registry again;
+func invalidateTenantCache() {
+	tenantCache.Lock()
+	defer tenantCache.Unlock()
+	tenantCache.bySlug = nil
+}
+
+func getCachedTenant(slug string) (error, models.Tenant) {
+	tenantCache.Lock()
+	defer tenantCache.Unlock()
+
+	err := loadTenantCache(false)
+	if err != nil {
+		return err, models.Tenant{}
+	}
+
+	tenant, ok := tenantCache.bySlug[slug]
+	if !ok {
+		return utils.HandleError(errors.New("unknown tenant "+slug), "Err: Unknown school!"), models.Tenant{}
+	}
+	return nil, tenant
+}
+
+// ResolveTenantDbHandler - Finds the school by slug, or by host name when no slug is given;
+// An unknown host is not an error (ok is false), as the API is also reached on the trust's own host;
+func ResolveTenantDbHandler(slug, host string) (error, models.Tenant, bool) {
+	if slug != "" {
+		err, tenant := getCachedTenant(slug)
+		if err != nil {
+			return err, models.Tenant{}, false
+		}
+		return nil, tenant, true
+	}
+
+	tenantCache.Lock()
+	defer tenantCache.Unlock()
+
+	err := loadTenantCache(false)
+	if err != nil {
+		return err, models.Tenant{}, false
+	}
+
+	slug, ok := tenantCache.byHost[strings.ToLower(host)]
+	if !ok {
+		return nil, models.Tenant{}, false
+	}
+	return nil, tenantCache.bySlug[slug], true
+}
+
+// ValidateTenant - Checks the slug, name and database name of a school;
+func ValidateTenant(tenant models.Tenant) error {
+	if !tenantSlugPattern.MatchString(tenant.Slug) {
+		return utils.HandleError(errors.New("invalid slug"), "Err: Slug must be lowercase letters, digits and dashes (at most 63)!")
+	}
+	if strings.TrimSpace(tenant.Name) == "" || len(tenant.Name) > 255 {
+		return utils.HandleError(errors.New("invalid name"), "Err: Name must be between 1 and 255 characters!")
+	}
+	if !tenantDbNamePattern.MatchString(tenant.DbName) {
+		return utils.HandleError(errors.New("invalid database"), "Err: Database name must be letters, digits and underscores (at most 64)!")
+	}
+	if len(tenant.Host) > 255 {
+		return utils.HandleError(errors.New("invalid host"), "Err: Host must be at most 255 characters!")
+	}
+	return nil
+}
+
+// GetTenantsDbHandler - Lists the schools of the trust;
+func GetTenantsDbHandler() (error, []models.Tenant) {
+	db, err := ConnectControlDb()
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getTenants(db)
+}
+
+// AddTenantDbHandler - Registers a school; its database must already hold the school schema (see migrations/);
+func AddTenantDbHandler(tenant models.Tenant) (error, models.Tenant) {
+	db, err := ConnectControlDb()
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Tenant{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var provisioned int
+	err = db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = 'execs'", tenant.DbName).Scan(&provisioned)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.Tenant{}
+	}
+	if provisioned == 0 {
+		return utils.HandleError(errors.New("database not provisioned"), "Err: Database "+tenant.DbName+" does not hold a school schema!"), models.Tenant{}
+	}
+
+	tenant.Host = strings.ToLower(strings.TrimSpace(tenant.Host))
+	tenant.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := db.Exec("INSERT INTO tenants (slug, name, db_name, host, active, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		tenant.Slug, tenant.Name, tenant.DbName, sql.NullString{String: tenant.Host, Valid: tenant.Host != ""}, tenant.Active, tenant.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add school, the slug, database or host may already be registered!"), models.Tenant{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add school!"), models.Tenant{}
+	}
+	tenant.Id = int(lastId)
+
+	invalidateTenantCache()
+	return nil, tenant
+}
+
+// PatchTenantDbHandler - Updates the name, host or active flag of a school; slug and database are fixed once registered;
+func PatchTenantDbHandler(id int, updates map[string]interface{}) (error, models.Tenant) {
+	db, err := ConnectControlDb()
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Tenant{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var tenant models.Tenant
+	err = scanTenant(db.QueryRow("SELECT "+tenantColumns+" FROM tenants WHERE id = ?", id), &tenant)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No school found!"), models.Tenant{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Tenant{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "name", "host":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Tenant{}
+			}
+			if k == "name" {
+				tenant.Name = value
+			} else {
+				tenant.Host = strings.ToLower(strings.TrimSpace(value))
+			}
+		case "active":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for active!"), models.Tenant{}
+			}
+			tenant.Active = value
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Tenant{}
+		}
+	}
+
+	err = ValidateTenant(tenant)
+	if err != nil {
+		return err, models.Tenant{}
+	}
+
+	_, err = db.Exec("UPDATE tenants SET name = ?, host = ?, active = ? WHERE id = ?",
+		tenant.Name, sql.NullString{String: tenant.Host, Valid: tenant.Host != ""}, tenant.Active, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update school, the host may already be registered!"), models.Tenant{}
+	}
+
+	invalidateTenantCache()
+	return nil, tenant
+}
+
+// GetTenantSummariesDbHandler - Head counts of every school, read from each school's database;
+// A school whose database cannot be read is reported with its error instead of failing the overview;
+func GetTenantSummariesDbHandler() (error, []models.TenantSummary) {
+	err, tenants := GetTenantsDbHandler()
+	if err != nil {
+		return err, nil
+	}
+
+	summaries := []models.TenantSummary{}
+	for _, tenant := range tenants {
+		summary := models.TenantSummary{Slug: tenant.Slug, Name: tenant.Name, Active: tenant.Active}
+
+		db, err := openDb(tenant.DbName)
+		if err == nil {
+			err = db.QueryRow(`SELECT (SELECT COUNT(*) FROM students WHERE inactive_status = 0),
+				(SELECT COUNT(*) FROM teachers), (SELECT COUNT(*) FROM execs WHERE inactive_status = 0)`).
+				Scan(&summary.Students, &summary.Teachers, &summary.Execs)
+			db.Close()
+		}
+		if err != nil {
+			summary.Error = utils.HandleError(err, "Err: Cannot read school database!").Error()
+		}
+		summaries = append(summaries, summary)
+	}
+	return nil, summaries
+}
+
+// SuperAdminLoginDbHandler - Fetches a super-admin account by username;
+func SuperAdminLoginDbHandler(username string) (error, models.SuperAdmin) {
+	db, err := ConnectControlDb()
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.SuperAdmin{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var admin models.SuperAdmin
+	err = db.QueryRow("SELECT id, username, email, password, inactive_status FROM super_admins WHERE username = ?", username).
+		Scan(&admin.Id, &admin.Username, &admin.Email, &admin.Password, &admin.Inactive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: Invalid username or password!"), models.SuperAdmin{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.SuperAdmin{}
+	}
+	return nil, admin
+}
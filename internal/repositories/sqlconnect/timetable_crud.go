@@ -0,0 +1,195 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+const timetableSlotColumns = "ts.id, ts.teacher_id, ts.class, ts.subject, ts.day_of_week, ts.period, ts.start_time, ts.end_time, ts.room_id"
+
+// scanTimetableSlot - Scans a single slot row selected with timetableSlotColumns;
+func scanTimetableSlot(scanner interface{ Scan(...interface{}) error }, slot *models.TimetableSlot) error {
+	var roomId sql.NullInt64
+	err := scanner.Scan(&slot.Id, &slot.TeacherId, &slot.Class, &slot.Subject, &slot.DayOfWeek, &slot.Period, &slot.StartTime, &slot.EndTime, &roomId)
+	if err != nil {
+		return err
+	}
+	slot.RoomId = int(roomId.Int64)
+	return nil
+}
+
+// isoWeekday - Day of week of a date with Monday as 1 and Sunday as 7;
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// ValidateTimetableSlot - Checks the mandatory fields of a timetable slot;
+func ValidateTimetableSlot(slot models.TimetableSlot) error {
+	if slot.TeacherId == 0 || slot.Class == "" || slot.Subject == "" || slot.Period <= 0 {
+		return utils.HandleError(errors.New("missing fields"), "Err: Teacher, class, subject and period are required!")
+	}
+
+	if slot.DayOfWeek < 1 || slot.DayOfWeek > 7 {
+		return utils.HandleError(errors.New("invalid day"), "Err: Day of week must be between 1 (Monday) and 7 (Sunday)!")
+	}
+
+	start, err := time.Parse(time.TimeOnly, slot.StartTime)
+	if err != nil {
+		return utils.HandleError(err, "Err: Start time must be in HH:MM:SS format!")
+	}
+	end, err := time.Parse(time.TimeOnly, slot.EndTime)
+	if err != nil || !end.After(start) {
+		return utils.HandleError(errors.New("invalid end time"), "Err: End time must be in HH:MM:SS format and after the start time!")
+	}
+	return nil
+}
+
+// GetTimetableSlotsDbHandler - Fetches timetable slots filtered by teacher_id, class, subject, day_of_week or room_id;
+func GetTimetableSlotsDbHandler(r *http.Request) (error, []models.TimetableSlot) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + timetableSlotColumns + " FROM timetable_slots ts WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"teacher_id":  "ts.teacher_id",
+		"class":       "ts.class",
+		"subject":     "ts.subject",
+		"day_of_week": "ts.day_of_week",
+		"room_id":     "ts.room_id",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	query += " ORDER BY ts.day_of_week, ts.period, ts.class"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	slots := []models.TimetableSlot{}
+	for rows.Next() {
+		var slot models.TimetableSlot
+		err = scanTimetableSlot(rows, &slot)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		slots = append(slots, slot)
+	}
+	return nil, slots
+}
+
+// AddTimetableSlotDbHandler - Adds a slot; a teacher, a class and a room can only have one slot per period;
+// A room must also be free of bookings on that weekday from now on;
+func AddTimetableSlotDbHandler(ctx context.Context, slot models.TimetableSlot) (error, models.TimetableSlot) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TimetableSlot{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var clashes int
+	err = db.QueryRow("SELECT COUNT(*) FROM timetable_slots WHERE day_of_week = ? AND period = ? AND (teacher_id = ? OR class = ?)", slot.DayOfWeek, slot.Period, slot.TeacherId, slot.Class).Scan(&clashes)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.TimetableSlot{}
+	}
+	if clashes > 0 {
+		return utils.HandleError(errors.New("slot clash"), "Err: The teacher or the class already has a slot in this period!"), models.TimetableSlot{}
+	}
+
+	var roomId sql.NullInt64
+	if slot.RoomId != 0 {
+		err = checkTimetableRoom(db, slot)
+		if err != nil {
+			return err, models.TimetableSlot{}
+		}
+		roomId = sql.NullInt64{Int64: int64(slot.RoomId), Valid: true}
+	}
+
+	res, err := db.Exec("INSERT INTO timetable_slots (teacher_id, class, subject, day_of_week, period, start_time, end_time, room_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		slot.TeacherId, slot.Class, slot.Subject, slot.DayOfWeek, slot.Period, slot.StartTime, slot.EndTime, roomId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add timetable slot!"), models.TimetableSlot{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add timetable slot!"), models.TimetableSlot{}
+	}
+	slot.Id = int(lastId)
+
+	return nil, slot
+}
+
+// getTimetableSlot - Fetches a single slot;
+func getTimetableSlot(db dbExecutor, id int) (error, models.TimetableSlot) {
+	var slot models.TimetableSlot
+	err := scanTimetableSlot(db.QueryRow("SELECT "+timetableSlotColumns+" FROM timetable_slots ts WHERE ts.id = ?", id), &slot)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No timetable slot found!"), models.TimetableSlot{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TimetableSlot{}
+	}
+	return nil, slot
+}
+
+// DeleteTimetableSlotDbHandler - Removes a slot together with its substitutions;
+func DeleteTimetableSlotDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("DELETE FROM timetable_slots WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete timetable slot!")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete timetable slot!")
+	}
+	if rowsAffected == 0 {
+		return utils.HandleError(errors.New("not found"), "Err: No timetable slot found!")
+	}
+	return nil
+}
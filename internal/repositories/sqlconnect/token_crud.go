@@ -0,0 +1,197 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"schoolManagement/pkg/utils"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenCutoffTTL - How long the token cutoff of an exec is kept in memory; the JWT middleware reads it on every request;
+const tokenCutoffTTL = 30 * time.Second
+
+type tokenCutoff struct {
+	validAfter time.Time
+	loadedAt   time.Time
+}
+
+// tokenCutoffs - Token cutoff per school and exec ("<school>:<exec id>");
+var tokenCutoffs = struct {
+	sync.Mutex
+	entries map[string]tokenCutoff
+}{entries: map[string]tokenCutoff{}}
+
+func tokenCutoffKey(ctx context.Context, execId int) string {
+	return utils.TenantFromContext(ctx) + ":" + strconv.Itoa(execId)
+}
+
+// forgetTokenCutoff - Drops the cached cutoff of an exec after it moved;
+func forgetTokenCutoff(ctx context.Context, execId int) {
+	tokenCutoffs.Lock()
+	defer tokenCutoffs.Unlock()
+	delete(tokenCutoffs.entries, tokenCutoffKey(ctx, execId))
+}
+
+// parseStoredTime - Reads a timestamp stored as DATETIME or, as password_changed_at historically was, RFC 3339;
+func parseStoredTime(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// GetTokenCutoffDbHandler - Time before which the exec's access tokens are no longer accepted:
+// the later of the last password change and the last "log out everywhere" (zero when neither happened);
+func GetTokenCutoffDbHandler(ctx context.Context, execId int) (error, time.Time) {
+	key := tokenCutoffKey(ctx, execId)
+	tokenCutoffs.Lock()
+	cached, ok := tokenCutoffs.entries[key]
+	tokenCutoffs.Unlock()
+	if ok && time.Since(cached.loadedAt) < tokenCutoffTTL {
+		return nil, cached.validAfter
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), time.Time{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var passwordChangedAt, tokensRevokedAt sql.NullString
+	err = db.QueryRow("SELECT password_changed_at, tokens_revoked_at FROM execs WHERE id = ?", execId).Scan(&passwordChangedAt, &tokensRevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No user found!"), time.Time{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), time.Time{}
+	}
+
+	var validAfter time.Time
+	for _, value := range []sql.NullString{passwordChangedAt, tokensRevokedAt} {
+		if !value.Valid || value.String == "" {
+			continue
+		}
+		t, err := parseStoredTime(value.String)
+		if err != nil {
+			return utils.HandleError(err, "Err: Invalid timestamp!"), time.Time{}
+		}
+		if t.After(validAfter) {
+			validAfter = t
+		}
+	}
+
+	tokenCutoffs.Lock()
+	tokenCutoffs.entries[key] = tokenCutoff{validAfter: validAfter, loadedAt: time.Now()}
+	tokenCutoffs.Unlock()
+	return nil, validAfter
+}
+
+// revokeExecSessions - Revokes every refresh token of an exec;
+func revokeExecSessions(db dbExecutor, execId int, now string) error {
+	_, err := db.Exec("UPDATE sessions SET revoked_at = ? WHERE exec_id = ? AND revoked_at IS NULL", now, execId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot revoke sessions!")
+	}
+	return nil
+}
+
+// RevokeAllTokensDbHandler - Logs an exec out everywhere: all access tokens issued so far and all refresh tokens stop working;
+func RevokeAllTokensDbHandler(ctx context.Context, execId int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	now := time.Now().Format(time.DateTime)
+	_, err = tx.Exec("UPDATE execs SET tokens_revoked_at = ? WHERE id = ?", now, execId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot revoke tokens!")
+	}
+
+	err = revokeExecSessions(tx, execId, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+
+	forgetTokenCutoff(ctx, execId)
+	return nil
+}
+
+// RevokeTokenDbHandler - Records a revoked access token until it expires; expired records are pruned on the way;
+func RevokeTokenDbHandler(ctx context.Context, jti string, execId int, expires time.Time) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	now := time.Now().Format(time.DateTime)
+	_, err = db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", now)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot prune revoked tokens!")
+	}
+
+	_, err = db.Exec("INSERT IGNORE INTO revoked_tokens (jti, exec_id, expires_at, revoked_at) VALUES (?, ?, ?, ?)",
+		jti, execId, expires.Format(time.DateTime), now)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot revoke token!")
+	}
+	return nil
+}
+
+// IsTokenRevokedDbHandler - Whether an access token was recorded as revoked;
+func IsTokenRevokedDbHandler(ctx context.Context, jti string) (error, bool) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?", jti).Scan(&count)
+	if err != nil {
+		return utils.HandleError(err, "Err: Data retrieval failed!"), false
+	}
+	return nil, count > 0
+}
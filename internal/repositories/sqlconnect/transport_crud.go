@@ -0,0 +1,1479 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const vehicleColumns = "v.id, v.registration, v.model, v.capacity, v.active, v.created_at"
+
+func scanVehicle(scanner interface{ Scan(...interface{}) error }, vehicle *models.Vehicle) error {
+	return scanner.Scan(&vehicle.Id, &vehicle.Registration, &vehicle.Model, &vehicle.Capacity, &vehicle.Active, &vehicle.CreatedAt)
+}
+
+const driverColumns = "d.id, d.first_name, d.last_name, d.phone, d.license_number, d.license_expiry, d.active, d.created_at"
+
+func scanDriver(scanner interface{ Scan(...interface{}) error }, driver *models.Driver) error {
+	return scanner.Scan(&driver.Id, &driver.FirstName, &driver.LastName, &driver.Phone, &driver.LicenseNumber, &driver.LicenseExpiry, &driver.Active, &driver.CreatedAt)
+}
+
+// routeColumns - Riders counts the assignments running today;
+const routeColumns = "r.id, r.name, r.vehicle_id, r.driver_id, r.active, r.created_at, COALESCE(v.capacity, 0), " +
+	"(SELECT COUNT(*) FROM transport_assignments a WHERE a.route_id = r.id AND a.start_date <= CURDATE() AND (a.end_date IS NULL OR a.end_date >= CURDATE()))"
+
+const routeTables = " FROM transport_routes r LEFT JOIN transport_vehicles v ON v.id = r.vehicle_id"
+
+func scanRoute(scanner interface{ Scan(...interface{}) error }, route *models.TransportRoute) error {
+	var vehicleId, driverId sql.NullInt64
+	err := scanner.Scan(&route.Id, &route.Name, &vehicleId, &driverId, &route.Active, &route.CreatedAt, &route.Capacity, &route.Riders)
+	if err != nil {
+		return err
+	}
+	route.VehicleId = int(vehicleId.Int64)
+	route.DriverId = int(driverId.Int64)
+	return nil
+}
+
+const stopColumns = "st.id, st.route_id, st.sequence, st.name, st.address, st.pickup_time, st.dropoff_time"
+
+func scanStop(scanner interface{ Scan(...interface{}) error }, stop *models.TransportStop) error {
+	var dropoff sql.NullString
+	err := scanner.Scan(&stop.Id, &stop.RouteId, &stop.Sequence, &stop.Name, &stop.Address, &stop.PickupTime, &dropoff)
+	if err != nil {
+		return err
+	}
+	stop.DropoffTime = dropoff.String
+	return nil
+}
+
+const assignmentColumns = "a.id, a.student_id, s.first_name, s.last_name, s.class, a.route_id, r.name, a.stop_id, st.name, st.pickup_time, st.dropoff_time, " +
+	"a.weekdays, a.start_date, a.end_date, a.created_at"
+
+const assignmentTables = " FROM transport_assignments a JOIN students s ON s.id = a.student_id " +
+	"JOIN transport_routes r ON r.id = a.route_id JOIN transport_stops st ON st.id = a.stop_id"
+
+func scanAssignment(scanner interface{ Scan(...interface{}) error }, assignment *models.TransportAssignment) error {
+	var dropoff, endDate sql.NullString
+	var weekdays string
+	err := scanner.Scan(&assignment.Id, &assignment.StudentId, &assignment.FirstName, &assignment.LastName, &assignment.Class, &assignment.RouteId,
+		&assignment.RouteName, &assignment.StopId, &assignment.StopName, &assignment.PickupTime, &dropoff, &weekdays, &assignment.StartDate, &endDate, &assignment.CreatedAt)
+	if err != nil {
+		return err
+	}
+	assignment.DropoffTime = dropoff.String
+	assignment.EndDate = endDate.String
+	assignment.Weekdays = parseWeekdays(weekdays)
+	return nil
+}
+
+// parseWeekdays - Reads the comma separated ISO weekdays of an assignment;
+func parseWeekdays(value string) []int {
+	weekdays := []int{}
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(part)
+		if err == nil {
+			weekdays = append(weekdays, day)
+		}
+	}
+	return weekdays
+}
+
+func formatWeekdays(weekdays []int) string {
+	sorted := append([]int(nil), weekdays...)
+	sort.Ints(sorted)
+
+	parts := make([]string, len(sorted))
+	for i, day := range sorted {
+		parts[i] = strconv.Itoa(day)
+	}
+	return strings.Join(parts, ",")
+}
+
+// nullableId - Stores 0 as NULL for optional foreign keys;
+func nullableId(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// ValidateVehicle - Checks the registration and seating capacity of a vehicle;
+func ValidateVehicle(vehicle models.Vehicle) error {
+	if strings.TrimSpace(vehicle.Registration) == "" || len(vehicle.Registration) > 32 {
+		return utils.HandleError(errors.New("invalid registration"), "Err: Registration must be between 1 and 32 characters!")
+	}
+	if vehicle.Capacity < 1 {
+		return utils.HandleError(errors.New("invalid capacity"), "Err: Capacity must be at least 1!")
+	}
+	return nil
+}
+
+// ValidateDriver - Checks the name, phone and licence of a driver;
+func ValidateDriver(driver models.Driver) error {
+	if strings.TrimSpace(driver.FirstName) == "" || strings.TrimSpace(driver.LastName) == "" {
+		return utils.HandleError(errors.New("missing name"), "Err: First and last name are required!")
+	}
+	if strings.TrimSpace(driver.Phone) == "" || len(driver.Phone) > 32 {
+		return utils.HandleError(errors.New("invalid phone"), "Err: Phone must be between 1 and 32 characters!")
+	}
+	if strings.TrimSpace(driver.LicenseNumber) == "" || len(driver.LicenseNumber) > 64 {
+		return utils.HandleError(errors.New("invalid licence"), "Err: Licence number must be between 1 and 64 characters!")
+	}
+	_, err := time.Parse(time.DateOnly, driver.LicenseExpiry)
+	if err != nil {
+		return utils.HandleError(err, "Err: Licence expiry must be in YYYY-MM-DD format!")
+	}
+	return nil
+}
+
+// ValidateTransportRoute - Checks the name and stops of a route; pickup times must follow the running order;
+func ValidateTransportRoute(route models.TransportRoute) error {
+	if strings.TrimSpace(route.Name) == "" || len(route.Name) > 255 {
+		return utils.HandleError(errors.New("invalid name"), "Err: Route name must be between 1 and 255 characters!")
+	}
+	return validateStops(route.Stops)
+}
+
+func validateStops(stops []models.TransportStop) error {
+	var previous time.Time
+	for i, stop := range stops {
+		if strings.TrimSpace(stop.Name) == "" || len(stop.Name) > 255 {
+			return utils.HandleError(errors.New("invalid stop"), "Err: Stop "+strconv.Itoa(i+1)+" needs a name of at most 255 characters!")
+		}
+		pickup, err := time.Parse(time.TimeOnly, stop.PickupTime)
+		if err != nil {
+			return utils.HandleError(err, "Err: Pickup time of stop "+stop.Name+" must be in HH:MM:SS format!")
+		}
+		if i > 0 && !pickup.After(previous) {
+			return utils.HandleError(errors.New("stops out of order"), "Err: Pickup times must increase along the route!")
+		}
+		previous = pickup
+
+		if stop.DropoffTime != "" {
+			_, err = time.Parse(time.TimeOnly, stop.DropoffTime)
+			if err != nil {
+				return utils.HandleError(err, "Err: Drop-off time of stop "+stop.Name+" must be in HH:MM:SS format!")
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateTransportAssignment - Checks the student, route, stop, weekdays and dates of an assignment;
+func ValidateTransportAssignment(assignment models.TransportAssignment) error {
+	if assignment.StudentId == 0 || assignment.RouteId == 0 || assignment.StopId == 0 {
+		return utils.HandleError(errors.New("missing fields"), "Err: Student, route and stop are required!")
+	}
+	if len(assignment.Weekdays) == 0 {
+		return utils.HandleError(errors.New("no weekdays"), "Err: At least one weekday is required!")
+	}
+
+	seen := map[int]bool{}
+	for _, day := range assignment.Weekdays {
+		if day < 1 || day > 7 || seen[day] {
+			return utils.HandleError(errors.New("invalid weekday"), "Err: Weekdays must be distinct days between 1 (Monday) and 7 (Sunday)!")
+		}
+		seen[day] = true
+	}
+
+	start, err := time.Parse(time.DateOnly, assignment.StartDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Start date must be in YYYY-MM-DD format!")
+	}
+	if assignment.EndDate != "" {
+		end, err := time.Parse(time.DateOnly, assignment.EndDate)
+		if err != nil || end.Before(start) {
+			return utils.HandleError(errors.New("invalid end date"), "Err: End date must be in YYYY-MM-DD format and not before the start date!")
+		}
+	}
+	return nil
+}
+
+func getVehicle(db dbExecutor, id int) (error, models.Vehicle) {
+	var vehicle models.Vehicle
+	err := scanVehicle(db.QueryRow("SELECT "+vehicleColumns+" FROM transport_vehicles v WHERE v.id = ?", id), &vehicle)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No vehicle found!"), models.Vehicle{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Vehicle{}
+	}
+	return nil, vehicle
+}
+
+func getDriver(db dbExecutor, id int) (error, models.Driver) {
+	var driver models.Driver
+	err := scanDriver(db.QueryRow("SELECT "+driverColumns+" FROM transport_drivers d WHERE d.id = ?", id), &driver)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No driver found!"), models.Driver{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Driver{}
+	}
+	return nil, driver
+}
+
+// routePeakLoad - Highest number of riders on any weekday of the route between start and end (open ended when empty);
+func routePeakLoad(db dbExecutor, routeId int, start, end string, excludeId int) (int, map[int]int, error) {
+	query := "SELECT weekdays FROM transport_assignments WHERE route_id = ? AND id <> ? AND (end_date IS NULL OR end_date >= ?)"
+	args := []interface{}{routeId, excludeId, start}
+	if end != "" {
+		query += " AND start_date <= ?"
+		args = append(args, end)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, nil, utils.HandleError(err, "Err: Query execution failed!")
+	}
+	defer rows.Close()
+
+	load := map[int]int{}
+	peak := 0
+	for rows.Next() {
+		var weekdays string
+		err = rows.Scan(&weekdays)
+		if err != nil {
+			return 0, nil, utils.HandleError(err, "Err: Data retrieval failed!")
+		}
+		for _, day := range parseWeekdays(weekdays) {
+			load[day]++
+			peak = max(peak, load[day])
+		}
+	}
+	return peak, load, nil
+}
+
+// AddVehicleDbHandler - Adds a vehicle to the fleet;
+func AddVehicleDbHandler(ctx context.Context, vehicle models.Vehicle) (error, models.Vehicle) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Vehicle{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	vehicle.Registration = strings.ToUpper(strings.TrimSpace(vehicle.Registration))
+	vehicle.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := db.Exec("INSERT INTO transport_vehicles (registration, model, capacity, active, created_at) VALUES (?, ?, ?, ?, ?)",
+		vehicle.Registration, vehicle.Model, vehicle.Capacity, vehicle.Active, vehicle.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add vehicle, the registration may already exist!"), models.Vehicle{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add vehicle!"), models.Vehicle{}
+	}
+	vehicle.Id = int(lastId)
+	return nil, vehicle
+}
+
+// GetVehiclesDbHandler - Lists the fleet, filtered by ?active=true|false;
+func GetVehiclesDbHandler(r *http.Request) (error, []models.Vehicle) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + vehicleColumns + " FROM transport_vehicles v WHERE 1=1"
+	switch r.URL.Query().Get("active") {
+	case "true":
+		query += " AND v.active = 1"
+	case "false":
+		query += " AND v.active = 0"
+	}
+	query += " ORDER BY v.registration"
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	vehicles := []models.Vehicle{}
+	for rows.Next() {
+		var vehicle models.Vehicle
+		err = scanVehicle(rows, &vehicle)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+	return nil, vehicles
+}
+
+// PatchVehicleDbHandler - Updates registration, model, capacity or active; capacity cannot drop below the riders of its routes;
+func PatchVehicleDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Vehicle) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Vehicle{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, vehicle := getVehicle(db, id)
+	if err != nil {
+		return err, models.Vehicle{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "registration", "model":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Vehicle{}
+			}
+			if k == "registration" {
+				vehicle.Registration = strings.ToUpper(strings.TrimSpace(value))
+			} else {
+				vehicle.Model = value
+			}
+		case "capacity":
+			value, ok := v.(float64)
+			if !ok || value != math.Trunc(value) {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for capacity!"), models.Vehicle{}
+			}
+			vehicle.Capacity = int(value)
+		case "active":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for active!"), models.Vehicle{}
+			}
+			vehicle.Active = value
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Vehicle{}
+		}
+	}
+
+	err = ValidateVehicle(vehicle)
+	if err != nil {
+		return err, models.Vehicle{}
+	}
+
+	if _, ok := updates["capacity"]; ok {
+		rows, err := db.Query("SELECT id, name FROM transport_routes WHERE vehicle_id = ?", id)
+		if err != nil {
+			return utils.HandleError(err, "Err: Query execution failed!"), models.Vehicle{}
+		}
+		routes := map[int]string{}
+		for rows.Next() {
+			var routeId int
+			var name string
+			err = rows.Scan(&routeId, &name)
+			if err != nil {
+				rows.Close()
+				return utils.HandleError(err, "Err: Data retrieval failed!"), models.Vehicle{}
+			}
+			routes[routeId] = name
+		}
+		rows.Close()
+
+		for routeId, name := range routes {
+			peak, _, err := routePeakLoad(db, routeId, time.Now().Format(time.DateOnly), "", 0)
+			if err != nil {
+				return err, models.Vehicle{}
+			}
+			if peak > vehicle.Capacity {
+				return utils.HandleError(errors.New("over capacity"), "Err: Route "+name+" has "+strconv.Itoa(peak)+" riders on its busiest day!"), models.Vehicle{}
+			}
+		}
+	}
+
+	_, err = db.Exec("UPDATE transport_vehicles SET registration = ?, model = ?, capacity = ?, active = ? WHERE id = ?",
+		vehicle.Registration, vehicle.Model, vehicle.Capacity, vehicle.Active, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update vehicle, the registration may already exist!"), models.Vehicle{}
+	}
+	return nil, vehicle
+}
+
+// DeleteVehicleDbHandler - Removes a vehicle; its routes are left without a vehicle;
+func DeleteVehicleDbHandler(ctx context.Context, id int) error {
+	return deleteTransportRow(ctx, "transport_vehicles", "vehicle", id)
+}
+
+// DeleteDriverDbHandler - Removes a driver; their routes are left without a driver;
+func DeleteDriverDbHandler(ctx context.Context, id int) error {
+	return deleteTransportRow(ctx, "transport_drivers", "driver", id)
+}
+
+func deleteTransportRow(ctx context.Context, table, name string, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	res, err := db.Exec("DELETE FROM "+table+" WHERE id = ?", id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete "+name+"!")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot delete "+name+"!")
+	}
+	if affected == 0 {
+		return utils.HandleError(sql.ErrNoRows, "Err: No "+name+" found!")
+	}
+	return nil
+}
+
+// AddDriverDbHandler - Adds a driver with their licence details;
+func AddDriverDbHandler(ctx context.Context, driver models.Driver) (error, models.Driver) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Driver{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	driver.CreatedAt = time.Now().Format(time.DateTime)
+	res, err := db.Exec("INSERT INTO transport_drivers (first_name, last_name, phone, license_number, license_expiry, active, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		driver.FirstName, driver.LastName, driver.Phone, driver.LicenseNumber, driver.LicenseExpiry, driver.Active, driver.CreatedAt)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add driver, the licence number may already exist!"), models.Driver{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot add driver!"), models.Driver{}
+	}
+	driver.Id = int(lastId)
+	return nil, driver
+}
+
+// GetDriversDbHandler - Lists drivers by ?active=true|false; ?license_expiring_before=YYYY-MM-DD finds licences due for renewal;
+func GetDriversDbHandler(r *http.Request) (error, []models.Driver) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + driverColumns + " FROM transport_drivers d WHERE 1=1"
+	var args []interface{}
+
+	switch r.URL.Query().Get("active") {
+	case "true":
+		query += " AND d.active = 1"
+	case "false":
+		query += " AND d.active = 0"
+	}
+	if before := r.URL.Query().Get("license_expiring_before"); before != "" {
+		_, err = time.Parse(time.DateOnly, before)
+		if err != nil {
+			return utils.HandleError(err, "Err: Date must be in YYYY-MM-DD format!"), nil
+		}
+		query += " AND d.license_expiry < ?"
+		args = append(args, before)
+	}
+	query += " ORDER BY d.last_name, d.first_name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	drivers := []models.Driver{}
+	for rows.Next() {
+		var driver models.Driver
+		err = scanDriver(rows, &driver)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		drivers = append(drivers, driver)
+	}
+	return nil, drivers
+}
+
+// PatchDriverDbHandler - Updates the contact, licence or active flag of a driver;
+func PatchDriverDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.Driver) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Driver{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, driver := getDriver(db, id)
+	if err != nil {
+		return err, models.Driver{}
+	}
+
+	for k, v := range updates {
+		if k == "active" {
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for active!"), models.Driver{}
+			}
+			driver.Active = value
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.Driver{}
+		}
+		switch k {
+		case "first_name":
+			driver.FirstName = value
+		case "last_name":
+			driver.LastName = value
+		case "phone":
+			driver.Phone = value
+		case "license_number":
+			driver.LicenseNumber = value
+		case "license_expiry":
+			driver.LicenseExpiry = value
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.Driver{}
+		}
+	}
+
+	err = ValidateDriver(driver)
+	if err != nil {
+		return err, models.Driver{}
+	}
+
+	_, err = db.Exec("UPDATE transport_drivers SET first_name = ?, last_name = ?, phone = ?, license_number = ?, license_expiry = ?, active = ? WHERE id = ?",
+		driver.FirstName, driver.LastName, driver.Phone, driver.LicenseNumber, driver.LicenseExpiry, driver.Active, id)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update driver, the licence number may already exist!"), models.Driver{}
+	}
+	return nil, driver
+}
+
+// checkRouteCrew - The vehicle and driver of a route must be active, the driver licensed and the vehicle large enough for its riders;
+func checkRouteCrew(db dbExecutor, route models.TransportRoute) error {
+	if route.VehicleId != 0 {
+		err, vehicle := getVehicle(db, route.VehicleId)
+		if err != nil {
+			return err
+		}
+		if !vehicle.Active {
+			return utils.HandleError(errors.New("inactive vehicle"), "Err: Vehicle "+vehicle.Registration+" is not in service!")
+		}
+
+		if route.Id != 0 {
+			peak, _, err := routePeakLoad(db, route.Id, time.Now().Format(time.DateOnly), "", 0)
+			if err != nil {
+				return err
+			}
+			if peak > vehicle.Capacity {
+				return utils.HandleError(errors.New("over capacity"), "Err: Vehicle "+vehicle.Registration+" seats "+strconv.Itoa(vehicle.Capacity)+" but the route has "+strconv.Itoa(peak)+" riders!")
+			}
+		}
+	}
+
+	if route.DriverId != 0 {
+		err, driver := getDriver(db, route.DriverId)
+		if err != nil {
+			return err
+		}
+		if !driver.Active {
+			return utils.HandleError(errors.New("inactive driver"), "Err: The driver is not active!")
+		}
+		if driver.LicenseExpiry < time.Now().Format(time.DateOnly) {
+			return utils.HandleError(errors.New("licence expired"), "Err: The driver's licence has expired!")
+		}
+	}
+	return nil
+}
+
+func getRouteStops(db dbExecutor, routeId int) (error, []models.TransportStop) {
+	rows, err := db.Query("SELECT "+stopColumns+" FROM transport_stops st WHERE st.route_id = ? ORDER BY st.sequence", routeId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	stops := []models.TransportStop{}
+	for rows.Next() {
+		var stop models.TransportStop
+		err = scanStop(rows, &stop)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		stops = append(stops, stop)
+	}
+	return nil, stops
+}
+
+// getRoute - Fetches a route with its stops, vehicle and driver;
+func getRoute(db dbExecutor, id int) (error, models.TransportRoute) {
+	var route models.TransportRoute
+	err := scanRoute(db.QueryRow("SELECT "+routeColumns+routeTables+" WHERE r.id = ?", id), &route)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No route found!"), models.TransportRoute{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TransportRoute{}
+	}
+
+	err, route.Stops = getRouteStops(db, id)
+	if err != nil {
+		return err, models.TransportRoute{}
+	}
+
+	if route.VehicleId != 0 {
+		err, vehicle := getVehicle(db, route.VehicleId)
+		if err != nil {
+			return err, models.TransportRoute{}
+		}
+		route.Vehicle = &vehicle
+	}
+	if route.DriverId != 0 {
+		err, driver := getDriver(db, route.DriverId)
+		if err != nil {
+			return err, models.TransportRoute{}
+		}
+		route.Driver = &driver
+	}
+	return nil, route
+}
+
+// AddTransportRouteDbHandler - Adds a route with its stops in running order;
+func AddTransportRouteDbHandler(ctx context.Context, route models.TransportRoute) (error, models.TransportRoute) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransportRoute{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = checkRouteCrew(db, route)
+	if err != nil {
+		return err, models.TransportRoute{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.TransportRoute{}
+	}
+
+	res, err := tx.Exec("INSERT INTO transport_routes (name, vehicle_id, driver_id, active, created_at) VALUES (?, ?, ?, ?, ?)",
+		strings.TrimSpace(route.Name), nullableId(route.VehicleId), nullableId(route.DriverId), route.Active, time.Now().Format(time.DateTime))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add route, the name may already be taken!"), models.TransportRoute{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot add route!"), models.TransportRoute{}
+	}
+
+	err = replaceRouteStops(tx, int(lastId), route.Stops)
+	if err != nil {
+		tx.Rollback()
+		return err, models.TransportRoute{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.TransportRoute{}
+	}
+	return getRoute(db, int(lastId))
+}
+
+// replaceRouteStops - Rewrites the stops of a route in the given order; stops sent with their id are kept and updated;
+// Stops left out are removed, unless students are still assigned to them;
+func replaceRouteStops(tx *sql.Tx, routeId int, stops []models.TransportStop) error {
+	keep := map[int]bool{}
+	for _, stop := range stops {
+		if stop.Id != 0 {
+			keep[stop.Id] = true
+		}
+	}
+
+	err, existing := getRouteStops(tx, routeId)
+	if err != nil {
+		return err
+	}
+	known := map[int]bool{}
+	for _, stop := range existing {
+		known[stop.Id] = true
+		if keep[stop.Id] {
+			continue
+		}
+
+		var riders int
+		err = tx.QueryRow("SELECT COUNT(*) FROM transport_assignments WHERE stop_id = ? AND (end_date IS NULL OR end_date >= CURDATE())", stop.Id).Scan(&riders)
+		if err != nil {
+			return utils.HandleError(err, "Err: Query execution failed!")
+		}
+		if riders > 0 {
+			return utils.HandleError(errors.New("stop in use"), "Err: Stop "+stop.Name+" still has students assigned!")
+		}
+
+		// Past assignments keep pointing at a stop, so they go with it;
+		_, err = tx.Exec("DELETE FROM transport_assignments WHERE stop_id = ?", stop.Id)
+		if err == nil {
+			_, err = tx.Exec("DELETE FROM transport_stops WHERE id = ?", stop.Id)
+		}
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot update stops!")
+		}
+	}
+
+	// Kept stops are moved out of the way first so the new order does not collide on the unique sequence;
+	_, err = tx.Exec("UPDATE transport_stops SET sequence = sequence + 10000 WHERE route_id = ?", routeId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot update stops!")
+	}
+
+	for i, stop := range stops {
+		if stop.Id != 0 && !known[stop.Id] {
+			return utils.HandleError(errors.New("unknown stop"), "Err: Stop "+strconv.Itoa(stop.Id)+" does not belong to this route!")
+		}
+
+		dropoff := sql.NullString{String: stop.DropoffTime, Valid: stop.DropoffTime != ""}
+		if stop.Id != 0 {
+			_, err = tx.Exec("UPDATE transport_stops SET sequence = ?, name = ?, address = ?, pickup_time = ?, dropoff_time = ? WHERE id = ?",
+				i+1, strings.TrimSpace(stop.Name), stop.Address, stop.PickupTime, dropoff, stop.Id)
+		} else {
+			_, err = tx.Exec("INSERT INTO transport_stops (route_id, sequence, name, address, pickup_time, dropoff_time) VALUES (?, ?, ?, ?, ?, ?)",
+				routeId, i+1, strings.TrimSpace(stop.Name), stop.Address, stop.PickupTime, dropoff)
+		}
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot update stops!")
+		}
+	}
+	return nil
+}
+
+// GetTransportRoutesDbHandler - Lists routes by ?active=true|false, ?vehicle_id= or ?driver_id=;
+func GetTransportRoutesDbHandler(r *http.Request) (error, []models.TransportRoute) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + routeColumns + routeTables + " WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"vehicle_id": "r.vehicle_id",
+		"driver_id":  "r.driver_id",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	switch r.URL.Query().Get("active") {
+	case "true":
+		query += " AND r.active = 1"
+	case "false":
+		query += " AND r.active = 0"
+	}
+	query += " ORDER BY r.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	routes := []models.TransportRoute{}
+	for rows.Next() {
+		var route models.TransportRoute
+		err = scanRoute(rows, &route)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		routes = append(routes, route)
+	}
+	rows.Close()
+
+	for i := range routes {
+		err, routes[i].Stops = getRouteStops(db, routes[i].Id)
+		if err != nil {
+			return err, nil
+		}
+	}
+	return nil, routes
+}
+
+// GetTransportRouteDbHandler - Fetches a route with its stops, vehicle and driver;
+func GetTransportRouteDbHandler(ctx context.Context, id int) (error, models.TransportRoute) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransportRoute{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getRoute(db, id)
+}
+
+// PatchTransportRouteDbHandler - Updates name, vehicle_id, driver_id (null to unassign), active or the full stops list;
+func PatchTransportRouteDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.TransportRoute) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransportRoute{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, route := getRoute(db, id)
+	if err != nil {
+		return err, models.TransportRoute{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "name":
+			value, ok := v.(string)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for name!"), models.TransportRoute{}
+			}
+			route.Name = value
+		case "vehicle_id", "driver_id":
+			value := 0
+			if v != nil {
+				number, ok := v.(float64)
+				if !ok || number != math.Trunc(number) || number < 0 {
+					return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.TransportRoute{}
+				}
+				value = int(number)
+			}
+			if k == "vehicle_id" {
+				route.VehicleId = value
+			} else {
+				route.DriverId = value
+			}
+		case "active":
+			value, ok := v.(bool)
+			if !ok {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for active!"), models.TransportRoute{}
+			}
+			route.Active = value
+		case "stops":
+			// The list is decoded again into its typed form;
+			raw, err := json.Marshal(v)
+			if err == nil {
+				route.Stops = nil
+				err = json.Unmarshal(raw, &route.Stops)
+			}
+			if err != nil {
+				return utils.HandleError(err, "Err: Invalid value for stops!"), models.TransportRoute{}
+			}
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.TransportRoute{}
+		}
+	}
+
+	err = ValidateTransportRoute(route)
+	if err != nil {
+		return err, models.TransportRoute{}
+	}
+	err = checkRouteCrew(db, route)
+	if err != nil {
+		return err, models.TransportRoute{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.TransportRoute{}
+	}
+
+	_, err = tx.Exec("UPDATE transport_routes SET name = ?, vehicle_id = ?, driver_id = ?, active = ? WHERE id = ?",
+		strings.TrimSpace(route.Name), nullableId(route.VehicleId), nullableId(route.DriverId), route.Active, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update route, the name may already be taken!"), models.TransportRoute{}
+	}
+
+	if _, ok := updates["stops"]; ok {
+		err = replaceRouteStops(tx, id, route.Stops)
+		if err != nil {
+			tx.Rollback()
+			return err, models.TransportRoute{}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.TransportRoute{}
+	}
+	return getRoute(db, id)
+}
+
+// DeleteTransportRouteDbHandler - Removes a route with its stops and history; refused while students are assigned to it;
+func DeleteTransportRouteDbHandler(ctx context.Context, id int) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var riders int
+	err = db.QueryRow("SELECT COUNT(*) FROM transport_assignments WHERE route_id = ? AND (end_date IS NULL OR end_date >= CURDATE())", id).Scan(&riders)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if riders > 0 {
+		return utils.HandleError(errors.New("route in use"), "Err: Students are still assigned to this route!")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	// Assignments reference stops without cascading, so they are removed before the route;
+	_, err = tx.Exec("DELETE FROM transport_assignments WHERE route_id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot delete route!")
+	}
+
+	res, err := tx.Exec("DELETE FROM transport_routes WHERE id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot delete route!")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot delete route!")
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return utils.HandleError(sql.ErrNoRows, "Err: No route found!")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
+
+func getAssignment(db dbExecutor, id int) (error, models.TransportAssignment) {
+	var assignment models.TransportAssignment
+	err := scanAssignment(db.QueryRow("SELECT "+assignmentColumns+assignmentTables+" WHERE a.id = ?", id), &assignment)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No assignment found!"), models.TransportAssignment{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TransportAssignment{}
+	}
+	return nil, assignment
+}
+
+// checkAssignment - A student rides one route at a time, from a stop of that route, within the seats of its vehicle;
+// The route row is locked so concurrent assignments cannot overbook it;
+func checkAssignment(tx *sql.Tx, assignment models.TransportAssignment) error {
+	var active bool
+	var capacity sql.NullInt64
+	err := tx.QueryRow("SELECT r.active, v.capacity FROM transport_routes r LEFT JOIN transport_vehicles v ON v.id = r.vehicle_id WHERE r.id = ? FOR UPDATE", assignment.RouteId).
+		Scan(&active, &capacity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No route found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	if !active {
+		return utils.HandleError(errors.New("inactive route"), "Err: The route is not running!")
+	}
+	if !capacity.Valid {
+		return utils.HandleError(errors.New("no vehicle"), "Err: The route has no vehicle assigned!")
+	}
+
+	var stopRoute int
+	err = tx.QueryRow("SELECT route_id FROM transport_stops WHERE id = ?", assignment.StopId).Scan(&stopRoute)
+	if err != nil || stopRoute != assignment.RouteId {
+		return utils.HandleError(errors.New("invalid stop"), "Err: The stop does not belong to this route!")
+	}
+
+	var inactive bool
+	err = tx.QueryRow("SELECT inactive_status FROM students WHERE id = ?", assignment.StudentId).Scan(&inactive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No student found!")
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	if inactive {
+		return utils.HandleError(errors.New("inactive student"), "Err: The student is no longer enrolled!")
+	}
+
+	query := "SELECT COUNT(*) FROM transport_assignments WHERE student_id = ? AND id <> ? AND (end_date IS NULL OR end_date >= ?)"
+	args := []interface{}{assignment.StudentId, assignment.Id, assignment.StartDate}
+	if assignment.EndDate != "" {
+		query += " AND start_date <= ?"
+		args = append(args, assignment.EndDate)
+	}
+	var overlapping int
+	err = tx.QueryRow(query, args...).Scan(&overlapping)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!")
+	}
+	if overlapping > 0 {
+		return utils.HandleError(errors.New("already assigned"), "Err: The student already rides a route in this period, end that assignment first!")
+	}
+
+	_, load, err := routePeakLoad(tx, assignment.RouteId, assignment.StartDate, assignment.EndDate, assignment.Id)
+	if err != nil {
+		return err
+	}
+	for _, day := range assignment.Weekdays {
+		if load[day] >= int(capacity.Int64) {
+			return utils.HandleError(errors.New("route full"), "Err: The route is full on "+time.Weekday(day%7).String()+"!")
+		}
+	}
+	return nil
+}
+
+// AddTransportAssignmentDbHandler - Assigns a student to a route and stop;
+func AddTransportAssignmentDbHandler(ctx context.Context, assignment models.TransportAssignment) (error, models.TransportAssignment) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransportAssignment{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.TransportAssignment{}
+	}
+
+	err = checkAssignment(tx, assignment)
+	if err != nil {
+		tx.Rollback()
+		return err, models.TransportAssignment{}
+	}
+
+	res, err := tx.Exec("INSERT INTO transport_assignments (student_id, route_id, stop_id, weekdays, start_date, end_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		assignment.StudentId, assignment.RouteId, assignment.StopId, formatWeekdays(assignment.Weekdays), assignment.StartDate,
+		sql.NullString{String: assignment.EndDate, Valid: assignment.EndDate != ""}, time.Now().Format(time.DateTime))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot assign student!"), models.TransportAssignment{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot assign student!"), models.TransportAssignment{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.TransportAssignment{}
+	}
+	return getAssignment(db, int(lastId))
+}
+
+// GetTransportAssignmentsDbHandler - Lists assignments by route_id, stop_id, student_id or class; ?date= keeps those running that day;
+func GetTransportAssignmentsDbHandler(r *http.Request) (error, []models.TransportAssignment) {
+	db, err := ConnectDb(r.Context())
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := "SELECT " + assignmentColumns + assignmentTables + " WHERE 1=1"
+	var args []interface{}
+
+	params := map[string]string{
+		"route_id":   "a.route_id",
+		"stop_id":    "a.stop_id",
+		"student_id": "a.student_id",
+		"class":      "s.class",
+	}
+	for param, dbField := range params {
+		value := r.URL.Query().Get(param)
+		if value != "" {
+			query += " AND " + dbField + " = ?"
+			args = append(args, value)
+		}
+	}
+
+	if date := r.URL.Query().Get("date"); date != "" {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return utils.HandleError(err, "Err: Date must be in YYYY-MM-DD format!"), nil
+		}
+		query += " AND a.start_date <= ? AND (a.end_date IS NULL OR a.end_date >= ?) AND FIND_IN_SET(?, a.weekdays)"
+		args = append(args, date, date, strconv.Itoa(isoWeekday(day)))
+	}
+	query += " ORDER BY r.name, st.sequence, s.last_name, s.first_name"
+
+	return getAssignments(db, query, args...)
+}
+
+func getAssignments(db dbExecutor, query string, args ...interface{}) (error, []models.TransportAssignment) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), nil
+	}
+	defer rows.Close()
+
+	assignments := []models.TransportAssignment{}
+	for rows.Next() {
+		var assignment models.TransportAssignment
+		err = scanAssignment(rows, &assignment)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), nil
+		}
+		assignments = append(assignments, assignment)
+	}
+	return nil, assignments
+}
+
+// PatchTransportAssignmentDbHandler - Changes the stop, weekdays, start_date or end_date (null for open ended) of an assignment;
+func PatchTransportAssignmentDbHandler(ctx context.Context, id int, updates map[string]interface{}) (error, models.TransportAssignment) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransportAssignment{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, assignment := getAssignment(db, id)
+	if err != nil {
+		return err, models.TransportAssignment{}
+	}
+
+	for k, v := range updates {
+		switch k {
+		case "stop_id":
+			value, ok := v.(float64)
+			if !ok || value != math.Trunc(value) {
+				return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for stop_id!"), models.TransportAssignment{}
+			}
+			assignment.StopId = int(value)
+		case "weekdays":
+			// The list is decoded again into its typed form;
+			raw, err := json.Marshal(v)
+			if err == nil {
+				assignment.Weekdays = nil
+				err = json.Unmarshal(raw, &assignment.Weekdays)
+			}
+			if err != nil {
+				return utils.HandleError(err, "Err: Invalid value for weekdays!"), models.TransportAssignment{}
+			}
+		case "start_date", "end_date":
+			value := ""
+			if v != nil {
+				text, ok := v.(string)
+				if !ok {
+					return utils.HandleError(errors.New("invalid value"), "Err: Invalid value for "+k+"!"), models.TransportAssignment{}
+				}
+				value = text
+			}
+			if k == "start_date" {
+				assignment.StartDate = value
+			} else {
+				assignment.EndDate = value
+			}
+		default:
+			return utils.HandleError(errors.New("invalid field"), "Err: Field "+k+" cannot be updated!"), models.TransportAssignment{}
+		}
+	}
+
+	err = ValidateTransportAssignment(assignment)
+	if err != nil {
+		return err, models.TransportAssignment{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.TransportAssignment{}
+	}
+
+	err = checkAssignment(tx, assignment)
+	if err != nil {
+		tx.Rollback()
+		return err, models.TransportAssignment{}
+	}
+
+	_, err = tx.Exec("UPDATE transport_assignments SET stop_id = ?, weekdays = ?, start_date = ?, end_date = ? WHERE id = ?",
+		assignment.StopId, formatWeekdays(assignment.Weekdays), assignment.StartDate, sql.NullString{String: assignment.EndDate, Valid: assignment.EndDate != ""}, id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update assignment!"), models.TransportAssignment{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.TransportAssignment{}
+	}
+	return getAssignment(db, id)
+}
+
+// DeleteTransportAssignmentDbHandler - Removes an assignment entered by mistake; finished ones should get an end_date instead;
+func DeleteTransportAssignmentDbHandler(ctx context.Context, id int) error {
+	return deleteTransportRow(ctx, "transport_assignments", "assignment", id)
+}
+
+// GetRouteManifestDbHandler - Riders of a route on a day, grouped by stop, with the boarding events recorded for that run;
+func GetRouteManifestDbHandler(ctx context.Context, routeId int, date string) (error, models.RouteManifest) {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return utils.HandleError(err, "Err: Date must be in YYYY-MM-DD format!"), models.RouteManifest{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.RouteManifest{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err, route := getRoute(db, routeId)
+	if err != nil {
+		return err, models.RouteManifest{}
+	}
+
+	manifest := models.RouteManifest{
+		RouteId:   route.Id,
+		RouteName: route.Name,
+		Date:      date,
+		Vehicle:   route.Vehicle,
+		Driver:    route.Driver,
+		Capacity:  route.Capacity,
+		Stops:     []models.ManifestStop{},
+	}
+	index := map[int]int{}
+	for i, stop := range route.Stops {
+		index[stop.Id] = i
+		manifest.Stops = append(manifest.Stops, models.ManifestStop{TransportStop: stop, Riders: []models.ManifestRider{}})
+	}
+
+	rows, err := db.Query(`SELECT a.stop_id, s.id, s.first_name, s.last_name, s.class,
+		(SELECT MIN(b.recorded_at) FROM transport_boardings b WHERE b.route_id = a.route_id AND b.student_id = s.id AND b.run_date = ? AND b.event = 'boarded'),
+		(SELECT MAX(b.recorded_at) FROM transport_boardings b WHERE b.route_id = a.route_id AND b.student_id = s.id AND b.run_date = ? AND b.event = 'alighted')
+		FROM transport_assignments a JOIN students s ON s.id = a.student_id
+		WHERE a.route_id = ? AND a.start_date <= ? AND (a.end_date IS NULL OR a.end_date >= ?) AND FIND_IN_SET(?, a.weekdays)
+		ORDER BY s.last_name, s.first_name`, date, date, routeId, date, date, strconv.Itoa(isoWeekday(day)))
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.RouteManifest{}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stopId int
+		var rider models.ManifestRider
+		var boardedAt, alightedAt sql.NullString
+		err = rows.Scan(&stopId, &rider.StudentId, &rider.FirstName, &rider.LastName, &rider.Class, &boardedAt, &alightedAt)
+		if err != nil {
+			return utils.HandleError(err, "Err: Data retrieval failed!"), models.RouteManifest{}
+		}
+		rider.BoardedAt = boardedAt.String
+		rider.AlightedAt = alightedAt.String
+
+		i, ok := index[stopId]
+		if !ok {
+			continue
+		}
+		manifest.Stops[i].Riders = append(manifest.Stops[i].Riders, rider)
+		manifest.Count++
+	}
+	manifest.OverCapacity = manifest.Count > manifest.Capacity
+	return nil, manifest
+}
+
+// RecordBoardingDbHandler - Records a student getting on or off the bus during today's run of a route;
+func RecordBoardingDbHandler(ctx context.Context, event models.BoardingEvent) (error, models.BoardingEvent) {
+	if !isAllowedValue(event.Event, models.BoardingEvents) {
+		return utils.HandleError(errors.New("invalid event"), "Err: Event must be boarded or alighted!"), models.BoardingEvent{}
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.BoardingEvent{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	now := time.Now()
+	event.RunDate = now.Format(time.DateOnly)
+	event.RecordedAt = now.Format(time.DateTime)
+
+	// Only riders on today's manifest can be recorded;
+	var assigned int
+	err = db.QueryRow(`SELECT COUNT(*) FROM transport_assignments WHERE route_id = ? AND student_id = ?
+		AND start_date <= ? AND (end_date IS NULL OR end_date >= ?) AND FIND_IN_SET(?, weekdays)`,
+		event.RouteId, event.StudentId, event.RunDate, event.RunDate, strconv.Itoa(isoWeekday(now))).Scan(&assigned)
+	if err != nil {
+		return utils.HandleError(err, "Err: Query execution failed!"), models.BoardingEvent{}
+	}
+	if assigned == 0 {
+		return utils.HandleError(errors.New("not on manifest"), "Err: The student is not on today's manifest for this route!"), models.BoardingEvent{}
+	}
+
+	if event.StopId != 0 {
+		var stopRoute int
+		err = db.QueryRow("SELECT route_id FROM transport_stops WHERE id = ?", event.StopId).Scan(&stopRoute)
+		if err != nil || stopRoute != event.RouteId {
+			return utils.HandleError(errors.New("invalid stop"), "Err: The stop does not belong to this route!"), models.BoardingEvent{}
+		}
+	}
+
+	res, err := db.Exec("INSERT INTO transport_boardings (route_id, student_id, stop_id, event, run_date, recorded_at, recorded_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		event.RouteId, event.StudentId, nullableId(event.StopId), event.Event, event.RunDate, event.RecordedAt, event.RecordedBy)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot record boarding!"), models.BoardingEvent{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot record boarding!"), models.BoardingEvent{}
+	}
+	event.Id = int(lastId)
+	return nil, event
+}
+
+// GetStudentTransportDbHandler - Current and upcoming assignments of a student with the routes they ride;
+// Driver licence details are left out as the result is shown to guardians;
+func GetStudentTransportDbHandler(ctx context.Context, studentId int) (error, []models.StudentTransport) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), nil
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = documentOwnerExists(db, "student", studentId)
+	if err != nil {
+		return err, nil
+	}
+
+	err, assignments := getAssignments(db, "SELECT "+assignmentColumns+assignmentTables+
+		" WHERE a.student_id = ? AND (a.end_date IS NULL OR a.end_date >= CURDATE()) ORDER BY a.start_date", studentId)
+	if err != nil {
+		return err, nil
+	}
+
+	transport := []models.StudentTransport{}
+	for _, assignment := range assignments {
+		err, route := getRoute(db, assignment.RouteId)
+		if err != nil {
+			return err, nil
+		}
+		if route.Driver != nil {
+			route.Driver = &models.Driver{Id: route.Driver.Id, FirstName: route.Driver.FirstName, LastName: route.Driver.LastName, Phone: route.Driver.Phone, Active: route.Driver.Active}
+		}
+		transport = append(transport, models.StudentTransport{Assignment: assignment, Route: route})
+	}
+	return nil, transport
+}
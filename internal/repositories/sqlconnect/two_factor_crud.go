@@ -0,0 +1,315 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// twoFactorKeyEnv - Environment variable holding the base64 AES-256 key for TOTP secrets;
+const twoFactorKeyEnv = "TWO_FACTOR_ENCRYPTION_KEY"
+
+// GetLoginExecDbHandler - Fetches what the second login step needs of an exec: contact, username, status and role;
+func GetLoginExecDbHandler(ctx context.Context, id int) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var exec models.Exec
+	err = db.QueryRow("SELECT id, first_name, email, username, inactive_status, role FROM execs WHERE id = ?", id).Scan(&exec.Id, &exec.FirstName, &exec.Email, &exec.Username, &exec.Inactive, &exec.Role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No user found!"), models.Exec{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Exec{}
+	}
+	return nil, exec
+}
+
+// GetTwoFactorDbHandler - Two-factor state of an exec, with the unused recovery codes left;
+func GetTwoFactorDbHandler(ctx context.Context, execId int) (error, models.TwoFactor) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TwoFactor{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var twoFactor models.TwoFactor
+	var enabledAt sql.NullString
+	err = db.QueryRow("SELECT enabled, enabled_at FROM exec_two_factor WHERE exec_id = ?", execId).Scan(&twoFactor.Enabled, &enabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, twoFactor
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TwoFactor{}
+	}
+	twoFactor.Pending = !twoFactor.Enabled
+	twoFactor.EnabledAt = enabledAt.String
+
+	err = db.QueryRow("SELECT COUNT(*) FROM exec_recovery_codes WHERE exec_id = ? AND used_at IS NULL", execId).Scan(&twoFactor.RecoveryCodesLeft)
+	if err != nil {
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TwoFactor{}
+	}
+	return nil, twoFactor
+}
+
+// insertRecoveryCodes - Replaces the recovery codes of an exec with the given hashes;
+func insertRecoveryCodes(db dbExecutor, execId int, codeHashes []string) error {
+	_, err := db.Exec("DELETE FROM exec_recovery_codes WHERE exec_id = ?", execId)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot replace recovery codes!")
+	}
+
+	for _, hash := range codeHashes {
+		_, err = db.Exec("INSERT INTO exec_recovery_codes (exec_id, code_hash) VALUES (?, ?)", execId, hash)
+		if err != nil {
+			return utils.HandleError(err, "Err: Cannot save recovery codes!")
+		}
+	}
+	return nil
+}
+
+// StartTwoFactorEnrollmentDbHandler - Stores a new, not yet confirmed, secret of an exec with its recovery codes;
+// A pending enrollment is replaced, an enabled one has to be disabled first;
+func StartTwoFactorEnrollmentDbHandler(ctx context.Context, execId int, secret string, codeHashes []string) error {
+	key, err := utils.EncryptionKey(twoFactorKeyEnv)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := utils.EncryptString(key, secret)
+	if err != nil {
+		return err
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	var enabled bool
+	err = tx.QueryRow("SELECT enabled FROM exec_two_factor WHERE exec_id = ? FOR UPDATE", execId).Scan(&enabled)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Data retrieval failed!")
+	}
+	if enabled {
+		tx.Rollback()
+		return utils.HandleError(errors.New("two-factor enabled"), "Err: Two-factor authentication is already enabled!")
+	}
+
+	_, err = tx.Exec(`INSERT INTO exec_two_factor (exec_id, secret, enabled, last_used_step, created_at) VALUES (?, ?, FALSE, 0, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), last_used_step = 0, created_at = VALUES(created_at)`, execId, encrypted, time.Now().Format(time.DateTime))
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot save two-factor secret!")
+	}
+
+	err = insertRecoveryCodes(tx, execId, codeHashes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
+
+// VerifyTwoFactorDbHandler - Checks a TOTP code, or a recovery code, of an exec and uses it up;
+// The first code accepted for a pending enrollment enables it; recovery codes only work once it is enabled;
+func VerifyTwoFactorDbHandler(ctx context.Context, execId int, code, recoveryCode string) (error, bool) {
+	key, err := utils.EncryptionKey(twoFactorKeyEnv)
+	if err != nil {
+		return err, false
+	}
+
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), false
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), false
+	}
+
+	// Locked, so that two requests cannot both accept the same code;
+	var encrypted string
+	var enabled bool
+	var lastStep int64
+	err = tx.QueryRow("SELECT secret, enabled, last_used_step FROM exec_two_factor WHERE exec_id = ? FOR UPDATE", execId).Scan(&encrypted, &enabled, &lastStep)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: Two-factor authentication is not set up!"), false
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), false
+	}
+
+	now := time.Now()
+	if code != "" {
+		secret, err := utils.DecryptString(key, encrypted)
+		if err != nil {
+			tx.Rollback()
+			return err, false
+		}
+
+		step, ok := utils.VerifyTOTP(secret, code, now, lastStep)
+		if !ok {
+			tx.Rollback()
+			return nil, false
+		}
+
+		if enabled {
+			_, err = tx.Exec("UPDATE exec_two_factor SET last_used_step = ? WHERE exec_id = ?", step, execId)
+		} else {
+			_, err = tx.Exec("UPDATE exec_two_factor SET last_used_step = ?, enabled = TRUE, enabled_at = ? WHERE exec_id = ?", step, now.Format(time.DateTime), execId)
+		}
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot update two-factor state!"), false
+		}
+	} else {
+		if !enabled || recoveryCode == "" {
+			tx.Rollback()
+			return nil, false
+		}
+
+		result, err := tx.Exec("UPDATE exec_recovery_codes SET used_at = ? WHERE exec_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1",
+			now.Format(time.DateTime), execId, utils.HashRecoveryCode(recoveryCode))
+		if err != nil {
+			tx.Rollback()
+			return utils.HandleError(err, "Err: Cannot use recovery code!"), false
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			tx.Rollback()
+			return nil, false
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), false
+	}
+	return nil, true
+}
+
+// ReplaceRecoveryCodesDbHandler - Swaps the recovery codes of an exec for new ones, the old ones stop working;
+func ReplaceRecoveryCodesDbHandler(ctx context.Context, execId int, codeHashes []string) error {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!")
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!")
+	}
+
+	err = insertRecoveryCodes(tx, execId, codeHashes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!")
+	}
+	return nil
+}
+
+// DisableTwoFactorDbHandler - Removes the secret and recovery codes of an exec, who logs in with the password alone again;
+// Returns the exec (name and email) so that they can be told;
+func DisableTwoFactorDbHandler(ctx context.Context, execId int) (error, models.Exec) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Exec{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var exec models.Exec
+	err = db.QueryRow("SELECT id, first_name, email FROM execs WHERE id = ?", execId).Scan(&exec.Id, &exec.FirstName, &exec.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No user found!"), models.Exec{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Exec{}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot start transaction!"), models.Exec{}
+	}
+
+	_, err = tx.Exec("DELETE FROM exec_recovery_codes WHERE exec_id = ?", execId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot remove recovery codes!"), models.Exec{}
+	}
+
+	_, err = tx.Exec("DELETE FROM exec_two_factor WHERE exec_id = ?", execId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot disable two-factor authentication!"), models.Exec{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Exec{}
+	}
+	return nil, exec
+}
@@ -0,0 +1,169 @@
+package sqlconnect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"schoolManagement/internal/models"
+	"schoolManagement/pkg/utils"
+	"time"
+)
+
+// WithdrawStudentDbHandler - Records a withdrawal/transfer, marks the student inactive and closes the enrollment;
+// The student row is kept so that the history stays queryable;
+func WithdrawStudentDbHandler(ctx context.Context, studentId int, withdrawal models.Withdrawal) (error, models.Withdrawal) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Withdrawal{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot begin transaction!"), models.Withdrawal{}
+	}
+
+	var class string
+	var inactive bool
+	err = tx.QueryRow("SELECT class, inactive_status FROM students WHERE id = ? FOR UPDATE", studentId).Scan(&class, &inactive)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No student found!"), models.Withdrawal{}
+		}
+		return utils.HandleError(err, "Err: Cannot get student from db!"), models.Withdrawal{}
+	}
+
+	if inactive {
+		tx.Rollback()
+		return utils.HandleError(errors.New("student inactive"), "Err: Student has already left the school!"), models.Withdrawal{}
+	}
+
+	withdrawal.StudentId = studentId
+	withdrawal.LastClass = class
+	withdrawal.CreatedAt = time.Now().Format(time.DateTime)
+
+	res, err := tx.Exec("INSERT INTO student_withdrawals (student_id, type, reason, leaving_date, destination_school, last_class, working_days, days_present, recorded_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		withdrawal.StudentId, withdrawal.Type, withdrawal.Reason, withdrawal.LeavingDate, withdrawal.DestinationSchool, withdrawal.LastClass, withdrawal.WorkingDays, withdrawal.DaysPresent, withdrawal.RecordedBy, withdrawal.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record withdrawal!"), models.Withdrawal{}
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record withdrawal!"), models.Withdrawal{}
+	}
+	withdrawal.Id = int(lastId)
+	withdrawal.CertificateNumber = fmt.Sprintf("TC-%s-%05d", withdrawal.LeavingDate[:4], withdrawal.Id)
+
+	_, err = tx.Exec("UPDATE student_withdrawals SET certificate_number = ? WHERE id = ?", withdrawal.CertificateNumber, withdrawal.Id)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot record withdrawal!"), models.Withdrawal{}
+	}
+
+	_, err = tx.Exec("UPDATE students SET inactive_status = 1 WHERE id = ?", studentId)
+	if err != nil {
+		tx.Rollback()
+		return utils.HandleError(err, "Err: Cannot update student in db!"), models.Withdrawal{}
+	}
+
+	enrollmentStatus := "withdrawn"
+	if withdrawal.Type == "transfer" {
+		enrollmentStatus = "transferred"
+	}
+	err = closeEnrollment(tx, studentId, class, enrollmentStatus)
+	if err != nil {
+		tx.Rollback()
+		return err, models.Withdrawal{}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot commit transaction!"), models.Withdrawal{}
+	}
+
+	return nil, withdrawal
+}
+
+// GetStudentWithdrawalDbHandler - Fetches the latest withdrawal record of a student;
+func GetStudentWithdrawalDbHandler(ctx context.Context, studentId int) (error, models.Withdrawal) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.Withdrawal{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	return getStudentWithdrawal(db, studentId)
+}
+
+func getStudentWithdrawal(db dbExecutor, studentId int) (error, models.Withdrawal) {
+	var withdrawal models.Withdrawal
+	err := db.QueryRow("SELECT id, student_id, type, reason, leaving_date, destination_school, last_class, working_days, days_present, certificate_number, recorded_by, created_at FROM student_withdrawals WHERE student_id = ? ORDER BY id DESC LIMIT 1", studentId).
+		Scan(&withdrawal.Id, &withdrawal.StudentId, &withdrawal.Type, &withdrawal.Reason, &withdrawal.LeavingDate, &withdrawal.DestinationSchool, &withdrawal.LastClass, &withdrawal.WorkingDays, &withdrawal.DaysPresent, &withdrawal.CertificateNumber, &withdrawal.RecordedBy, &withdrawal.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No withdrawal recorded for this student!"), models.Withdrawal{}
+		}
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.Withdrawal{}
+	}
+	return nil, withdrawal
+}
+
+// GetTransferCertificateDbHandler - Collects the student, withdrawal and enrollment data printed on the transfer certificate;
+func GetTransferCertificateDbHandler(ctx context.Context, studentId int) (error, models.TransferCertificate) {
+	db, err := ConnectDb(ctx)
+	if err != nil {
+		return utils.HandleError(err, "Err: Internal server error!"), models.TransferCertificate{}
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			return
+		}
+	}()
+
+	var certificate models.TransferCertificate
+	student := &certificate.Student
+	err = db.QueryRow("SELECT id, first_name, last_name, email, class, inactive_status FROM students WHERE id = ?", studentId).Scan(&student.Id, &student.FirstName, &student.LastName, &student.Email, &student.Class, &student.Inactive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.HandleError(err, "Err: No student found!"), models.TransferCertificate{}
+		}
+		return utils.HandleError(err, "Err: Cannot get student from db!"), models.TransferCertificate{}
+	}
+
+	err, certificate.Withdrawal = getStudentWithdrawal(db, studentId)
+	if err != nil {
+		return err, models.TransferCertificate{}
+	}
+
+	var admissionDate sql.NullString
+	err = db.QueryRow("SELECT MIN(start_date) FROM enrollments WHERE student_id = ?", studentId).Scan(&admissionDate)
+	if err != nil {
+		return utils.HandleError(err, "Err: Data retrieval failed!"), models.TransferCertificate{}
+	}
+
+	certificate.AdmissionDate = admissionDate.String
+	certificate.CertificateNumber = certificate.Withdrawal.CertificateNumber
+	if certificate.Withdrawal.WorkingDays > 0 {
+		certificate.AttendancePercentage = float64(certificate.Withdrawal.DaysPresent) * 100 / float64(certificate.Withdrawal.WorkingDays)
+	}
+	return nil, certificate
+}
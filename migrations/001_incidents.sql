@@ -0,0 +1,25 @@
+-- Disciplinary incidents and the students involved in each incident;
+CREATE TABLE IF NOT EXISTS incidents (
+    id                   INT AUTO_INCREMENT PRIMARY KEY,
+    incident_date        DATE         NOT NULL,
+    reporting_teacher_id INT          NOT NULL,
+    reported_by          INT          NOT NULL,
+    category             VARCHAR(100) NOT NULL,
+    severity             ENUM ('low', 'medium', 'high', 'critical') NOT NULL DEFAULT 'low',
+    description          TEXT         NOT NULL,
+    actions_taken        TEXT         NOT NULL,
+    follow_up_status     ENUM ('open', 'in_progress', 'resolved', 'closed') NOT NULL DEFAULT 'open',
+    created_at           TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_incidents_reported_by (reported_by),
+    INDEX idx_incidents_date (incident_date),
+    FOREIGN KEY (reporting_teacher_id) REFERENCES teachers (id)
+);
+
+CREATE TABLE IF NOT EXISTS incident_students (
+    incident_id INT NOT NULL,
+    student_id  INT NOT NULL,
+    PRIMARY KEY (incident_id, student_id),
+    INDEX idx_incident_students_student (student_id),
+    FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
@@ -0,0 +1,20 @@
+-- Year-end promotion batches and the class move of every promoted student;
+CREATE TABLE IF NOT EXISTS promotions (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    academic_year VARCHAR(20) NOT NULL,
+    promoted_by   INT         NOT NULL,
+    status        ENUM ('committed', 'undone') NOT NULL DEFAULT 'committed',
+    undo_until    DATETIME    NOT NULL,
+    created_at    DATETIME    NOT NULL,
+    undone_at     DATETIME    NULL
+);
+
+CREATE TABLE IF NOT EXISTS promotion_moves (
+    promotion_id INT          NOT NULL,
+    student_id   INT          NOT NULL,
+    from_class   VARCHAR(255) NOT NULL,
+    to_class     VARCHAR(255) NOT NULL,
+    PRIMARY KEY (promotion_id, student_id),
+    INDEX idx_promotion_moves_student (student_id),
+    FOREIGN KEY (promotion_id) REFERENCES promotions (id) ON DELETE CASCADE
+);
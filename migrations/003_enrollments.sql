@@ -0,0 +1,26 @@
+-- Class history of every student across academic years;
+CREATE TABLE IF NOT EXISTS enrollments (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    student_id    INT          NOT NULL,
+    class         VARCHAR(255) NOT NULL,
+    academic_year VARCHAR(20)  NOT NULL,
+    start_date    DATE         NULL,
+    end_date      DATE         NULL,
+    status        ENUM ('active', 'promoted', 'completed', 'withdrawn', 'transferred') NOT NULL DEFAULT 'active',
+    promotion_id  INT          NULL,
+    INDEX idx_enrollments_student (student_id, status),
+    INDEX idx_enrollments_class_year (class, academic_year),
+    INDEX idx_enrollments_promotion (promotion_id),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE,
+    FOREIGN KEY (promotion_id) REFERENCES promotions (id) ON DELETE SET NULL
+);
+
+-- Seeds an active enrollment for students that existed before enrollments were tracked;
+-- The academic year follows utils.AcademicYear: it is named after the calendar year it starts in, in June by default;
+-- Set the month below to ACADEMIC_YEAR_START_MONTH when the server is configured with another one;
+SET @academic_year_start_month = 6;
+
+INSERT INTO enrollments (student_id, class, academic_year, start_date, status)
+SELECT s.id, s.class, YEAR(CURRENT_DATE) - IF(MONTH(CURRENT_DATE) < @academic_year_start_month, 1, 0), CURRENT_DATE, 'active'
+FROM students s
+WHERE NOT EXISTS (SELECT 1 FROM enrollments e WHERE e.student_id = s.id);
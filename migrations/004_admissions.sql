@@ -0,0 +1,35 @@
+-- Admission applications and the history of their state changes;
+CREATE TABLE IF NOT EXISTS applicants (
+    id                 INT AUTO_INCREMENT PRIMARY KEY,
+    first_name         VARCHAR(255) NOT NULL,
+    last_name          VARCHAR(255) NOT NULL,
+    email              VARCHAR(255) NOT NULL,
+    date_of_birth      DATE         NOT NULL,
+    applying_for_class VARCHAR(255) NOT NULL,
+    academic_year      VARCHAR(20)  NOT NULL,
+    guardian_name      VARCHAR(255) NOT NULL,
+    guardian_email     VARCHAR(255) NOT NULL,
+    guardian_phone     VARCHAR(255) NOT NULL DEFAULT '',
+    previous_school    VARCHAR(255) NOT NULL DEFAULT '',
+    status             ENUM ('submitted', 'under_review', 'interview_scheduled', 'offered', 'accepted', 'enrolled', 'rejected', 'withdrawn') NOT NULL DEFAULT 'submitted',
+    interview_at       DATETIME     NULL,
+    student_id         INT          NULL,
+    created_at         DATETIME     NOT NULL,
+    updated_at         DATETIME     NULL,
+    INDEX idx_applicants_status (status),
+    INDEX idx_applicants_email_year (email, academic_year),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS applicant_transitions (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    applicant_id INT          NOT NULL,
+    from_status  VARCHAR(50)  NULL,
+    to_status    VARCHAR(50)  NOT NULL,
+    actor_id     INT          NULL,
+    actor_role   VARCHAR(50)  NOT NULL,
+    note         TEXT         NOT NULL,
+    changed_at   DATETIME     NOT NULL,
+    INDEX idx_applicant_transitions_applicant (applicant_id),
+    FOREIGN KEY (applicant_id) REFERENCES applicants (id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+-- Students who leave are marked inactive instead of being deleted;
+ALTER TABLE students
+    ADD COLUMN inactive_status BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS student_withdrawals (
+    id                 INT AUTO_INCREMENT PRIMARY KEY,
+    student_id         INT          NOT NULL,
+    type               ENUM ('withdrawal', 'transfer') NOT NULL,
+    reason             VARCHAR(255) NOT NULL,
+    leaving_date       DATE         NOT NULL,
+    destination_school VARCHAR(255) NOT NULL DEFAULT '',
+    last_class         VARCHAR(255) NOT NULL,
+    working_days       INT          NOT NULL DEFAULT 0,
+    days_present       INT          NOT NULL DEFAULT 0,
+    certificate_number VARCHAR(50)  NULL,
+    recorded_by        INT          NULL,
+    created_at         DATETIME     NOT NULL,
+    UNIQUE INDEX idx_student_withdrawals_certificate (certificate_number),
+    INDEX idx_student_withdrawals_student (student_id),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
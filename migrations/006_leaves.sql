@@ -0,0 +1,46 @@
+-- Weekly timetable, teacher leave requests and the substitutes covering their periods;
+CREATE TABLE IF NOT EXISTS timetable_slots (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    teacher_id  INT          NOT NULL,
+    class       VARCHAR(255) NOT NULL,
+    subject     VARCHAR(255) NOT NULL,
+    day_of_week TINYINT      NOT NULL,
+    period      TINYINT      NOT NULL,
+    start_time  TIME         NOT NULL,
+    end_time    TIME         NOT NULL,
+    UNIQUE INDEX idx_timetable_teacher_period (teacher_id, day_of_week, period),
+    UNIQUE INDEX idx_timetable_class_period (class, day_of_week, period),
+    FOREIGN KEY (teacher_id) REFERENCES teachers (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS leave_requests (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    teacher_id    INT          NOT NULL,
+    start_date    DATE         NOT NULL,
+    end_date      DATE         NOT NULL,
+    reason        VARCHAR(255) NOT NULL,
+    status        ENUM ('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    requested_by  INT          NOT NULL,
+    decided_by    INT          NULL,
+    decided_at    DATETIME     NULL,
+    decision_note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    DATETIME     NOT NULL,
+    INDEX idx_leave_requests_teacher_dates (teacher_id, start_date, end_date),
+    INDEX idx_leave_requests_status (status),
+    FOREIGN KEY (teacher_id) REFERENCES teachers (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS substitutions (
+    id                    INT AUTO_INCREMENT PRIMARY KEY,
+    leave_id              INT      NOT NULL,
+    slot_id               INT      NOT NULL,
+    date                  DATE     NOT NULL,
+    substitute_teacher_id INT      NOT NULL,
+    assigned_by           INT      NOT NULL,
+    created_at            DATETIME NOT NULL,
+    UNIQUE INDEX idx_substitutions_slot_date (slot_id, date),
+    INDEX idx_substitutions_teacher_date (substitute_teacher_id, date),
+    FOREIGN KEY (leave_id) REFERENCES leave_requests (id) ON DELETE CASCADE,
+    FOREIGN KEY (slot_id) REFERENCES timetable_slots (id) ON DELETE CASCADE,
+    FOREIGN KEY (substitute_teacher_id) REFERENCES teachers (id) ON DELETE CASCADE
+);
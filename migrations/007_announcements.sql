@@ -0,0 +1,44 @@
+-- Guardian accounts are execs with the guardian role, linked to their students;
+CREATE TABLE IF NOT EXISTS student_guardians (
+    student_id   INT         NOT NULL,
+    exec_id      INT         NOT NULL,
+    relationship VARCHAR(50) NOT NULL DEFAULT '',
+    PRIMARY KEY (student_id, exec_id),
+    INDEX idx_student_guardians_exec (exec_id),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE,
+    FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE CASCADE
+);
+
+-- Announcements, their audience (roles, classes or individual users) and read receipts;
+CREATE TABLE IF NOT EXISTS announcements (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    title       VARCHAR(255) NOT NULL,
+    body        TEXT         NOT NULL,
+    author_id   INT          NOT NULL,
+    author_role VARCHAR(50)  NOT NULL,
+    target_all  BOOLEAN      NOT NULL DEFAULT 0,
+    pinned      BOOLEAN      NOT NULL DEFAULT 0,
+    publish_at  DATETIME     NOT NULL,
+    expire_at   DATETIME     NULL,
+    created_at  DATETIME     NOT NULL,
+    updated_at  DATETIME     NULL,
+    INDEX idx_announcements_schedule (publish_at, expire_at),
+    INDEX idx_announcements_author (author_id)
+);
+
+CREATE TABLE IF NOT EXISTS announcement_targets (
+    announcement_id INT                            NOT NULL,
+    target_type     ENUM ('role', 'class', 'user') NOT NULL,
+    target_value    VARCHAR(255)                   NOT NULL,
+    PRIMARY KEY (announcement_id, target_type, target_value),
+    INDEX idx_announcement_targets_value (target_type, target_value),
+    FOREIGN KEY (announcement_id) REFERENCES announcements (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS announcement_reads (
+    announcement_id INT      NOT NULL,
+    user_id         INT      NOT NULL,
+    read_at         DATETIME NOT NULL,
+    PRIMARY KEY (announcement_id, user_id),
+    FOREIGN KEY (announcement_id) REFERENCES announcements (id) ON DELETE CASCADE
+);
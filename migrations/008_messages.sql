@@ -0,0 +1,49 @@
+-- Conversations between a staff member and a guardian about a student;
+CREATE TABLE IF NOT EXISTS message_threads (
+    id              INT AUTO_INCREMENT PRIMARY KEY,
+    student_id      INT          NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    staff_id        INT          NOT NULL,
+    guardian_id     INT          NOT NULL,
+    created_by      INT          NOT NULL,
+    locked          BOOLEAN      NOT NULL DEFAULT 0,
+    created_at      DATETIME     NOT NULL,
+    last_message_at DATETIME     NOT NULL,
+    INDEX idx_message_threads_staff (staff_id, last_message_at),
+    INDEX idx_message_threads_guardian (guardian_id, last_message_at),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE,
+    FOREIGN KEY (staff_id) REFERENCES execs (id),
+    FOREIGN KEY (guardian_id) REFERENCES execs (id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    thread_id   INT         NOT NULL,
+    sender_id   INT         NOT NULL,
+    sender_role VARCHAR(50) NOT NULL,
+    body        TEXT        NOT NULL,
+    hidden      BOOLEAN     NOT NULL DEFAULT 0,
+    hidden_by   INT         NULL,
+    created_at  DATETIME    NOT NULL,
+    INDEX idx_messages_thread (thread_id, id),
+    FOREIGN KEY (thread_id) REFERENCES message_threads (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS message_attachments (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    message_id   INT          NOT NULL,
+    file_name    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size         INT          NOT NULL,
+    data         MEDIUMBLOB   NOT NULL,
+    FOREIGN KEY (message_id) REFERENCES messages (id) ON DELETE CASCADE
+);
+
+-- Last message each participant has read, used for the unread counts;
+CREATE TABLE IF NOT EXISTS message_thread_reads (
+    thread_id            INT NOT NULL,
+    user_id              INT NOT NULL,
+    last_read_message_id INT NOT NULL,
+    PRIMARY KEY (thread_id, user_id),
+    FOREIGN KEY (thread_id) REFERENCES message_threads (id) ON DELETE CASCADE
+);
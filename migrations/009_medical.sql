@@ -0,0 +1,37 @@
+-- Medical profile per student, stored as encrypted JSON (MEDICAL_ENCRYPTION_KEY);
+CREATE TABLE IF NOT EXISTS student_medical_profiles (
+    student_id   INT        NOT NULL PRIMARY KEY,
+    profile_data MEDIUMTEXT NOT NULL,
+    updated_by   INT        NULL,
+    updated_at   DATETIME   NOT NULL,
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
+
+-- Visits to the school nurse; complaint, treatment and notes are encrypted;
+CREATE TABLE IF NOT EXISTS nurse_visits (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    student_id  INT      NOT NULL,
+    visited_at  DATETIME NOT NULL,
+    complaint   TEXT     NOT NULL,
+    treatment   TEXT     NOT NULL,
+    outcome     ENUM ('returned_to_class', 'rested', 'sent_home', 'referred', 'emergency') NOT NULL,
+    notes       TEXT     NOT NULL,
+    recorded_by INT      NOT NULL,
+    created_at  DATETIME NOT NULL,
+    INDEX idx_nurse_visits_student (student_id, visited_at),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
+
+-- Every read or write of medical data is logged here;
+CREATE TABLE IF NOT EXISTS medical_access_log (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    role        VARCHAR(50)  NOT NULL,
+    student_id  INT          NULL,
+    class       VARCHAR(255) NOT NULL DEFAULT '',
+    action      VARCHAR(50)  NOT NULL,
+    ip_address  VARCHAR(64)  NOT NULL,
+    accessed_at DATETIME     NOT NULL,
+    INDEX idx_medical_access_student (student_id, accessed_at),
+    INDEX idx_medical_access_user (user_id, accessed_at)
+);
@@ -0,0 +1,29 @@
+-- Files attached to students and teachers; the content lives in the storage backend (STORAGE_BACKEND / STORAGE_PATH);
+CREATE TABLE IF NOT EXISTS documents (
+    id              INT AUTO_INCREMENT PRIMARY KEY,
+    owner_type      ENUM ('student', 'teacher') NOT NULL,
+    owner_id        INT          NOT NULL,
+    category        VARCHAR(50)  NOT NULL,
+    title           VARCHAR(255) NOT NULL,
+    current_version INT          NOT NULL DEFAULT 1,
+    created_by      INT          NOT NULL,
+    created_at      DATETIME     NOT NULL,
+    updated_at      DATETIME     NOT NULL,
+    INDEX idx_documents_owner (owner_type, owner_id, category)
+);
+
+-- Every upload is kept; documents.current_version points at the latest one;
+CREATE TABLE IF NOT EXISTS document_versions (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    document_id  INT          NOT NULL,
+    version      INT          NOT NULL,
+    file_name    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size         BIGINT       NOT NULL,
+    checksum     CHAR(64)     NOT NULL,
+    storage_key  VARCHAR(512) NOT NULL,
+    uploaded_by  INT          NOT NULL,
+    uploaded_at  DATETIME     NOT NULL,
+    UNIQUE KEY uq_document_versions (document_id, version),
+    FOREIGN KEY (document_id) REFERENCES documents (id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+-- Current profile photo of a student or teacher; the JPEG variants (small, medium, large, original) live in storage below storage_prefix;
+CREATE TABLE IF NOT EXISTS profile_photos (
+    owner_type     ENUM ('student', 'teacher') NOT NULL,
+    owner_id       INT          NOT NULL,
+    version        INT          NOT NULL,
+    width          INT          NOT NULL,
+    height         INT          NOT NULL,
+    storage_prefix VARCHAR(255) NOT NULL,
+    updated_by     INT          NOT NULL,
+    updated_at     DATETIME     NOT NULL,
+    PRIMARY KEY (owner_type, owner_id)
+);
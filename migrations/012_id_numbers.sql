@@ -0,0 +1,15 @@
+-- Printable ids generated on creation from STUDENT_ID_FORMAT / STAFF_ID_FORMAT (e.g. {year}{class}{seq:04});
+-- Rows created before this migration get theirs through POST /students/assign-ids and POST /teachers/assign-ids;
+ALTER TABLE students
+    ADD COLUMN roll_number VARCHAR(32) NULL,
+    ADD UNIQUE KEY uq_students_roll_number (roll_number);
+
+ALTER TABLE teachers
+    ADD COLUMN employee_id VARCHAR(32) NULL,
+    ADD UNIQUE KEY uq_teachers_employee_id (employee_id);
+
+-- Last sequence value handed out per scope (table plus the id with everything but {seq} filled in);
+CREATE TABLE IF NOT EXISTS id_sequences (
+    scope      VARCHAR(100) NOT NULL PRIMARY KEY,
+    last_value INT          NOT NULL
+);
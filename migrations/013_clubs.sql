@@ -0,0 +1,64 @@
+-- Extracurricular clubs and activities;
+CREATE TABLE IF NOT EXISTS clubs (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL,
+    capacity    INT          NOT NULL,
+    active      BOOLEAN      NOT NULL DEFAULT 1,
+    created_at  DATETIME     NOT NULL,
+    UNIQUE KEY uq_clubs_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS club_supervisors (
+    club_id    INT NOT NULL,
+    teacher_id INT NOT NULL,
+    PRIMARY KEY (club_id, teacher_id),
+    FOREIGN KEY (club_id) REFERENCES clubs (id) ON DELETE CASCADE,
+    FOREIGN KEY (teacher_id) REFERENCES teachers (id) ON DELETE CASCADE
+);
+
+-- Weekly meetings (1 = Monday ... 7 = Sunday);
+CREATE TABLE IF NOT EXISTS club_schedules (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    club_id     INT          NOT NULL,
+    day_of_week TINYINT      NOT NULL,
+    start_time  TIME         NOT NULL,
+    end_time    TIME         NOT NULL,
+    location    VARCHAR(255) NOT NULL DEFAULT '',
+    FOREIGN KEY (club_id) REFERENCES clubs (id) ON DELETE CASCADE
+);
+
+-- One row per student and club; waitlisted rows are promoted by joined_at when a place frees up;
+CREATE TABLE IF NOT EXISTS club_members (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    club_id    INT                                   NOT NULL,
+    student_id INT                                   NOT NULL,
+    status     ENUM ('active', 'waitlisted', 'left') NOT NULL,
+    joined_at  DATETIME                              NOT NULL,
+    left_at    DATETIME                              NULL,
+    UNIQUE KEY uq_club_members (club_id, student_id),
+    INDEX idx_club_members_student (student_id),
+    FOREIGN KEY (club_id) REFERENCES clubs (id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS club_sessions (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    club_id      INT      NOT NULL,
+    session_date DATE     NOT NULL,
+    start_time   TIME     NOT NULL,
+    notes        TEXT     NOT NULL,
+    created_by   INT      NOT NULL,
+    created_at   DATETIME NOT NULL,
+    UNIQUE KEY uq_club_sessions (club_id, session_date, start_time),
+    FOREIGN KEY (club_id) REFERENCES clubs (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS club_attendance (
+    session_id INT                                   NOT NULL,
+    student_id INT                                   NOT NULL,
+    status     ENUM ('present', 'absent', 'excused') NOT NULL,
+    PRIMARY KEY (session_id, student_id),
+    FOREIGN KEY (session_id) REFERENCES club_sessions (id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE
+);
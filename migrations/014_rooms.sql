@@ -0,0 +1,46 @@
+-- Rooms and facilities (classrooms, labs, the auditorium, sports grounds) and their bookings;
+CREATE TABLE IF NOT EXISTS rooms (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    type       ENUM ('classroom', 'lab', 'auditorium', 'sports_ground', 'other') NOT NULL,
+    building   VARCHAR(255) NOT NULL DEFAULT '',
+    capacity   INT          NOT NULL,
+    restricted BOOLEAN      NOT NULL DEFAULT 0,
+    active     BOOLEAN      NOT NULL DEFAULT 1,
+    created_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_rooms_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS room_equipment (
+    room_id  INT          NOT NULL,
+    item     VARCHAR(255) NOT NULL,
+    quantity INT          NOT NULL DEFAULT 1,
+    PRIMARY KEY (room_id, item),
+    FOREIGN KEY (room_id) REFERENCES rooms (id) ON DELETE CASCADE
+);
+
+-- Bookings of restricted rooms wait for approval; occurrences of a recurring booking share series_id (the id of the first one);
+CREATE TABLE IF NOT EXISTS room_bookings (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    room_id       INT          NOT NULL,
+    title         VARCHAR(255) NOT NULL,
+    start_at      DATETIME     NOT NULL,
+    end_at        DATETIME     NOT NULL,
+    status        ENUM ('pending', 'approved', 'rejected', 'cancelled') NOT NULL,
+    series_id     INT          NULL,
+    booked_by     INT          NOT NULL,
+    decided_by    INT          NULL,
+    decided_at    DATETIME     NULL,
+    decision_note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    DATETIME     NOT NULL,
+    INDEX idx_room_bookings_room_time (room_id, start_at, end_at),
+    INDEX idx_room_bookings_series (series_id),
+    INDEX idx_room_bookings_status (status),
+    FOREIGN KEY (room_id) REFERENCES rooms (id) ON DELETE CASCADE
+);
+
+-- Timetable periods can take place in a room, which then counts as booked every week;
+ALTER TABLE timetable_slots
+    ADD COLUMN room_id INT NULL,
+    ADD UNIQUE INDEX idx_timetable_room_period (room_id, day_of_week, period),
+    ADD FOREIGN KEY (room_id) REFERENCES rooms (id) ON DELETE SET NULL;
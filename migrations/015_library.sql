@@ -0,0 +1,58 @@
+-- Library catalogue, copies, loans to students and teachers, and holds;
+CREATE TABLE IF NOT EXISTS books (
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    isbn           CHAR(13)     NOT NULL,
+    title          VARCHAR(255) NOT NULL,
+    author         VARCHAR(255) NOT NULL,
+    publisher      VARCHAR(255) NOT NULL DEFAULT '',
+    published_year SMALLINT     NULL,
+    subject        VARCHAR(255) NOT NULL DEFAULT '',
+    created_at     DATETIME     NOT NULL,
+    UNIQUE KEY uq_books_isbn (isbn),
+    INDEX idx_books_title (title)
+);
+
+CREATE TABLE IF NOT EXISTS book_copies (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    book_id     INT          NOT NULL,
+    barcode     VARCHAR(64)  NOT NULL,
+    location    VARCHAR(255) NOT NULL DEFAULT '',
+    status      ENUM ('available', 'on_loan', 'on_hold', 'lost', 'withdrawn') NOT NULL DEFAULT 'available',
+    acquired_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_book_copies_barcode (barcode),
+    INDEX idx_book_copies_book_status (book_id, status),
+    FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+-- Borrowers are students or teachers; fine_cents is fixed when the copy comes back;
+CREATE TABLE IF NOT EXISTS book_loans (
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    copy_id        INT      NOT NULL,
+    borrower_type  ENUM ('student', 'teacher') NOT NULL,
+    borrower_id    INT      NOT NULL,
+    checked_out_at DATETIME NOT NULL,
+    checked_out_by INT      NOT NULL,
+    due_date       DATE     NOT NULL,
+    renewals       INT      NOT NULL DEFAULT 0,
+    returned_at    DATETIME NULL,
+    fine_cents     INT      NOT NULL DEFAULT 0,
+    INDEX idx_book_loans_borrower (borrower_type, borrower_id, returned_at),
+    INDEX idx_book_loans_due (returned_at, due_date),
+    FOREIGN KEY (copy_id) REFERENCES book_copies (id) ON DELETE CASCADE
+);
+
+-- Holds queue per book; a returned copy is set aside (ready) for the oldest waiting hold until expires_at;
+CREATE TABLE IF NOT EXISTS book_holds (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    book_id       INT      NOT NULL,
+    borrower_type ENUM ('student', 'teacher') NOT NULL,
+    borrower_id   INT      NOT NULL,
+    status        ENUM ('waiting', 'ready', 'fulfilled', 'cancelled', 'expired') NOT NULL DEFAULT 'waiting',
+    copy_id       INT      NULL,
+    created_at    DATETIME NOT NULL,
+    ready_at      DATETIME NULL,
+    expires_at    DATETIME NULL,
+    INDEX idx_book_holds_book_status (book_id, status, created_at),
+    FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    FOREIGN KEY (copy_id) REFERENCES book_copies (id) ON DELETE SET NULL
+);
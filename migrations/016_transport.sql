@@ -0,0 +1,80 @@
+-- School transport: vehicles, drivers, routes with ordered stops, and student riders;
+CREATE TABLE IF NOT EXISTS transport_vehicles (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    registration VARCHAR(32)  NOT NULL,
+    model        VARCHAR(255) NOT NULL DEFAULT '',
+    capacity     INT          NOT NULL,
+    active       BOOLEAN      NOT NULL DEFAULT 1,
+    created_at   DATETIME     NOT NULL,
+    UNIQUE KEY uq_transport_vehicles_registration (registration)
+);
+
+CREATE TABLE IF NOT EXISTS transport_drivers (
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    first_name     VARCHAR(255) NOT NULL,
+    last_name      VARCHAR(255) NOT NULL,
+    phone          VARCHAR(32)  NOT NULL,
+    license_number VARCHAR(64)  NOT NULL,
+    license_expiry DATE         NOT NULL,
+    active         BOOLEAN      NOT NULL DEFAULT 1,
+    created_at     DATETIME     NOT NULL,
+    UNIQUE KEY uq_transport_drivers_license (license_number)
+);
+
+CREATE TABLE IF NOT EXISTS transport_routes (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    vehicle_id INT          NULL,
+    driver_id  INT          NULL,
+    active     BOOLEAN      NOT NULL DEFAULT 1,
+    created_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_transport_routes_name (name),
+    FOREIGN KEY (vehicle_id) REFERENCES transport_vehicles (id) ON DELETE SET NULL,
+    FOREIGN KEY (driver_id) REFERENCES transport_drivers (id) ON DELETE SET NULL
+);
+
+-- Stops in running order; pickup_time is the morning run, dropoff_time the optional afternoon run;
+CREATE TABLE IF NOT EXISTS transport_stops (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    route_id     INT          NOT NULL,
+    sequence     INT          NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    address      VARCHAR(255) NOT NULL DEFAULT '',
+    pickup_time  TIME         NOT NULL,
+    dropoff_time TIME         NULL,
+    UNIQUE KEY uq_transport_stops_sequence (route_id, sequence),
+    FOREIGN KEY (route_id) REFERENCES transport_routes (id) ON DELETE CASCADE
+);
+
+-- A student rides a route from a stop on the listed ISO weekdays (1 = Monday) between start_date and end_date;
+CREATE TABLE IF NOT EXISTS transport_assignments (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    student_id INT         NOT NULL,
+    route_id   INT         NOT NULL,
+    stop_id    INT         NOT NULL,
+    weekdays   VARCHAR(13) NOT NULL DEFAULT '1,2,3,4,5',
+    start_date DATE        NOT NULL,
+    end_date   DATE        NULL,
+    created_at DATETIME    NOT NULL,
+    INDEX idx_transport_assignments_route (route_id, start_date, end_date),
+    INDEX idx_transport_assignments_student (student_id, start_date),
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE,
+    FOREIGN KEY (route_id) REFERENCES transport_routes (id) ON DELETE CASCADE,
+    FOREIGN KEY (stop_id) REFERENCES transport_stops (id)
+);
+
+-- Boarding and alighting recorded on the bus against the day's manifest;
+CREATE TABLE IF NOT EXISTS transport_boardings (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    route_id    INT      NOT NULL,
+    student_id  INT      NOT NULL,
+    stop_id     INT      NULL,
+    event       ENUM ('boarded', 'alighted') NOT NULL,
+    run_date    DATE     NOT NULL,
+    recorded_at DATETIME NOT NULL,
+    recorded_by INT      NOT NULL,
+    INDEX idx_transport_boardings_run (route_id, run_date),
+    FOREIGN KEY (route_id) REFERENCES transport_routes (id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES students (id) ON DELETE CASCADE,
+    FOREIGN KEY (stop_id) REFERENCES transport_stops (id) ON DELETE SET NULL
+);
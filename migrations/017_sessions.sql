@@ -0,0 +1,18 @@
+-- Login sessions: one row per refresh token, only its SHA-256 hash is stored;
+-- A refresh marks the row used and adds its successor to the same family; presenting a used token again revokes the family;
+CREATE TABLE IF NOT EXISTS sessions (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    family_id   CHAR(32)     NOT NULL,
+    exec_id     INT          NOT NULL,
+    token_hash  CHAR(64)     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    used_at     DATETIME     NULL,
+    revoked_at  DATETIME     NULL,
+    user_agent  VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address  VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at  DATETIME     NOT NULL,
+    UNIQUE KEY uq_sessions_token_hash (token_hash),
+    INDEX idx_sessions_family (family_id),
+    INDEX idx_sessions_exec (exec_id),
+    FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE CASCADE
+);
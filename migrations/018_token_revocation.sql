@@ -0,0 +1,15 @@
+-- Server-side revocation of access tokens;
+-- Tokens issued before tokens_revoked_at (log out everywhere) or password_changed_at are rejected;
+ALTER TABLE execs
+    ADD COLUMN tokens_revoked_at DATETIME NULL;
+
+-- Individually revoked tokens (logout), kept until the token would have expired;
+-- Consulted when TOKEN_REVOCATION_STORE=db, so that a logout on one API instance holds on the others;
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        CHAR(32) PRIMARY KEY,
+    exec_id    INT      NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NOT NULL,
+    INDEX idx_revoked_tokens_expires (expires_at),
+    FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Free-text notes on a student, the one field teachers may edit for the students of their classes;
+ALTER TABLE students
+    ADD COLUMN notes TEXT NULL;
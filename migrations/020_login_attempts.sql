@@ -0,0 +1,8 @@
+-- Consecutive failed logins per username, kept whether or not an account has that username,
+-- so that lockouts do not reveal which usernames exist;
+CREATE TABLE IF NOT EXISTS login_attempts (
+    username       VARCHAR(255) PRIMARY KEY,
+    failures       INT          NOT NULL DEFAULT 0,
+    last_failed_at DATETIME     NOT NULL,
+    locked_until   DATETIME     NULL
+);
@@ -0,0 +1,22 @@
+-- TOTP two-factor authentication of execs;
+-- The secret is encrypted with TWO_FACTOR_ENCRYPTION_KEY; enabled stays FALSE until the first code is confirmed;
+-- last_used_step is the newest 30 second step a code was accepted for, so that a code cannot be replayed;
+CREATE TABLE IF NOT EXISTS exec_two_factor (
+    exec_id        INT          PRIMARY KEY,
+    secret         VARCHAR(255) NOT NULL,
+    enabled        BOOLEAN      NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT       NOT NULL DEFAULT 0,
+    created_at     DATETIME     NOT NULL,
+    enabled_at     DATETIME     NULL,
+    FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE CASCADE
+);
+
+-- One-time recovery codes, only their SHA-256 hash is stored;
+CREATE TABLE IF NOT EXISTS exec_recovery_codes (
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    exec_id   INT      NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at   DATETIME NULL,
+    INDEX idx_recovery_codes_exec (exec_id, code_hash),
+    FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- Explicit link from a teacher record to the exec account the teacher logs in with;
+-- Scoping by class (students, enrollments, incidents, announcements) follows this key instead of matching emails at query time;
+-- Existing rows are linked once through their email, new ones when the teacher or the exec is created;
+ALTER TABLE teachers
+    ADD COLUMN exec_id INT NULL,
+    ADD UNIQUE KEY uq_teachers_exec_id (exec_id),
+    ADD FOREIGN KEY (exec_id) REFERENCES execs (id) ON DELETE SET NULL;
+
+UPDATE teachers t
+    JOIN (SELECT email, MIN(id) AS id FROM teachers GROUP BY email) first_teacher ON first_teacher.id = t.id
+    JOIN execs e ON e.email = t.email
+SET t.exec_id = e.id
+WHERE t.exec_id IS NULL;
@@ -0,0 +1,25 @@
+-- Control database (DB_NAME): the registry of schools and the trust-level super-admins;
+-- The numbered migrations in migrations/ are applied to every school database;
+-- An existing single-school deployment joins by registering its database as a tenant and moving its uploads below tenants/<slug>/;
+CREATE TABLE IF NOT EXISTS tenants (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    slug       VARCHAR(63)  NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    db_name    VARCHAR(64)  NOT NULL,
+    host       VARCHAR(255) NULL,
+    active     BOOLEAN      NOT NULL DEFAULT 1,
+    created_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_tenants_slug (slug),
+    UNIQUE KEY uq_tenants_db_name (db_name),
+    UNIQUE KEY uq_tenants_host (host)
+);
+
+CREATE TABLE IF NOT EXISTS super_admins (
+    id              INT AUTO_INCREMENT PRIMARY KEY,
+    username        VARCHAR(255) NOT NULL,
+    email           VARCHAR(255) NOT NULL,
+    password        VARCHAR(255) NOT NULL,
+    inactive_status BOOLEAN      NOT NULL DEFAULT 0,
+    created_at      DATETIME     NOT NULL,
+    UNIQUE KEY uq_super_admins_username (username)
+);
@@ -0,0 +1,126 @@
+package rbac
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"os"
+	"schoolManagement/pkg/utils"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultPolicy - Role to permission mapping shipped with the server, used unless RBAC_POLICY_FILE points to another one;
+//
+//go:embed policy.json
+var defaultPolicy []byte
+
+// Policy - Permissions granted to each role; a permission is "<resource>:<action>",
+// "<resource>:*" grants every action on a resource and "*" grants everything;
+type Policy struct {
+	Roles map[string][]string `json:"roles"`
+}
+
+var current = struct {
+	sync.RWMutex
+	policy Policy
+}{}
+
+// declared - Permissions required by at least one route or handler scope, used to expand wildcards into effective permissions;
+var declared = struct {
+	sync.Mutex
+	permissions map[string]bool
+}{permissions: map[string]bool{}}
+
+func init() {
+	policy, err := parsePolicy(defaultPolicy)
+	if err != nil {
+		panic(err)
+	}
+	current.policy = policy
+}
+
+func parsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	err := json.Unmarshal(data, &policy)
+	if err != nil {
+		return Policy{}, utils.HandleError(err, "Err: Invalid RBAC policy!")
+	}
+	if len(policy.Roles) == 0 {
+		return Policy{}, utils.HandleError(errors.New("no roles in policy"), "Err: RBAC policy grants no roles!")
+	}
+	return policy, nil
+}
+
+// LoadPolicy - Replaces the built-in policy with the one in RBAC_POLICY_FILE, when set;
+func LoadPolicy() error {
+	path := os.Getenv("RBAC_POLICY_FILE")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return utils.HandleError(err, "Err: Cannot read RBAC policy file!")
+	}
+
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return err
+	}
+
+	current.Lock()
+	current.policy = policy
+	current.Unlock()
+	return nil
+}
+
+// Declare - Records a permission a route requires;
+func Declare(permission string) {
+	declared.Lock()
+	declared.permissions[permission] = true
+	declared.Unlock()
+}
+
+// Scope - Declares a permission that a handler checks itself, e.g. to widen a route from the caller's own records to everyone's, and returns it;
+func Scope(permission string) string {
+	Declare(permission)
+	return permission
+}
+
+// grants - Whether a policy entry covers the permission;
+func grants(pattern, permission string) bool {
+	if pattern == "*" || pattern == permission {
+		return true
+	}
+	resource, found := strings.CutSuffix(pattern, ":*")
+	return found && strings.HasPrefix(permission, resource+":")
+}
+
+// Can - Whether the role holds the permission;
+func Can(role, permission string) bool {
+	current.RLock()
+	defer current.RUnlock()
+	for _, pattern := range current.policy.Roles[role] {
+		if grants(pattern, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions - Effective permissions of a role: the declared permissions it holds, sorted;
+func Permissions(role string) []string {
+	declared.Lock()
+	defer declared.Unlock()
+
+	permissions := []string{}
+	for permission := range declared.permissions {
+		if Can(role, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
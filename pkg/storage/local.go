@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"schoolManagement/pkg/utils"
+	"strings"
+)
+
+// LocalStore - Store backed by a directory on the local filesystem;
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore - Creates the root directory if needed and returns a store rooted there;
+func NewLocalStore(root string) (*LocalStore, error) {
+	err := os.MkdirAll(root, 0o750)
+	if err != nil {
+		return nil, utils.HandleError(err, "Err: Cannot create storage directory!")
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// path - Maps a key to a file below the root, rejecting keys that would escape it;
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", utils.HandleError(errors.New("invalid key "+key), "Err: Invalid storage key!")
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+// Put - Writes to a temporary file first so readers never see a partial object;
+func (s *LocalStore) Put(key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot create storage directory!")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot store file!")
+	}
+	defer func() {
+		// No-op once the file has been renamed;
+		_ = os.Remove(tmp.Name())
+	}()
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, utils.HandleError(err, "Err: Cannot store file!")
+	}
+	err = tmp.Close()
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot store file!")
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return 0, utils.HandleError(err, "Err: Cannot store file!")
+	}
+	return n, nil
+}
+
+func (s *LocalStore) Get(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, utils.HandleError(err, "Err: Cannot read file!")
+	}
+	return file, nil
+}
+
+func (s *LocalStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return utils.HandleError(err, "Err: Cannot delete file!")
+	}
+	return nil
+}
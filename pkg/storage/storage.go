@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"schoolManagement/pkg/utils"
+	"strings"
+)
+
+// ErrNotFound - Returned by Get and Delete when no object is stored under the key;
+var ErrNotFound = errors.New("object not found")
+
+// Store - A place to keep binary objects (uploaded files) by key;
+// Keys are slash separated relative paths such as "documents/12/v1-ab34.pdf";
+// A local S3-compatible stand-in only has to implement these three methods;
+type Store interface {
+	// Put - Stores the content read from r under key and returns the number of bytes written;
+	Put(key string, r io.Reader) (int64, error)
+	// Get - Opens the object stored under key; the caller must close it;
+	Get(key string) (io.ReadCloser, error)
+	// Delete - Removes the object stored under key;
+	Delete(key string) error
+}
+
+// New - Returns the store of a school (tenant), configured through STORAGE_BACKEND (default "local");
+// The local backend keeps files below STORAGE_PATH (default "uploads"); every school's keys live under tenants/<slug>/;
+func New(tenant string) (Store, error) {
+	if tenant == "" {
+		return nil, utils.HandleError(errors.New("no tenant"), "Err: No school selected!")
+	}
+
+	var store Store
+	backend := os.Getenv("STORAGE_BACKEND")
+	switch backend {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		local, err := NewLocalStore(root)
+		if err != nil {
+			return nil, err
+		}
+		store = local
+	default:
+		return nil, utils.HandleError(errors.New("unknown storage backend "+backend), "Err: Storage backend not supported!")
+	}
+	return prefixedStore{store: store, prefix: "tenants/" + tenant + "/"}, nil
+}
+
+// prefixedStore - Keeps the keys of one school below its own prefix of a shared store;
+type prefixedStore struct {
+	store  Store
+	prefix string
+}
+
+// key - Prefixes a key, rejecting keys that would climb out of the school's prefix;
+func (s prefixedStore) key(key string) (string, error) {
+	clean := path.Clean(key)
+	if key == "" || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", utils.HandleError(errors.New("invalid key "+key), "Err: Invalid storage key!")
+	}
+	return s.prefix + clean, nil
+}
+
+func (s prefixedStore) Put(key string, r io.Reader) (int64, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.Put(key, r)
+}
+
+func (s prefixedStore) Get(key string) (io.ReadCloser, error) {
+	key, err := s.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Get(key)
+}
+
+func (s prefixedStore) Delete(key string) error {
+	key, err := s.key(key)
+	if err != nil {
+		return err
+	}
+	return s.store.Delete(key)
+}
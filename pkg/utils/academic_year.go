@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// AcademicYear - Returns the academic year (its starting calendar year) that the given date falls in;
+// The year starts in ACADEMIC_YEAR_START_MONTH (1-12, defaults to June);
+func AcademicYear(t time.Time) string {
+	startMonth, err := strconv.Atoi(os.Getenv("ACADEMIC_YEAR_START_MONTH"))
+	if err != nil || startMonth < 1 || startMonth > 12 {
+		startMonth = int(time.June)
+	}
+
+	year := t.Year()
+	if int(t.Month()) < startMonth {
+		year--
+	}
+	return strconv.Itoa(year)
+}
@@ -1,15 +1,47 @@
 package utils
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
 
 type ContextKey string
 
+// AuthorizeUser - Checks the role against the allowed roles; a super-admin is allowed wherever an admin is;
 func AuthorizeUser(userRole string, allowedRoles ...string) (bool, error) {
 	for _, role := range allowedRoles {
-		if role == userRole {
+		if role == userRole || (role == "admin" && userRole == "superadmin") {
 			return true, nil
 		}
 	}
 
 	return false, HandleError(errors.New("user role not allowed"), "Err : Unauthorized user!")
 }
+
+// RoleFromContext - Role that the JWT middleware stored in the context ("" when none);
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(ContextKey("role")).(string)
+	return role
+}
+
+// UserIdFromContext - User id that the JWT middleware stored in the context (0 if missing);
+func UserIdFromContext(ctx context.Context) int {
+	uid, _ := ctx.Value(ContextKey("userid")).(string)
+	id, err := strconv.Atoi(uid)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// GetUserRole - Returns the role that the JWT middleware stored in the request context;
+func GetUserRole(r *http.Request) string {
+	return RoleFromContext(r.Context())
+}
+
+// GetUserId - Returns the user id that the JWT middleware stored in the request context (0 if missing);
+func GetUserId(r *http.Request) int {
+	return UserIdFromContext(r.Context())
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+)
+
+// code128Patterns - Bar / space widths (in modules) of every Code 128 symbol value; 103-105 are the start codes, 106 is stop;
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const code128StartB = 104
+const code128Stop = 106
+
+// Code128 - Encodes printable ASCII text as a Code 128 (code set B) barcode;
+// The result alternates bar and space widths in modules, starting with a bar, without the quiet zones;
+func Code128(text string) ([]int, error) {
+	if text == "" {
+		return nil, errors.New("Err: Nothing to encode in the barcode!")
+	}
+
+	symbols := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range text {
+		if r < 32 || r > 126 {
+			return nil, errors.New("Err: Barcode text must be printable ASCII!")
+		}
+		value := int(r) - 32
+		symbols = append(symbols, value)
+		checksum += (i + 1) * value
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	var widths []int
+	for _, symbol := range symbols {
+		for _, w := range code128Patterns[symbol] {
+			widths = append(widths, int(w-'0'))
+		}
+	}
+	return widths, nil
+}
+
+// Barcode128 - Draws text as a Code 128 barcode with its bottom-left corner at (x, y), fitted into width;
+func (p *PDFPage) Barcode128(x, y, width, height float64, text string) error {
+	widths, err := Code128(text)
+	if err != nil {
+		return err
+	}
+
+	modules := 0
+	for _, w := range widths {
+		modules += w
+	}
+	module := width / float64(modules)
+
+	for i, w := range widths {
+		// Even positions are bars, odd positions the spaces between them;
+		if i%2 == 0 {
+			p.Rect(x, y, float64(w)*module, height, true)
+		}
+		x += float64(w) * module
+	}
+	return nil
+}
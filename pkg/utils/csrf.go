@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// CSRF double-submit token: sent as a cookie readable by the page's script, which echoes it in the header on every state-changing request;
+const (
+	CSRFCookieName = "XSRF-TOKEN"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// SetCSRFCookie - Issues a new CSRF token cookie expiring at the given time and returns the token;
+func SetCSRFCookie(w http.ResponseWriter, expires time.Time) (string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", HandleError(err, "Err: Cannot generate CSRF token!")
+	}
+
+	token := hex.EncodeToString(tokenBytes)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: false, // The page reads it to send it back in the header;
+		Secure:   true,
+		Expires:  expires,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+// ClearCSRFCookie - Removes the CSRF token cookie;
+func ClearCSRFCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    "",
+		Path:     "/",
+		Secure:   true,
+		Expires:  time.Unix(0, 0),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
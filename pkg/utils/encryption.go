@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+)
+
+// encryptedPrefix - Marks values produced by EncryptString (and the format version);
+const encryptedPrefix = "v1:"
+
+// EncryptionKey - Reads a base64 encoded 32 byte AES-256 key from the given environment variable;
+func EncryptionKey(envName string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(os.Getenv(envName))
+	if err != nil || len(key) != 32 {
+		return nil, HandleError(errors.New("invalid key"), "Err: Encryption key "+envName+" is not configured!")
+	}
+	return key, nil
+}
+
+// EncryptString - Encrypts a value with AES-GCM; the result is "v1:" followed by base64(nonce | ciphertext);
+func EncryptString(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", HandleError(err, "Err: Encryption failed!")
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString - Reverses EncryptString; tampered values or a wrong key fail authentication;
+func DecryptString(key []byte, ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, encryptedPrefix) {
+		return "", HandleError(errors.New("unknown format"), "Err: Decryption failed!")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedPrefix))
+	if err != nil {
+		return "", HandleError(err, "Err: Decryption failed!")
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", HandleError(errors.New("short ciphertext"), "Err: Decryption failed!")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", HandleError(err, "Err: Decryption failed!")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, HandleError(err, "Err: Invalid encryption key!")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, HandleError(err, "Err: Invalid encryption key!")
+	}
+	return gcm, nil
+}
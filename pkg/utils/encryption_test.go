@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, 32)
+}
+
+func TestEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"32 bytes", base64.StdEncoding.EncodeToString(testKey(1)), false},
+		{"unset", "", true},
+		{"16 bytes", base64.StdEncoding.EncodeToString(testKey(1)[:16]), true},
+		{"not base64", "not-a-key!", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENCRYPTION_KEY", tt.value)
+			key, err := EncryptionKey("TEST_ENCRYPTION_KEY")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncryptionKey error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(key, testKey(1)) {
+				t.Errorf("EncryptionKey = %x, want %x", key, testKey(1))
+			}
+		})
+	}
+}
+
+func TestEncryptDecryptString(t *testing.T) {
+	for _, plaintext := range []string{"", "Asthma, carries an inhaler", "Allergie: Erdnüsse 🥜"} {
+		ciphertext, err := EncryptString(testKey(1), plaintext)
+		if err != nil {
+			t.Fatalf("EncryptString(%q): %v", plaintext, err)
+		}
+		if !strings.HasPrefix(ciphertext, "v1:") {
+			t.Errorf("ciphertext %q has no version prefix", ciphertext)
+		}
+		if plaintext != "" && strings.Contains(ciphertext, plaintext) {
+			t.Errorf("ciphertext %q contains the plaintext", ciphertext)
+		}
+
+		got, err := DecryptString(testKey(1), ciphertext)
+		if err != nil {
+			t.Fatalf("DecryptString(%q): %v", ciphertext, err)
+		}
+		if got != plaintext {
+			t.Errorf("DecryptString = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestEncryptStringRandomNonce(t *testing.T) {
+	first, _ := EncryptString(testKey(1), "same value")
+	second, _ := EncryptString(testKey(1), "same value")
+	if first == second {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptStringRejects(t *testing.T) {
+	ciphertext, err := EncryptString(testKey(1), "Diabetic")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "v1:"))
+	sealed[len(sealed)-1] ^= 0xff
+	tampered := "v1:" + base64.StdEncoding.EncodeToString(sealed)
+
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext string
+	}{
+		{"wrong key", testKey(2), ciphertext},
+		{"tampered", testKey(1), tampered},
+		{"missing prefix", testKey(1), strings.TrimPrefix(ciphertext, "v1:")},
+		{"not base64", testKey(1), "v1:%%%"},
+		{"shorter than the nonce", testKey(1), "v1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"invalid key size", testKey(1)[:10], ciphertext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecryptString(tt.key, tt.ciphertext)
+			if err == nil {
+				t.Error("DecryptString succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Default schemes, overridable with STUDENT_ID_FORMAT and STAFF_ID_FORMAT;
+const DefaultStudentIdFormat = "{year}{class}{seq:04}"
+const DefaultStaffIdFormat = "T{year}{seq:04}"
+
+// idToken - Placeholders of an ID format: {year}, {yy}, {class} and {seq} / {seq:NN} (zero padded to NN digits);
+var idToken = regexp.MustCompile(`\{(year|yy|class|seq)(?::(\d{1,2}))?\}`)
+
+// IdScheme - A parsed ID format such as "{year}{class}{seq:04}";
+type IdScheme struct {
+	format string
+}
+
+// ParseIdScheme - Validates a format; it must contain exactly one {seq} token so that generated ids can be unique;
+func ParseIdScheme(format string) (IdScheme, error) {
+	seqCount := 0
+	for _, match := range idToken.FindAllStringSubmatch(format, -1) {
+		if match[1] == "seq" {
+			seqCount++
+		}
+	}
+	if seqCount != 1 {
+		return IdScheme{}, errors.New("Err: ID format must contain exactly one {seq} placeholder!")
+	}
+
+	rest := idToken.ReplaceAllString(format, "")
+	if strings.ContainsAny(rest, "{}") {
+		return IdScheme{}, errors.New("Err: ID format contains an unknown placeholder!")
+	}
+	return IdScheme{format: format}, nil
+}
+
+// IdSchemeFromEnv - The scheme configured in the environment variable, or the fallback when unset or invalid;
+func IdSchemeFromEnv(envName, fallback string) IdScheme {
+	format := strings.TrimSpace(os.Getenv(envName))
+	if format != "" {
+		scheme, err := ParseIdScheme(format)
+		if err == nil {
+			return scheme
+		}
+		HandleError(err, "Err: Invalid "+envName+", using "+fallback+"!")
+	}
+	scheme, _ := ParseIdScheme(fallback)
+	return scheme
+}
+
+// Scope - The id with every placeholder but {seq} filled in; each scope has its own sequence;
+func (s IdScheme) Scope(year, class string) string {
+	return idToken.ReplaceAllStringFunc(s.format, func(token string) string {
+		match := idToken.FindStringSubmatch(token)
+		if match[1] == "seq" {
+			return "{seq}"
+		}
+		return s.value(match[1], year, class)
+	})
+}
+
+// Format - Renders the id for the given sequence number;
+func (s IdScheme) Format(year, class string, seq int) string {
+	return idToken.ReplaceAllStringFunc(s.format, func(token string) string {
+		match := idToken.FindStringSubmatch(token)
+		if match[1] != "seq" {
+			return s.value(match[1], year, class)
+		}
+		width, _ := strconv.Atoi(match[2])
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+func (s IdScheme) value(token, year, class string) string {
+	switch token {
+	case "year":
+		return year
+	case "yy":
+		if len(year) >= 2 {
+			return year[len(year)-2:]
+		}
+		return year
+	case "class":
+		// Only letters and digits, so that "10-A" and "10 A" both give "10A";
+		var b strings.Builder
+		for _, r := range strings.ToUpper(class) {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package utils
+
+import "testing"
+
+func TestParseIdScheme(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{DefaultStudentIdFormat, false},
+		{DefaultStaffIdFormat, false},
+		{"{yy}-{class}-{seq}", false},
+		{"S{seq:6}", false},
+		{"{year}{class}", true},
+		{"{seq}{seq:04}", true},
+		{"{year}{seq}{term}", true},
+		{"{year}{seq:04", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseIdScheme(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIdScheme(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIdSchemeFormat(t *testing.T) {
+	tests := []struct {
+		format    string
+		year      string
+		class     string
+		seq       int
+		want      string
+		wantScope string
+	}{
+		{DefaultStudentIdFormat, "2024", "6B", 7, "20246B0007", "20246B{seq}"},
+		{DefaultStaffIdFormat, "2024", "6B", 12, "T20240012", "T2024{seq}"},
+		{"{yy}-{class}-{seq}", "2024", "10-a", 3, "24-10A-3", "24-10A-{seq}"},
+		{"{class}{seq:03}", "2024", "10 A", 1234, "10A1234", "10A{seq}"},
+		{"{yy}{seq:02}", "7", "", 5, "705", "7{seq}"},
+	}
+
+	for _, tt := range tests {
+		scheme, err := ParseIdScheme(tt.format)
+		if err != nil {
+			t.Fatalf("ParseIdScheme(%q): %v", tt.format, err)
+		}
+		if got := scheme.Format(tt.year, tt.class, tt.seq); got != tt.want {
+			t.Errorf("%q.Format(%q, %q, %d) = %q, want %q", tt.format, tt.year, tt.class, tt.seq, got, tt.want)
+		}
+		if got := scheme.Scope(tt.year, tt.class); got != tt.wantScope {
+			t.Errorf("%q.Scope(%q, %q) = %q, want %q", tt.format, tt.year, tt.class, got, tt.wantScope)
+		}
+	}
+}
+
+func TestIdSchemeFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"unset", "", "T20240001"},
+		{"valid", "EMP{seq:03}", "EMP001"},
+		{"invalid falls back", "EMP{year}", "T20240001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STAFF_ID_FORMAT", tt.value)
+			got := IdSchemeFromEnv("STAFF_ID_FORMAT", DefaultStaffIdFormat).Format("2024", "", 1)
+			if got != tt.want {
+				t.Errorf("Format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
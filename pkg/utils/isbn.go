@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// NormalizeISBN - Checks an ISBN-10 or ISBN-13 (hyphens and spaces allowed) and returns it as 13 digits;
+// ISBN-10s are converted so that both forms of the same book compare equal;
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		if r == 'x' {
+			return 'X'
+		}
+		return r
+	}, isbn)
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, r := range digits {
+			value := int(r - '0')
+			if r == 'X' && i == 9 {
+				value = 10
+			} else if r < '0' || r > '9' {
+				return "", errors.New("Err: Invalid ISBN!")
+			}
+			sum += value * (10 - i)
+		}
+		if sum%11 != 0 {
+			return "", errors.New("Err: Invalid ISBN check digit!")
+		}
+
+		converted := "978" + digits[:9]
+		return converted + string(rune('0'+isbn13CheckDigit(converted))), nil
+	case 13:
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return "", errors.New("Err: Invalid ISBN!")
+			}
+		}
+		if int(digits[12]-'0') != isbn13CheckDigit(digits[:12]) {
+			return "", errors.New("Err: Invalid ISBN check digit!")
+		}
+		return digits, nil
+	}
+	return "", errors.New("Err: ISBN must have 10 or 13 digits!")
+}
+
+// isbn13CheckDigit - Check digit for the first 12 digits of an ISBN-13 (weights 1 and 3);
+func isbn13CheckDigit(digits string) int {
+	sum := 0
+	for i, r := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn    string
+		want    string
+		wantErr bool
+	}{
+		{"978-0-306-40615-7", "9780306406157", false},
+		{"9780306406157", "9780306406157", false},
+		{"0-306-40615-2", "9780306406157", false},
+		{"0306406152", "9780306406157", false},
+		{"0 306 40615 2", "9780306406157", false},
+		{"0-8044-2957-X", "9780804429573", false},
+		{"0-8044-2957-x", "9780804429573", false},
+		{"978-0-306-40615-8", "", true},
+		{"0-306-40615-3", "", true},
+		{"X-306-40615-2", "", true},
+		{"978-0-306-4061A-7", "", true},
+		{"12345", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeISBN(tt.isbn)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeISBN(%q) error = %v, wantErr %v", tt.isbn, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, want %q", tt.isbn, got, tt.want)
+		}
+	}
+}
@@ -1,31 +1,43 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
-func SignToken(userId, username, role string) (string, error) {
+// SignToken - Signs a session token; tid is the school (tenant) slug the user belongs to, empty for super-admins;
+// Every token carries a unique id (jti), by which it can be revoked before it expires, and its issue time (iat);
+func SignToken(userId, username, role, tenant string) (string, error) {
 	jwtSecret := os.Getenv("JWT_SECRET")
-	jwtExpiry := os.Getenv("JWT_EXPIRY")
 
+	jti := make([]byte, 16)
+	_, err := rand.Read(jti)
+	if err != nil {
+		return "", HandleError(err, "Err: Cannot generate token id!")
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"uid":      userId,
 		"username": username,
 		"role":     role,
+		"tid":      tenant,
+		"jti":      hex.EncodeToString(jti),
+		"iat":      jwt.NewNumericDate(now),
 	}
 
-	if jwtExpiry != "" {
-		duration, err := time.ParseDuration(jwtExpiry)
-		if err != nil {
-			return "", HandleError(err, "Err: JWT expiry parsing failed!")
-		}
-
-		claims["exp"] = jwt.NewNumericDate(time.Now().Add(duration))
-	} else {
-		claims["exp"] = jwt.NewNumericDate(time.Now().Add(15 * time.Minute))
+	duration, err := AccessTokenTTL()
+	if err != nil {
+		return "", err
 	}
+	claims["exp"] = jwt.NewNumericDate(now.Add(duration))
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signedToken, err := token.SignedString([]byte(jwtSecret))
@@ -34,3 +46,73 @@ func SignToken(userId, username, role string) (string, error) {
 	}
 	return signedToken, nil
 }
+
+// AccessTokenTTL - Lifetime of an access token; JWT_EXPIRY (a Go duration such as "15m"), 15 minutes by default;
+// The Bearer cookie expires together with its token, sessions are kept alive by the refresh token;
+func AccessTokenTTL() (time.Duration, error) {
+	jwtExpiry := os.Getenv("JWT_EXPIRY")
+	if jwtExpiry == "" {
+		return 15 * time.Minute, nil
+	}
+
+	duration, err := time.ParseDuration(jwtExpiry)
+	if err != nil {
+		return 0, HandleError(err, "Err: JWT expiry parsing failed!")
+	}
+	return duration, nil
+}
+
+// ParseToken - Verifies the signature and expiry of a token signed by SignToken and returns its claims;
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	jwtSecret := os.Getenv("JWT_SECRET")
+
+	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || !parsedToken.Valid {
+		return nil, errors.New("Token invalid!")
+	}
+	return claims, nil
+}
+
+// Authentication sources of a request, stored in the context under "auth_source" by the JWT middleware;
+const (
+	AuthSourceHeader = "header"
+	AuthSourceCookie = "cookie"
+)
+
+// RequestToken - Returns the access token of a request and where it came from: an "Authorization: Bearer" header or the Bearer cookie;
+// When both are present AUTH_TOKEN_PRECEDENCE ("header", the default, or "cookie") decides which one is used;
+func RequestToken(r *http.Request) (string, string) {
+	var headerToken, cookieToken string
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		headerToken = strings.TrimSpace(value)
+	}
+	cookie, err := r.Cookie("Bearer")
+	if err == nil {
+		cookieToken = cookie.Value
+	}
+
+	if cookieToken != "" && (headerToken == "" || os.Getenv("AUTH_TOKEN_PRECEDENCE") == AuthSourceCookie) {
+		return cookieToken, AuthSourceCookie
+	}
+	if headerToken != "" {
+		return headerToken, AuthSourceHeader
+	}
+	return "", ""
+}
+
+// GetAuthSource - Returns how the request was authenticated (AuthSourceHeader or AuthSourceCookie), "" when it was not;
+func GetAuthSource(r *http.Request) string {
+	source, _ := r.Context().Value(ContextKey("auth_source")).(string)
+	return source
+}
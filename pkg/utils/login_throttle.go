@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Failed logins are counted per username (in the school's database) and per client IP (here, in memory);
+// Each failure of a username past the first few delays its next attempt a little longer, and too many lock it out for a while;
+// A client IP is only locked out, once it reaches LoginIPMaxFailures within a lockout window: a whole school often shares
+// one address, so its staff are not slowed down by the typos of their colleagues;
+
+// loginFreeFailures - Failures tolerated before attempts are delayed;
+const loginFreeFailures = 2
+
+// loginMaxBackoff - Longest delay imposed between two attempts;
+const loginMaxBackoff = time.Minute
+
+// LoginMaxFailures - Consecutive failures that lock a username; LOGIN_MAX_FAILURES, 5 by default;
+func LoginMaxFailures() int {
+	limit, err := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES"))
+	if err != nil || limit <= 0 {
+		return 5
+	}
+	return limit
+}
+
+// LoginIPMaxFailures - Failures that lock a client IP; LOGIN_IP_MAX_FAILURES, 20 by default as a school often shares one address;
+func LoginIPMaxFailures() int {
+	limit, err := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_FAILURES"))
+	if err != nil || limit <= 0 {
+		return 20
+	}
+	return limit
+}
+
+// LoginLockoutDuration - How long a lockout lasts; LOGIN_LOCKOUT (a Go duration), 15 minutes by default;
+func LoginLockoutDuration() (time.Duration, error) {
+	lockout := os.Getenv("LOGIN_LOCKOUT")
+	if lockout == "" {
+		return 15 * time.Minute, nil
+	}
+
+	duration, err := time.ParseDuration(lockout)
+	if err != nil {
+		return 0, HandleError(err, "Err: Login lockout parsing failed!")
+	}
+	return duration, nil
+}
+
+// LoginBackoff - Delay required after the given number of consecutive failures: none at first, then 1s doubling up to a minute;
+func LoginBackoff(failures int) time.Duration {
+	if failures <= loginFreeFailures {
+		return 0
+	}
+
+	delay := time.Second << (failures - loginFreeFailures - 1)
+	if delay <= 0 || delay > loginMaxBackoff {
+		return loginMaxBackoff
+	}
+	return delay
+}
+
+// ClientIP - Address of the client without the port, which changes with every connection;
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+type ipLoginFailures struct {
+	count       int
+	lastFailed  time.Time
+	lockedUntil time.Time
+}
+
+// loginFailuresByIP - Consecutive failed logins per client IP;
+var loginFailuresByIP = struct {
+	sync.Mutex
+	entries map[string]*ipLoginFailures
+	swept   time.Time
+}{entries: map[string]*ipLoginFailures{}}
+
+// LoginIPRetryAfter - How long the client IP stays locked out (0 when it may try now);
+func LoginIPRetryAfter(ip string) time.Duration {
+	loginFailuresByIP.Lock()
+	defer loginFailuresByIP.Unlock()
+
+	entry, ok := loginFailuresByIP.entries[ip]
+	if !ok {
+		return 0
+	}
+
+	now := time.Now()
+	if now.Before(entry.lockedUntil) {
+		return entry.lockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// RecordLoginIPFailure - Counts a failed login from the client IP, locking it out once it reaches the limit;
+func RecordLoginIPFailure(ip string) error {
+	lockout, err := LoginLockoutDuration()
+	if err != nil {
+		return err
+	}
+
+	loginFailuresByIP.Lock()
+	defer loginFailuresByIP.Unlock()
+
+	now := time.Now()
+	entry, ok := loginFailuresByIP.entries[ip]
+	if !ok {
+		entry = &ipLoginFailures{}
+		loginFailuresByIP.entries[ip] = entry
+	}
+	// Failures older than a lockout window no longer count;
+	if now.Sub(entry.lastFailed) > lockout {
+		entry.count = 0
+	}
+	entry.count++
+	entry.lastFailed = now
+	if entry.count >= LoginIPMaxFailures() {
+		entry.count = 0
+		entry.lockedUntil = now.Add(lockout)
+	}
+
+	// Addresses quiet for longer than a lockout are forgotten, at most once a minute;
+	if now.Sub(loginFailuresByIP.swept) > time.Minute {
+		for key, e := range loginFailuresByIP.entries {
+			if now.Sub(e.lastFailed) > lockout && now.After(e.lockedUntil) {
+				delete(loginFailuresByIP.entries, key)
+			}
+		}
+		loginFailuresByIP.swept = now
+	}
+	return nil
+}
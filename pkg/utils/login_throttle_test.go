@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{8, 32 * time.Second},
+		{9, time.Minute},
+		{20, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := LoginBackoff(tt.failures); got != tt.want {
+			t.Errorf("LoginBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLockoutDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 15 * time.Minute, false},
+		{"30m", 30 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"fifteen", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LOGIN_LOCKOUT", tt.value)
+		got, err := LoginLockoutDuration()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("LoginLockoutDuration(%q) = %v, %v, want %v, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoginMaxFailures(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int
+		wantIP int
+	}{
+		{"", 5, 20},
+		{"3", 3, 3},
+		{"0", 5, 20},
+		{"-1", 5, 20},
+		{"many", 5, 20},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LOGIN_MAX_FAILURES", tt.value)
+		t.Setenv("LOGIN_IP_MAX_FAILURES", tt.value)
+		if got := LoginMaxFailures(); got != tt.want {
+			t.Errorf("LoginMaxFailures(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+		if got := LoginIPMaxFailures(); got != tt.wantIP {
+			t.Errorf("LoginIPMaxFailures(%q) = %d, want %d", tt.value, got, tt.wantIP)
+		}
+	}
+}
+
+func TestLoginIPFailures(t *testing.T) {
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "4")
+	t.Setenv("LOGIN_LOCKOUT", "1h")
+	ip := "192.0.2.10"
+	t.Cleanup(func() {
+		loginFailuresByIP.Lock()
+		delete(loginFailuresByIP.entries, ip)
+		loginFailuresByIP.Unlock()
+	})
+
+	record := func() {
+		if err := RecordLoginIPFailure(ip); err != nil {
+			t.Fatalf("RecordLoginIPFailure: %v", err)
+		}
+	}
+
+	// Failures below the limit do not slow the address down, it may be shared by a whole school;
+	for i := 0; i < 3; i++ {
+		record()
+	}
+	if wait := LoginIPRetryAfter(ip); wait != 0 {
+		t.Errorf("after 3 failures wait = %v, want 0", wait)
+	}
+
+	// Reaching the limit locks the address for the lockout duration;
+	record()
+	if wait := LoginIPRetryAfter(ip); wait <= 59*time.Minute || wait > time.Hour {
+		t.Errorf("after 4 failures wait = %v, want about 1h", wait)
+	}
+
+	// Other addresses are not affected;
+	if wait := LoginIPRetryAfter("192.0.2.11"); wait != 0 {
+		t.Errorf("other address wait = %v, want 0", wait)
+	}
+}
+
+func TestLoginIPFailuresExpire(t *testing.T) {
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "3")
+	t.Setenv("LOGIN_LOCKOUT", "1h")
+	ip := "192.0.2.20"
+	t.Cleanup(func() {
+		loginFailuresByIP.Lock()
+		delete(loginFailuresByIP.entries, ip)
+		loginFailuresByIP.Unlock()
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := RecordLoginIPFailure(ip); err != nil {
+			t.Fatalf("RecordLoginIPFailure: %v", err)
+		}
+	}
+
+	// Move the failures out of the lockout window;
+	loginFailuresByIP.Lock()
+	loginFailuresByIP.entries[ip].lastFailed = time.Now().Add(-2 * time.Hour)
+	loginFailuresByIP.Unlock()
+
+	if err := RecordLoginIPFailure(ip); err != nil {
+		t.Fatalf("RecordLoginIPFailure: %v", err)
+	}
+	if wait := LoginIPRetryAfter(ip); wait != 0 {
+		t.Errorf("failures outside the window still counted, wait = %v", wait)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"github.com/go-mail/mail/v2"
+	"os"
+	"strconv"
+)
+
+// SendMail - Sends an HTML email through the configured SMTP server;
+// MAIL_HOST, MAIL_PORT and MAIL_FROM default to the local development mail catcher;
+func SendMail(to, subject, body string) error {
+	host := os.Getenv("MAIL_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+
+	port, err := strconv.Atoi(os.Getenv("MAIL_PORT"))
+	if err != nil {
+		port = 1025
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "schooladmin@school.com"
+	}
+
+	m := mail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	d := mail.NewDialer(host, port, os.Getenv("MAIL_USERNAME"), os.Getenv("MAIL_PASSWORD"))
+	return d.DialAndSend(m)
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"strings"
+)
+
+// PDF page sizes in points (1/72 inch);
+const (
+	PDFA4Width  = 595.28
+	PDFA4Height = 841.89
+)
+
+// PDFDocument - A minimal PDF writer (text with the standard Helvetica fonts, lines, rectangles and JPEG images);
+// It only covers what the generated school documents need and has no external dependencies;
+type PDFDocument struct {
+	width  float64
+	height float64
+	pages  []*PDFPage
+	images []pdfImage
+}
+
+// pdfImage - A JPEG embedded as is (DCTDecode), shared by every page that draws it;
+type pdfImage struct {
+	data       []byte
+	width      int
+	height     int
+	colorSpace string
+}
+
+// PDFPage - A single page; coordinates are in points with the origin at the bottom-left corner;
+type PDFPage struct {
+	content bytes.Buffer
+}
+
+// NewPDFDocument - Creates an empty document whose pages have the given size;
+func NewPDFDocument(width, height float64) *PDFDocument {
+	return &PDFDocument{width: width, height: height}
+}
+
+// AddPage - Appends a new blank page and returns it;
+func (d *PDFDocument) AddPage() *PDFPage {
+	page := &PDFPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// AddJPEG - Embeds a JPEG image and returns the name to draw it with and its size in pixels;
+func (d *PDFDocument) AddJPEG(data []byte) (string, int, int, error) {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, HandleError(err, "Err: Invalid JPEG image!")
+	}
+
+	img := pdfImage{data: data, width: config.Width, height: config.Height}
+	switch config.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "/DeviceGray"
+	case color.YCbCrModel:
+		img.colorSpace = "/DeviceRGB"
+	default:
+		return "", 0, 0, HandleError(errors.New("unsupported color model"), "Err: Only RGB and grayscale JPEG images are supported!")
+	}
+
+	d.images = append(d.images, img)
+	return fmt.Sprintf("Im%d", len(d.images)), img.width, img.height, nil
+}
+
+// Image - Draws an image added with AddJPEG, scaled into the w x h box with its bottom-left corner at (x, y);
+func (p *PDFPage) Image(name string, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", w, h, x, y, name)
+}
+
+// Text - Writes a single line of text at (x, y); bold selects Helvetica-Bold;
+func (p *PDFPage) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(text))
+}
+
+// Line - Draws a straight line;
+func (p *PDFPage) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// Rect - Draws a rectangle outline, or a filled black rectangle when fill is true;
+func (p *PDFPage) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%.2f %.2f %.2f %.2f re %s\n", x, y, w, h, op)
+}
+
+// Bytes - Serialises the document;
+func (d *PDFDocument) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Object layout: 1 catalog, 2 pages tree, 3-4 fonts, then the images, then a page and content object per page;
+	firstPage := 5 + len(d.images)
+
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+i*2))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	var xObjects []string
+	for i, img := range d.images {
+		xObjects = append(xObjects, fmt.Sprintf("/Im%d %d 0 R", i+1, 5+i))
+		objects = append(objects, fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream",
+			img.width, img.height, img.colorSpace, len(img.data), img.data))
+	}
+
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xObjects) > 0 {
+		resources += " /XObject << " + strings.Join(xObjects, " ") + " >>"
+	}
+
+	for i, page := range d.pages {
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << %s >> /Contents %d 0 R >>", d.width, d.height, resources, firstPage+1+i*2))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfEscape - Escapes a string for a PDF literal; characters outside Latin-1 are replaced with '?';
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		case r > 127:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
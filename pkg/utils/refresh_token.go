@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// RefreshTokenTTL - Lifetime of a refresh token; REFRESH_TOKEN_EXPIRY (a Go duration), 30 days by default;
+// Every refresh issues a new token, so the session lives on as long as it is used within this window;
+func RefreshTokenTTL() (time.Duration, error) {
+	expiry := os.Getenv("REFRESH_TOKEN_EXPIRY")
+	if expiry == "" {
+		return 30 * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(expiry)
+	if err != nil {
+		return 0, HandleError(err, "Err: Refresh token expiry parsing failed!")
+	}
+	return duration, nil
+}
+
+// NewRefreshToken - Returns a random refresh token of the school and the hash it is stored under;
+// The token reads "<school>.<hex>", so it can be refreshed without an access token telling the school;
+func NewRefreshToken(tenant string) (string, string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", "", HandleError(err, "Err: Cannot generate refresh token!")
+	}
+
+	hashedToken := sha256.Sum256(tokenBytes)
+	return tenant + "." + hex.EncodeToString(tokenBytes), hex.EncodeToString(hashedToken[:]), nil
+}
+
+// ParseRefreshToken - Splits a refresh token into its school and the hash it is stored under;
+func ParseRefreshToken(token string) (string, string, error) {
+	tenant, value, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", HandleError(errors.New("malformed refresh token"), "Err: Invalid refresh token!")
+	}
+
+	tokenBytes, err := hex.DecodeString(value)
+	if err != nil || len(tokenBytes) != 32 {
+		return "", "", HandleError(errors.New("malformed refresh token"), "Err: Invalid refresh token!")
+	}
+
+	hashedToken := sha256.Sum256(tokenBytes)
+	return tenant, hex.EncodeToString(hashedToken[:]), nil
+}
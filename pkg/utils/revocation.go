@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// revokedTokens - Ids (jti) of access tokens revoked before they expired, kept until their expiry;
+var revokedTokens = struct {
+	sync.Mutex
+	entries map[string]time.Time
+	swept   time.Time
+}{entries: map[string]time.Time{}}
+
+// RevokeToken - Rejects the access token with the given jti until it expires;
+func RevokeToken(jti string, expires time.Time) {
+	if jti == "" {
+		return
+	}
+
+	revokedTokens.Lock()
+	defer revokedTokens.Unlock()
+	revokedTokens.entries[jti] = expires
+
+	// Expired entries are swept at most once a minute;
+	now := time.Now()
+	if now.Sub(revokedTokens.swept) > time.Minute {
+		for id, expiry := range revokedTokens.entries {
+			if now.After(expiry) {
+				delete(revokedTokens.entries, id)
+			}
+		}
+		revokedTokens.swept = now
+	}
+}
+
+// IsTokenRevoked - Whether the access token with the given jti was revoked in this process;
+func IsTokenRevoked(jti string) bool {
+	revokedTokens.Lock()
+	defer revokedTokens.Unlock()
+	expiry, ok := revokedTokens.entries[jti]
+	return ok && time.Now().Before(expiry)
+}
+
+// RevocationStoreInDb - Whether revoked tokens are also recorded in the database (TOKEN_REVOCATION_STORE=db),
+// which is needed when several API instances serve the same school;
+func RevocationStoreInDb() bool {
+	return os.Getenv("TOKEN_REVOCATION_STORE") == "db"
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// downloadSecret - Key used to sign download URLs; DOWNLOAD_URL_SECRET, falling back to JWT_SECRET;
+func downloadSecret() []byte {
+	secret := os.Getenv("DOWNLOAD_URL_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	return []byte(secret)
+}
+
+func downloadSignature(path string, expires int64) string {
+	mac := hmac.New(sha256.New, downloadSecret())
+	mac.Write([]byte(path + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignDownloadPath - Returns the expiry (unix seconds) and signature that authorize a GET of path until then;
+func SignDownloadPath(path string, ttl time.Duration) (int64, string) {
+	expires := time.Now().Add(ttl).Unix()
+	return expires, downloadSignature(path, expires)
+}
+
+// VerifyDownloadPath - Checks a signature produced by SignDownloadPath and that it has not expired;
+func VerifyDownloadPath(path, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("Err: Invalid download link!")
+	}
+	if !hmac.Equal([]byte(downloadSignature(path, expiresAt)), []byte(signature)) {
+		return errors.New("Err: Invalid download link!")
+	}
+	if time.Now().Unix() > expiresAt {
+		return errors.New("Err: Download link expired!")
+	}
+	return nil
+}
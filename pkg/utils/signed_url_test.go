@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyDownloadPath(t *testing.T) {
+	t.Setenv("DOWNLOAD_URL_SECRET", "download-secret")
+
+	path := "/documents/12/versions/3/download"
+	expires, signature := SignDownloadPath(path, time.Minute)
+	expiresStr := strconv.FormatInt(expires, 10)
+
+	// The last hex digit flipped to another value, whatever it was;
+	altered := signature[:len(signature)-1] + "0"
+	if signature[len(signature)-1] == '0' {
+		altered = signature[:len(signature)-1] + "1"
+	}
+
+	past := time.Now().Add(-time.Minute).Unix()
+	expiredSignature := downloadSignature(path, past)
+
+	tests := []struct {
+		name      string
+		path      string
+		expires   string
+		signature string
+		wantErr   string
+	}{
+		{"valid", path, expiresStr, signature, ""},
+		{"other path", "/documents/13/versions/3/download", expiresStr, signature, "Err: Invalid download link!"},
+		{"extended expiry", path, strconv.FormatInt(expires+3600, 10), signature, "Err: Invalid download link!"},
+		{"altered signature", path, expiresStr, altered, "Err: Invalid download link!"},
+		{"missing signature", path, expiresStr, "", "Err: Invalid download link!"},
+		{"expiry not a number", path, "tomorrow", signature, "Err: Invalid download link!"},
+		{"expired", path, strconv.FormatInt(past, 10), expiredSignature, "Err: Download link expired!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyDownloadPath(tt.path, tt.expires, tt.signature)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifyDownloadPath error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("VerifyDownloadPath error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadSecretFallback(t *testing.T) {
+	path := "/photos/students/4"
+
+	t.Setenv("DOWNLOAD_URL_SECRET", "")
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	expires, signature := SignDownloadPath(path, time.Minute)
+	expiresStr := strconv.FormatInt(expires, 10)
+	if err := VerifyDownloadPath(path, expiresStr, signature); err != nil {
+		t.Fatalf("link signed with JWT_SECRET: %v", err)
+	}
+
+	// Setting a dedicated secret invalidates links signed with the fallback;
+	t.Setenv("DOWNLOAD_URL_SECRET", "download-secret")
+	if err := VerifyDownloadPath(path, expiresStr, signature); err == nil {
+		t.Error("link signed with JWT_SECRET still valid after DOWNLOAD_URL_SECRET was set")
+	}
+}
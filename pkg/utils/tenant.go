@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithTenant - Returns a copy of ctx resolved to the given school (tenant) slug;
+func WithTenant(ctx context.Context, slug string) context.Context {
+	return context.WithValue(ctx, ContextKey("tenant"), slug)
+}
+
+// TenantFromContext - Slug of the school the request was resolved to ("" when none);
+func TenantFromContext(ctx context.Context) string {
+	slug, _ := ctx.Value(ContextKey("tenant")).(string)
+	return slug
+}
+
+// GetTenant - Returns the school slug that the tenant and JWT middlewares stored in the request context;
+func GetTenant(r *http.Request) string {
+	return TenantFromContext(r.Context())
+}
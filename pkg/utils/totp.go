@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Time-based one-time passwords (RFC 6238) as shown by authenticator apps: HMAC-SHA1, 6 digits, a new code every 30 seconds;
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpModulo = 1000000
+	// totpSkew - Steps accepted either side of the current one, for clocks that drift apart;
+	totpSkew = 1
+)
+
+// totpEncoding - Base32 without padding, the form authenticator apps expect secrets in;
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret - Returns a random 160 bit secret, base32 encoded;
+func NewTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", HandleError(err, "Err: Cannot generate two-factor secret!")
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPCode - The code of a base32 secret for the given time step (RFC 4226 HOTP with the step as counter);
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", HandleError(err, "Err: Invalid two-factor secret!")
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation: 31 bits read at the offset given by the low nibble of the last byte;
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo), nil
+}
+
+// TOTPStep - Time step a moment falls in;
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// VerifyTOTP - Checks a code against the steps around now and returns the step it matched;
+// Steps up to lastStep were already used and are refused, so that a code cannot be replayed;
+func VerifyTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPURI - otpauth:// URI of a secret, which authenticator apps import (usually from a QR code);
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return fmt.Sprintf("otpauth://totp/%s?secret=%s&issuer=%s&algorithm=SHA1&digits=%d&period=%d",
+		label, secret, url.PathEscape(issuer), totpDigits, totpPeriod)
+}
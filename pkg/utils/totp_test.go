@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret - The SHA-1 seed of the RFC 6238 test vectors ("12345678901234567890"), base32 encoded;
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to the 6 digits authenticator apps show;
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeLowercaseSecret(t *testing.T) {
+	got, err := TOTPCode(strings.ToLower(rfcSecret), 1)
+	if err != nil || got != "287082" {
+		t.Errorf("TOTPCode with lowercase secret = %s, %v, want 287082", got, err)
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	_, err := TOTPCode("not base32!", 1)
+	if err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0) // step 37037037, code 050471
+	current := TOTPStep(now)
+
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOk   bool
+	}{
+		{"current step", "050471", 0, current, true},
+		{"spaces around and inside", " 050 471 ", 0, current, true},
+		{"previous step within skew", codeAt(current - 1), 0, current - 1, true},
+		{"next step within skew", codeAt(current + 1), 0, current + 1, true},
+		{"two steps behind", codeAt(current - 2), 0, 0, false},
+		{"two steps ahead", codeAt(current + 2), 0, 0, false},
+		{"replayed code", "050471", current, 0, false},
+		{"newer code after an older one was used", codeAt(current + 1), current, current + 1, true},
+		{"wrong code", "123456", 0, 0, false},
+		{"too short", "05047", 0, 0, false},
+		{"too long", "0504711", 0, 0, false},
+		{"empty", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOk || step != tt.wantStep {
+				t.Errorf("VerifyTOTP(%q, lastStep %d) = %d, %v, want %d, %v", tt.code, tt.lastStep, step, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("NewTOTPSecret: %v", err)
+	}
+	// 160 bits are 32 base32 characters without padding;
+	if len(secret) != 32 {
+		t.Errorf("len(secret) = %d, want 32", len(secret))
+	}
+	_, err = TOTPCode(secret, 1)
+	if err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	got := TOTPURI("School Management", "jane@example.com", rfcSecret)
+	want := "otpauth://totp/School%20Management:jane@example.com?secret=" + rfcSecret + "&issuer=School%20Management&algorithm=SHA1&digits=6&period=30"
+	if got != want {
+		t.Errorf("TOTPURI = %s, want %s", got, want)
+	}
+}
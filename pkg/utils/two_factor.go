@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount - Recovery codes issued at enrollment, each usable once in place of a TOTP code;
+const RecoveryCodeCount = 10
+
+// loginChallengeTTL - Time given to enter the second factor after the password;
+const loginChallengeTTL = 5 * time.Minute
+
+// TwoFactorRequired - Whether the role has to use two-factor authentication; TWO_FACTOR_ROLES, a comma separated list of roles;
+func TwoFactorRequired(role string) bool {
+	for _, required := range strings.Split(os.Getenv("TWO_FACTOR_ROLES"), ",") {
+		if strings.TrimSpace(required) == role && role != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorIssuer - Name authenticator apps show next to the code; TWO_FACTOR_ISSUER, "School Management" by default;
+func TwoFactorIssuer() string {
+	issuer := os.Getenv("TWO_FACTOR_ISSUER")
+	if issuer == "" {
+		return "School Management"
+	}
+	return issuer
+}
+
+// NewRecoveryCodes - Returns RecoveryCodeCount random codes ("xxxx-xxxx-xxxx-xxxx", 80 bits each) and the hashes they are stored under;
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		_, err := rand.Read(raw)
+		if err != nil {
+			return nil, nil, HandleError(err, "Err: Cannot generate recovery codes!")
+		}
+
+		encoded := strings.ToLower(totpEncoding.EncodeToString(raw))
+		codes[i] = encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode - Hash a recovery code is stored and looked up under; case, spaces and dashes do not matter;
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hashed := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hashed[:])
+}
+
+// loginChallengeSignature - Binds a challenge to the exec, the school and the expiry;
+func loginChallengeSignature(execId int, tenant string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte(fmt.Sprintf("login-challenge\n%s\n%d\n%d", tenant, execId, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignLoginChallenge - Token proving the password of an exec was checked, exchanged for a session with the second factor;
+// It reads "<exec id>.<expiry>.<signature>" and is no access token, so it opens no other route;
+func SignLoginChallenge(execId int, tenant string) (string, time.Time) {
+	expires := time.Now().Add(loginChallengeTTL)
+	return fmt.Sprintf("%d.%d.%s", execId, expires.Unix(), loginChallengeSignature(execId, tenant, expires.Unix())), expires
+}
+
+// ParseLoginChallenge - Checks a challenge from SignLoginChallenge for the school and returns the exec id it was issued to;
+func ParseLoginChallenge(challenge, tenant string) (int, error) {
+	invalid := errors.New("Err: Login challenge invalid or expired, log in again!")
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 3 {
+		return 0, invalid
+	}
+	execId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, invalid
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	if !hmac.Equal([]byte(loginChallengeSignature(execId, tenant, expires)), []byte(parts[2])) || time.Now().Unix() > expires {
+		return 0, invalid
+	}
+	return execId, nil
+}